@@ -1,30 +1,99 @@
 package app
 
 import (
+	"fmt"
+	"io"
 	"log"
 	"net/http"
+	"path/filepath"
 	"strconv"
 
-	"github.com/brianvoe/gofakeit/v7"
 	"github.com/paqstd-team/fake-cli/config"
 	"github.com/paqstd-team/fake-cli/handler"
+	"github.com/paqstd-team/fake-cli/openapi"
 )
 
-// Run constructs the HTTP server using the provided config path and port.
-// It seeds the random generator to make responses deterministic across runs.
-func Run(configPath string, port int) (*http.Server, error) {
-	gofakeit.Seed(0)
+// Option overrides a setting of the loaded config, e.g. from a command-line flag.
+type Option func(*config.Config)
+
+// WithSeed overrides the config seed unless seed is empty.
+func WithSeed(seed string) Option {
+	return func(cfg *config.Config) {
+		if seed != "" {
+			cfg.Seed = config.Seed(seed)
+		}
+	}
+}
 
+// Run constructs the HTTP server using the provided config path and port.
+// Responses are deterministic for a given seed, see config.Seed.
+func Run(configPath string, port int, opts ...Option) (*http.Server, error) {
 	cfg, err := config.LoadConfigFromFile(configPath)
 	if err != nil {
 		return nil, err
 	}
 
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if err := importOpenAPI(&cfg, cfg.Dir); err != nil {
+		return nil, err
+	}
+	if err := config.Validate(cfg); err != nil {
+		return nil, err
+	}
+
+	h := handler.MakeHandler(cfg)
 	srv := &http.Server{
 		Addr:    ":" + strconv.Itoa(port),
-		Handler: handler.MakeHandler(cfg),
+		Handler: h,
+	}
+	// The handler keeps the journal file open until the server shuts down
+	if closer, ok := h.(io.Closer); ok {
+		srv.RegisterOnShutdown(func() { closer.Close() })
 	}
 
 	log.Printf("Starting server on %v", srv.Addr)
 	return srv, nil
 }
+
+// importOpenAPI adds the operations of the config's OpenAPI document as
+// endpoints. Endpoints in the config take precedence over imported ones with
+// the same method and URL.
+func importOpenAPI(cfg *config.Config, dir string) error {
+	if cfg.OpenAPI == "" {
+		return nil
+	}
+	path := cfg.OpenAPI
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	doc, err := openapi.Load(path)
+	if err != nil {
+		return fmt.Errorf("openapi: %w", err)
+	}
+	imported, err := openapi.Endpoints(doc)
+	if err != nil {
+		return fmt.Errorf("openapi: %w", err)
+	}
+
+	configured := make(map[string]bool, len(cfg.Endpoints))
+	for _, endpoint := range cfg.Endpoints {
+		configured[endpointKey(endpoint)] = true
+	}
+	for _, endpoint := range imported {
+		if !configured[endpointKey(endpoint)] {
+			cfg.Endpoints = append(cfg.Endpoints, endpoint)
+		}
+	}
+	log.Printf("Imported %d endpoints from %s", len(imported), path)
+	return nil
+}
+
+func endpointKey(endpoint config.Endpoint) string {
+	method := endpoint.Type
+	if method == "" {
+		method = http.MethodGet
+	}
+	return method + " " + endpoint.URL
+}
@@ -3,8 +3,11 @@ package app
 import (
 	"context"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 )
@@ -123,7 +126,8 @@ func TestApp_RunMissingConfig(t *testing.T) {
 }
 
 func TestApp_RunLifecycle(t *testing.T) {
-	cfgPath := filepath.Join(t.TempDir(), "config.json")
+	dir := t.TempDir()
+	cfgPath := filepath.Join(dir, "config.json")
 	config := `{
 		"endpoints": [
 			{
@@ -134,7 +138,8 @@ func TestApp_RunLifecycle(t *testing.T) {
 				}
 			}
 		],
-		"cache": 3
+		"cache": 3,
+		"journal": {"file": ` + strconv.Quote(filepath.Join(dir, "requests.jsonl")) + `}
 	}`
 
 	if err := os.WriteFile(cfgPath, []byte(config), 0o600); err != nil {
@@ -161,3 +166,193 @@ func TestApp_RunLifecycle(t *testing.T) {
 		t.Errorf("Failed to shutdown server: %v", err)
 	}
 }
+
+func TestApp_RunWithSeed(t *testing.T) {
+	cfgPath := filepath.Join(t.TempDir(), "config.json")
+	config := `{
+		"seed": 1,
+		"endpoints": [
+			{
+				"url": "/api/users",
+				"response": {"id": "uuid"}
+			}
+		]
+	}`
+	if err := os.WriteFile(cfgPath, []byte(config), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	body := func(srv *http.Server) string {
+		w := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/users", nil))
+		return w.Body.String()
+	}
+
+	fromConfig, err := Run(cfgPath, 0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	emptyOverride, err := Run(cfgPath, 0, WithSeed(""))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	overridden, err := Run(cfgPath, 0, WithSeed("2"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if body(fromConfig) != body(emptyOverride) {
+		t.Error("Expected an empty seed option to keep the config seed")
+	}
+	if body(fromConfig) == body(overridden) {
+		t.Error("Expected the seed option to override the config seed")
+	}
+
+	if _, err := Run(cfgPath, 0, WithSeed("not-a-seed")); err == nil {
+		t.Error("Expected error for invalid seed option")
+	}
+}
+
+func TestApp_RunWithOpenAPI(t *testing.T) {
+	dir := t.TempDir()
+	spec := `
+openapi: 3.0.3
+info: {title: Users, version: "1"}
+paths:
+  /users:
+    get:
+      responses:
+        200:
+          description: OK
+          content:
+            application/json:
+              schema:
+                type: array
+                items:
+                  type: object
+                  properties:
+                    id: {type: string, format: uuid}
+  /health:
+    get:
+      responses:
+        200:
+          description: OK
+          content:
+            application/json:
+              schema:
+                type: object
+                properties:
+                  status: {type: string}
+`
+	if err := os.WriteFile(filepath.Join(dir, "users.yaml"), []byte(spec), 0o600); err != nil {
+		t.Fatalf("Failed to write spec: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "broken.yaml"), []byte(`openapi: 3.0.0
+paths: {/a: {get: {responses: {200: {$ref: "#/components/responses/Missing"}}}}}`), 0o600); err != nil {
+		t.Fatalf("Failed to write spec: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		openapi     string
+		expectError bool
+	}{
+		{name: "relative_path", openapi: "users.yaml"},
+		{name: "absolute_path", openapi: filepath.Join(dir, "users.yaml")},
+		{name: "missing_document", openapi: "missing.yaml", expectError: true},
+		{name: "unresolvable_reference", openapi: "broken.yaml", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfgPath := filepath.Join(dir, tt.name+".json")
+			config := `{
+				"openapi": "` + tt.openapi + `",
+				"endpoints": [{"url": "/health", "response": {"status": "ok"}}]
+			}`
+			if err := os.WriteFile(cfgPath, []byte(config), 0o600); err != nil {
+				t.Fatalf("Failed to write config: %v", err)
+			}
+
+			srv, err := Run(cfgPath, 0)
+			if tt.expectError {
+				if err == nil {
+					t.Fatal("Expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			w := httptest.NewRecorder()
+			srv.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users", nil))
+			if w.Code != http.StatusOK || !strings.HasPrefix(w.Body.String(), `[{"id":"`) {
+				t.Errorf("Expected imported list endpoint, got %d %s", w.Code, w.Body.String())
+			}
+
+			// The configured endpoint wins over the imported one
+			w = httptest.NewRecorder()
+			srv.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+			if w.Body.String() != `{"status":"ok"}` {
+				t.Errorf("Expected configured endpoint, got %s", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestApp_RunWithBodyFiles(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "fixtures"), 0o700); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	files := map[string]string{"page.html": "<p>page</p>", "case.txt": "case", "variant.txt": "variant", "state.txt": "state", "step.txt": "step", "fault.txt": "fault", "missing.txt": "missing", "method.txt": "method"}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, "fixtures", name), []byte(content), 0o600); err != nil {
+			t.Fatalf("Failed to write fixture: %v", err)
+		}
+	}
+	config := `{
+		"scenarios": [{"name": "flow", "initial": "start"}],
+		"fault": {"rate": 0, "body_file": "fixtures/fault.txt"},
+		"errors": {"not_found": {"body_file": "fixtures/missing.txt"}, "method_not_allowed": {"body_file": "fixtures/method.txt"}},
+		"endpoints": [
+			{"url": "/page", "body_file": "fixtures/page.html", "cases": [{"when": {"query": {"case": {"exists": true}}}, "body_file": "fixtures/case.txt"}], "variants": {"other": {"body_file": "fixtures/variant.txt"}}},
+			{"url": "/state", "scenario": "flow", "states": {"start": {"body_file": "fixtures/state.txt"}}},
+			{"url": "/step", "sequence": [{"body_file": "fixtures/step.txt"}]},
+			{"url": "/fault", "fault": {"body_file": "fixtures/fault.txt"}},
+			{"url": "/absolute", "body_file": "` + filepath.Join(dir, "fixtures", "page.html") + `"}
+		]
+	}`
+	cfgPath := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(cfgPath, []byte(config), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	srv, err := Run(cfgPath, 0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		method   string
+		target   string
+		expected string
+	}{
+		{http.MethodGet, "/page", "<p>page</p>"},
+		{http.MethodGet, "/page?case", "case"},
+		{http.MethodGet, "/page?_variant=other", "variant"},
+		{http.MethodGet, "/state", "state"},
+		{http.MethodGet, "/step", "step"},
+		{http.MethodGet, "/fault", "fault"},
+		{http.MethodGet, "/absolute", "<p>page</p>"},
+		{http.MethodGet, "/nowhere", "missing"},
+		{http.MethodPost, "/page", "method"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(w, httptest.NewRequest(tt.method, tt.target, nil))
+		if w.Body.String() != tt.expected {
+			t.Errorf("%s %s: expected %q, got %d %q", tt.method, tt.target, tt.expected, w.Code, w.Body.String())
+		}
+	}
+}
@@ -51,3 +51,24 @@ func (c *Cache) Set(key string, value interface{}) {
 	c.data[key] = value
 	c.requests[key] = 0
 }
+
+// Entries returns a copy of the cached values by key.
+func (c *Cache) Entries() map[string]interface{} {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	entries := make(map[string]interface{}, len(c.data))
+	for key, value := range c.data {
+		entries[key] = value
+	}
+	return entries
+}
+
+// Clear removes every cached value.
+func (c *Cache) Clear() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.data = make(map[string]interface{})
+	c.requests = make(map[string]int)
+}
@@ -211,3 +211,27 @@ func TestCache_ConcurrentAccess(t *testing.T) {
 		<-done
 	}
 }
+
+func TestCache_EntriesAndClear(t *testing.T) {
+	cache := NewCache(-1)
+
+	cache.Set("first", "1")
+	cache.Set("second", "2")
+
+	entries := cache.Entries()
+	if len(entries) != 2 || entries["first"] != "1" || entries["second"] != "2" {
+		t.Fatalf("Expected both entries, got %v", entries)
+	}
+
+	// The copy is not affected by later changes
+	cache.Clear()
+	if len(entries) != 2 {
+		t.Errorf("Expected copy to keep its entries, got %v", entries)
+	}
+	if _, ok := cache.Get("first"); ok {
+		t.Error("Expected cache miss after clear")
+	}
+	if len(cache.Entries()) != 0 {
+		t.Errorf("Expected no entries after clear, got %v", cache.Entries())
+	}
+}
@@ -0,0 +1,38 @@
+package config
+
+import "strings"
+
+// DefaultAdminPrefix is where the admin API is served unless configured otherwise.
+const DefaultAdminPrefix = "/__admin"
+
+// Admin configures the runtime control API.
+type Admin struct {
+	// Prefix is the path the API is served under, DefaultAdminPrefix when empty.
+	Prefix string `json:"prefix"`
+	// Disabled turns the API off entirely.
+	Disabled bool `json:"disabled"`
+}
+
+// PathPrefix returns the path the API is served under.
+func (a Admin) PathPrefix() string {
+	if a.Prefix == "" {
+		return DefaultAdminPrefix
+	}
+	return strings.TrimSuffix(a.Prefix, "/")
+}
+
+// Covers reports whether path belongs to the API: the prefix itself or a path
+// below it, but not /__administrator for the prefix /__admin.
+func (a Admin) Covers(path string) bool {
+	prefix := a.PathPrefix()
+	return !a.Disabled && (path == prefix || strings.HasPrefix(path, prefix+"/"))
+}
+
+// Fault makes requests fail on purpose. Its reply is sent instead of the
+// regular one, with status 500 unless it sets another.
+type Fault struct {
+	Reply
+	// Rate is the share of requests that fail, from 0 to 1. Every request
+	// fails when it is not set.
+	Rate *float64 `json:"rate"`
+}
@@ -0,0 +1,78 @@
+package config
+
+import (
+	"fmt"
+	"image/color"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Types of generated assets.
+const (
+	AssetPNG  = "png"
+	AssetJPEG = "jpeg"
+	AssetSVG  = "svg"
+	AssetCSV  = "csv"
+	AssetPDF  = "pdf"
+	AssetZIP  = "zip"
+)
+
+// AssetTypes lists the types of generated assets.
+var AssetTypes = []string{AssetPNG, AssetJPEG, AssetSVG, AssetCSV, AssetPDF, AssetZIP}
+
+// Limits of generated assets, so that a request can't exhaust the server.
+const (
+	MaxAssetDimension = 4096
+	MaxAssetSize      = 64 << 20
+)
+
+// Asset is a generated binary file: a placeholder image showing Text on a
+// Background of Width x Height pixels, or a CSV, PDF or ZIP file of about Size
+// bytes. Requests can override the values, see the README.
+type Asset struct {
+	Type       string `json:"type"`
+	Width      int    `json:"width,omitempty"`
+	Height     int    `json:"height,omitempty"`
+	Background string `json:"background,omitempty"`
+	Color      string `json:"color,omitempty"`
+	Text       string `json:"text,omitempty"`
+	Size       int    `json:"size,omitempty"`
+	// Filename makes clients download the asset under that name.
+	Filename string `json:"filename,omitempty"`
+}
+
+// ParseColor reads a colour written as "#rgb" or "#rrggbb", the "#" being optional.
+func ParseColor(s string) (color.RGBA, error) {
+	hex := strings.TrimPrefix(s, "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	value, err := strconv.ParseUint(hex, 16, 32)
+	if len(hex) != 6 || err != nil {
+		return color.RGBA{}, fmt.Errorf("invalid colour %q", s)
+	}
+	return color.RGBA{R: uint8(value >> 16), G: uint8(value >> 8), B: uint8(value), A: 0xff}, nil
+}
+
+// validateAsset checks an asset, which may be nil.
+func validateAsset(asset *Asset) error {
+	if asset == nil {
+		return nil
+	}
+	if !slices.Contains(AssetTypes, asset.Type) {
+		return fmt.Errorf("asset: unknown type %q: must be one of %s", asset.Type, strings.Join(AssetTypes, ", "))
+	}
+	if asset.Width < 0 || asset.Width > MaxAssetDimension || asset.Height < 0 || asset.Height > MaxAssetDimension {
+		return fmt.Errorf("asset: width and height must be between 0 and %d", MaxAssetDimension)
+	}
+	if asset.Size < 0 || asset.Size > MaxAssetSize {
+		return fmt.Errorf("asset: size must be between 0 and %d", MaxAssetSize)
+	}
+	for _, c := range []string{asset.Background, asset.Color} {
+		if _, err := ParseColor(c); c != "" && err != nil {
+			return fmt.Errorf("asset: %w", err)
+		}
+	}
+	return nil
+}
@@ -3,19 +3,120 @@ package config
 import (
 	"encoding/json"
 	"os"
+	"path/filepath"
+
+	"github.com/paqstd-team/fake-cli/jsonschema"
+)
+
+// Validation modes for payload templates.
+const (
+	// ValidationStructure only checks that objects, arrays and required keys
+	// line up. It is the default.
+	ValidationStructure = "structure"
+	// ValidationSchema infers a JSON Schema from the template, so that values
+	// have to match the data types they name.
+	ValidationSchema = "schema"
 )
 
+// Upload limits the files sent under one multipart form field. Zero values
+// don't limit anything, and content types may end in a wildcard ("image/*").
+type Upload struct {
+	MinCount     int      `json:"min_count,omitempty"`
+	MaxCount     int      `json:"max_count,omitempty"`
+	MaxSize      int64    `json:"max_size,omitempty"`
+	ContentTypes []string `json:"content_types,omitempty"`
+}
+
 type Endpoint struct {
 	URL      string      `json:"url"`
 	Type     string      `json:"type"`
-	Response interface{} `json:"response"`
-	Status   int         `json:"status"`
-	Payload  interface{} `json:"payload"`
-	Cache    *int        `json:"cache"`
+	Response interface{} `json:"response,omitempty"`
+	Status   int         `json:"status,omitempty"`
+	// Headers are added to the endpoint's own reply.
+	Headers map[string]string `json:"headers,omitempty"`
+	// Body sends raw text and BodyFile the contents of a file, relative to
+	// Config.Dir, instead of a generated Response. Their content type is
+	// detected unless a header sets it. Template replaces {{type}} tokens in
+	// them with generated data.
+	Body     string `json:"body,omitempty"`
+	BodyFile string `json:"body_file,omitempty"`
+	Template bool   `json:"template,omitempty"`
+	// Asset sends a generated placeholder image or file instead of a Response.
+	Asset   *Asset      `json:"asset,omitempty"`
+	Payload interface{} `json:"payload,omitempty"`
+	// PayloadSchema is a JSON Schema that request bodies are validated against
+	// instead of Payload.
+	PayloadSchema *jsonschema.Schema `json:"payload_schema,omitempty"`
+	// Query and HeaderSchema are JSON Schemas of objects that the query
+	// parameters and request headers are validated against. Values are
+	// converted to the declared types, so {"type": "integer"} accepts ?limit=10.
+	Query        *jsonschema.Schema `json:"query,omitempty"`
+	HeaderSchema *jsonschema.Schema `json:"header_schema,omitempty"`
+	// Files limits the files uploaded under each multipart form field.
+	Files map[string]Upload `json:"files,omitempty"`
+	Cache *int              `json:"cache,omitempty"`
+	// Format fixes the encoding of responses instead of negotiating it with
+	// the Accept header, and XML names the elements of XML responses.
+	Format string `json:"format,omitempty"`
+	XML    *XML   `json:"xml,omitempty"`
+	// Stream sends the generated response as Server-Sent Events instead of a
+	// single response.
+	Stream *Stream `json:"stream,omitempty"`
+	// Socket serves WebSocket connections, see Socket.
+	Socket *Socket `json:"socket,omitempty"`
+	// Cases are checked in order and the first matching one replaces the default reply.
+	Cases []Case `json:"cases,omitempty"`
+	// Variants are named replies selected per request or through the admin API.
+	Variants map[string]Reply `json:"variants,omitempty"`
+	// Variant is the variant served by default instead of the endpoint's own reply.
+	Variant string `json:"variant,omitempty"`
+	// Scenario names the state machine the endpoint belongs to, and States holds
+	// its reply for each state of that scenario ("*" matches any state).
+	Scenario string           `json:"scenario,omitempty"`
+	States   map[string]State `json:"states,omitempty"`
+	// Sequence replaces the endpoint's own reply with replies sent in turn,
+	// and SequenceMode decides how it goes on after the last one.
+	Sequence     []Reply `json:"sequence,omitempty"`
+	SequenceMode string  `json:"sequence_mode,omitempty"`
+	// Fault injects failures into the endpoint, see Config.Fault.
+	Fault *Fault `json:"fault,omitempty"`
+	// Passthrough forwards requests to the fallback upstream instead of sending
+	// the endpoint's own reply, and Patch overrides fields of the upstream's
+	// JSON response with generated values.
+	Passthrough bool        `json:"passthrough,omitempty"`
+	Patch       interface{} `json:"patch,omitempty"`
+}
+
+// Reply returns the endpoint's own reply, sent when no case, variant, state or
+// sequence replaces it.
+func (e Endpoint) Reply() Reply {
+	return Reply{Status: e.Status, Headers: e.Headers, Response: e.Response, Body: e.Body, BodyFile: e.BodyFile, Template: e.Template, Asset: e.Asset}
 }
 
 type Config struct {
 	Endpoints []Endpoint `json:"endpoints"`
+	// Strict only treats prefixed strings such as "$word" as data types.
+	Strict bool `json:"strict"`
+	Seed   Seed `json:"seed"`
+	// Scenarios are state machines that endpoints advance as they are called.
+	Scenarios []Scenario `json:"scenarios"`
+	Admin     Admin      `json:"admin"`
+	Journal   Journal    `json:"journal"`
+	Fallback  Fallback   `json:"fallback"`
+	// Fault injects failures into every endpoint that has no fault of its own.
+	Fault *Fault `json:"fault"`
+	// Errors configures the responses sent when a request cannot be served.
+	Errors Errors `json:"errors"`
+	// Validation decides how request bodies are checked against payload
+	// templates, see ValidationStructure and ValidationSchema.
+	Validation string `json:"validation,omitempty"`
+	// OpenAPI is an OpenAPI 3 document whose operations are added as endpoints.
+	// A relative path is resolved against the directory of the config file.
+	OpenAPI string `json:"openapi,omitempty"`
+	// Dir is the directory of the config file, which relative body files are
+	// resolved against, including those of endpoints added at runtime. It is
+	// set by LoadConfigFromFile.
+	Dir string `json:"-"`
 }
 
 func LoadConfigFromFile(path string) (Config, error) {
@@ -32,6 +133,11 @@ func LoadConfigFromFile(path string) (Config, error) {
 	if err != nil {
 		return config, err
 	}
+	config.Dir = filepath.Dir(path)
+
+	if err := Validate(config); err != nil {
+		return config, err
+	}
 
 	return config, nil
 }
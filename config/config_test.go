@@ -1,9 +1,12 @@
 package config
 
 import (
+	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestConfig_Load(t *testing.T) {
@@ -158,6 +161,9 @@ func TestConfig_Load(t *testing.T) {
 			if len(cfg.Endpoints) != tt.expectedEndpoints {
 				t.Errorf("Expected %d endpoints, got %d", tt.expectedEndpoints, len(cfg.Endpoints))
 			}
+			if cfg.Dir != dir {
+				t.Errorf("Expected dir %s, got %s", dir, cfg.Dir)
+			}
 
 			// Cache is now per-endpoint, so we don't check global cache
 		})
@@ -210,3 +216,599 @@ func TestConfig_InvalidFilePermissions(t *testing.T) {
 		t.Fatal("Expected error for file with no read permissions")
 	}
 }
+
+func TestConfig_ValidateDirectives(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      string
+		expectError bool
+	}{
+		{
+			name: "valid_regex_and_format",
+			config: `{
+				"endpoints": [
+					{
+						"url": "/api/orders",
+						"response": [
+							{
+								"id": {"$format": "ORD-####-??"},
+								"sku": {"$regex": "^[A-Z]{3}-\\d{4}$"},
+								"tags": [{"$regex": "[a-z]{5}"}]
+							}
+						]
+					}
+				]
+			}`,
+			expectError: false,
+		},
+		{
+			name: "invalid_regex",
+			config: `{
+				"endpoints": [
+					{
+						"url": "/api/orders",
+						"response": {"sku": {"$regex": "[A-Z"}}
+					}
+				]
+			}`,
+			expectError: true,
+		},
+		{
+			name: "non_string_regex",
+			config: `{
+				"endpoints": [
+					{
+						"url": "/api/orders",
+						"response": {"sku": {"$regex": 42}}
+					}
+				]
+			}`,
+			expectError: true,
+		},
+		{
+			name: "invalid_regex_in_array",
+			config: `{
+				"endpoints": [
+					{
+						"url": "/api/orders",
+						"response": [{"sku": {"$regex": "(a"}}]
+					}
+				]
+			}`,
+			expectError: true,
+		},
+		{
+			name: "literal_skips_validation",
+			config: `{
+				"strict": true,
+				"endpoints": [
+					{
+						"url": "/api/docs",
+						"response": {"example": {"$literal": {"$regex": "[A-Z"}}}
+					}
+				]
+			}`,
+			expectError: false,
+		},
+		{
+			name:   "valid_range",
+			config: `{"endpoints": [{"url": "/a", "response": {"age": {"$range": [18, 99]}, "score": {"$range": [0.5, 1]}, "ratio": {"$range": [0, 1, "number"]}}}]}`,
+		},
+		{
+			name:        "range_unknown_type",
+			config:      `{"endpoints": [{"url": "/a", "response": {"age": {"$range": [1, 9, "int"]}}}]}`,
+			expectError: true,
+		},
+		{
+			name:        "integer_range_fractional_bounds",
+			config:      `{"endpoints": [{"url": "/a", "response": {"age": {"$range": [0.5, 9, "integer"]}}}]}`,
+			expectError: true,
+		},
+		{
+			name:        "integer_range_too_large",
+			config:      `{"endpoints": [{"url": "/a", "response": {"age": {"$range": [0, 1e300, "integer"]}}}]}`,
+			expectError: true,
+		},
+		{
+			name:        "reversed_range",
+			config:      `{"endpoints": [{"url": "/a", "response": {"age": {"$range": [99, 18]}}}]}`,
+			expectError: true,
+		},
+		{
+			name:        "range_not_numbers",
+			config:      `{"endpoints": [{"url": "/a", "response": {"age": {"$range": ["a", 1]}}}]}`,
+			expectError: true,
+		},
+		{
+			name:        "range_wrong_length",
+			config:      `{"endpoints": [{"url": "/a", "response": {"age": {"$range": [1]}}}]}`,
+			expectError: true,
+		},
+		{
+			name: "empty_format",
+			config: `{
+				"endpoints": [
+					{
+						"url": "/api/orders",
+						"response": {"id": {"$format": ""}}
+					}
+				]
+			}`,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "config.json")
+			if err := os.WriteFile(configPath, []byte(tt.config), 0o600); err != nil {
+				t.Fatalf("Failed to write config file: %v", err)
+			}
+
+			_, err := LoadConfigFromFile(configPath)
+			if tt.expectError && err == nil {
+				t.Fatal("Expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestConfig_Seed(t *testing.T) {
+	tests := []struct {
+		name         string
+		config       string
+		expectError  bool
+		expectedSeed uint64
+	}{
+		{name: "default_seed", config: `{"endpoints": []}`, expectedSeed: 0},
+		{name: "numeric_seed", config: `{"seed": 42, "endpoints": []}`, expectedSeed: 42},
+		{name: "string_seed", config: `{"seed": "7", "endpoints": []}`, expectedSeed: 7},
+		{name: "random_seed", config: `{"seed": "random", "endpoints": []}`},
+		{name: "negative_seed", config: `{"seed": -1, "endpoints": []}`, expectError: true},
+		{name: "invalid_seed", config: `{"seed": "often", "endpoints": []}`, expectError: true},
+		{name: "escaped_seed", config: `{"seed": "\u0037", "endpoints": []}`, expectedSeed: 7},
+		{name: "quoted_number_seed", config: `{"seed": "\"7\"", "endpoints": []}`, expectError: true},
+		{name: "boolean_seed", config: `{"seed": true, "endpoints": []}`, expectError: true},
+		{name: "fractional_seed", config: `{"seed": 1.5, "endpoints": []}`, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "config.json")
+			if err := os.WriteFile(configPath, []byte(tt.config), 0o600); err != nil {
+				t.Fatalf("Failed to write config file: %v", err)
+			}
+
+			cfg, err := LoadConfigFromFile(configPath)
+			if tt.expectError {
+				if err == nil {
+					t.Fatal("Expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			seed, err := cfg.Seed.Value()
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if cfg.Seed != SeedRandom && seed != tt.expectedSeed {
+				t.Errorf("Expected seed %d, got %d", tt.expectedSeed, seed)
+			}
+		})
+	}
+}
+
+func TestConfig_Cases(t *testing.T) {
+	tests := []struct {
+		name        string
+		cases       string
+		expectError bool
+		check       func(t *testing.T, c Case)
+	}{
+		{
+			name: "shorthand_equals",
+			cases: `[{
+				"when": {"body": {"$.username": "admin", "$.age": 30, "$.tags": ["a"], "$.user": {"id": 1}}},
+				"status": 200,
+				"headers": {"X-Session": "abc"},
+				"response": {"token": "uuid"}
+			}]`,
+			check: func(t *testing.T, c Case) {
+				if c.When.Body["$.username"].Equals != "admin" || c.When.Body["$.age"].Equals != float64(30) {
+					t.Errorf("Unexpected shorthand conditions: %+v", c.When.Body)
+				}
+				if _, ok := c.When.Body["$.tags"].Equals.([]any); !ok {
+					t.Errorf("Expected array to be compared as a whole, got %+v", c.When.Body["$.tags"])
+				}
+				if _, ok := c.When.Body["$.user"].Equals.(map[string]any); !ok {
+					t.Errorf("Expected object with other keys to be compared as a whole, got %+v", c.When.Body["$.user"])
+				}
+				if c.Status != 200 || c.Headers["X-Session"] != "abc" || c.Response == nil {
+					t.Errorf("Unexpected reply: %+v", c.Reply)
+				}
+			},
+		},
+		{
+			name:  "operators",
+			cases: `[{"when": {"query": {"q": {"regex": "^a"}}, "headers": {"X-Debug": {"exists": true}}, "path": {"id": {"equals": "1"}}}}]`,
+			check: func(t *testing.T, c Case) {
+				if c.When.Query["q"].Regex != "^a" || c.When.Path["id"].Equals != "1" {
+					t.Errorf("Unexpected operator conditions: %+v", c.When)
+				}
+				if exists := c.When.Headers["X-Debug"].Exists; exists == nil || !*exists {
+					t.Errorf("Expected exists=true, got %v", exists)
+				}
+			},
+		},
+		{
+			name:  "empty_object_equals",
+			cases: `[{"when": {"body": {"$.meta": {}}}}]`,
+			check: func(t *testing.T, c Case) {
+				if _, ok := c.When.Body["$.meta"].Equals.(map[string]any); !ok {
+					t.Errorf("Expected empty object to be compared as a whole, got %+v", c.When.Body["$.meta"])
+				}
+			},
+		},
+		{
+			name:        "invalid_operator_value",
+			cases:       `[{"when": {"query": {"q": {"regex": 5}}}}]`,
+			expectError: true,
+		},
+		{
+			name:        "invalid_regex",
+			cases:       `[{"when": {"headers": {"Authorization": {"regex": "Bearer ["}}}}]`,
+			expectError: true,
+		},
+		{
+			name:        "invalid_jsonpath",
+			cases:       `[{"when": {"body": {"$.items[": "x"}}}]`,
+			expectError: true,
+		},
+		{
+			name:        "invalid_case_response",
+			cases:       `[{"when": {}, "response": {"id": {"$regex": "("}}}]`,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "config.json")
+			content := `{"endpoints": [{"url": "/login", "type": "POST", "response": {}, "cases": ` + tt.cases + `}]}`
+			if err := os.WriteFile(configPath, []byte(content), 0o600); err != nil {
+				t.Fatalf("Failed to write config file: %v", err)
+			}
+
+			cfg, err := LoadConfigFromFile(configPath)
+			if tt.expectError {
+				if err == nil {
+					t.Fatal("Expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			tt.check(t, cfg.Endpoints[0].Cases[0])
+		})
+	}
+}
+
+func TestConfig_Variants(t *testing.T) {
+	tests := []struct {
+		name        string
+		endpoint    string
+		expectError bool
+	}{
+		{
+			name:     "valid_variants",
+			endpoint: `{"url": "/items", "variant": "empty", "variants": {"empty": {"response": []}, "error": {"status": 500, "response": {"error": "word"}}}}`,
+		},
+		{
+			name:        "unknown_default_variant",
+			endpoint:    `{"url": "/items", "variant": "huge", "variants": {"empty": {"response": []}}}`,
+			expectError: true,
+		},
+		{
+			name:        "reserved_variant_name",
+			endpoint:    `{"url": "/items", "variants": {"default": {"response": []}}}`,
+			expectError: true,
+		},
+		{
+			name:        "invalid_variant_response",
+			endpoint:    `{"url": "/items", "variants": {"bad": {"response": {"$regex": "("}}}}`,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "config.json")
+			if err := os.WriteFile(configPath, []byte(`{"endpoints": [`+tt.endpoint+`]}`), 0o600); err != nil {
+				t.Fatalf("Failed to write config file: %v", err)
+			}
+
+			_, err := LoadConfigFromFile(configPath)
+			if tt.expectError && err == nil {
+				t.Fatal("Expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestConfig_Scenarios(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      string
+		expectError bool
+	}{
+		{
+			name: "valid_scenario",
+			config: `{
+				"scenarios": [{"name": "job", "initial": "submitted", "key": "id"}],
+				"endpoints": [{"url": "/jobs/{id}", "scenario": "job", "states": {
+					"submitted": {"response": {"status": "submitted"}, "next": "running"},
+					"*": {"status": 200, "response": {"status": "done"}, "next": "submitted"}
+				}}, {"url": "/jobs/{id}/status", "scenario": "job", "states": {
+					"running": {"response": {"status": "running"}}
+				}}]
+			}`,
+		},
+		{
+			name: "listed_final_state",
+			config: `{
+				"scenarios": [{"name": "flaky", "initial": "failing", "states": ["healthy"]}],
+				"endpoints": [{"url": "/health", "scenario": "flaky", "states": {"failing": {"status": 500, "next": "healthy"}}}]
+			}`,
+		},
+		{
+			name: "unknown_next_state",
+			config: `{
+				"scenarios": [{"name": "flaky", "initial": "failing"}],
+				"endpoints": [{"url": "/health", "scenario": "flaky", "states": {"failing": {"status": 500, "next": "healthy"}}}]
+			}`,
+			expectError: true,
+		},
+		{
+			name:        "any_state_listed",
+			config:      `{"scenarios": [{"name": "job", "initial": "a", "states": ["*"]}], "endpoints": []}`,
+			expectError: true,
+		},
+		{
+			name:        "missing_initial_state",
+			config:      `{"scenarios": [{"name": "job"}], "endpoints": []}`,
+			expectError: true,
+		},
+		{
+			name:        "duplicate_scenario",
+			config:      `{"scenarios": [{"name": "job", "initial": "a"}, {"name": "job", "initial": "b"}], "endpoints": []}`,
+			expectError: true,
+		},
+		{
+			name:        "unknown_scenario",
+			config:      `{"endpoints": [{"url": "/jobs", "scenario": "job"}]}`,
+			expectError: true,
+		},
+		{
+			name:        "states_without_scenario",
+			config:      `{"endpoints": [{"url": "/jobs", "states": {"a": {}}}]}`,
+			expectError: true,
+		},
+		{
+			name: "invalid_state_response",
+			config: `{
+				"scenarios": [{"name": "job", "initial": "a"}],
+				"endpoints": [{"url": "/jobs", "scenario": "job", "states": {"a": {"response": {"$regex": "("}}}}]
+			}`,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "config.json")
+			if err := os.WriteFile(configPath, []byte(tt.config), 0o600); err != nil {
+				t.Fatalf("Failed to write config file: %v", err)
+			}
+
+			_, err := LoadConfigFromFile(configPath)
+			if tt.expectError && err == nil {
+				t.Fatal("Expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestConfig_Sequence(t *testing.T) {
+	tests := []struct {
+		name          string
+		config        string
+		expectError   bool
+		expectedDelay time.Duration
+	}{
+		{
+			name:          "delay_in_milliseconds",
+			config:        `{"endpoints": [{"url": "/a", "sequence": [{"status": 500, "delay": 250}, {"response": {"id": "uuid"}}]}]}`,
+			expectedDelay: 250 * time.Millisecond,
+		},
+		{
+			name:          "delay_as_duration",
+			config:        `{"endpoints": [{"url": "/a", "sequence_mode": "loop", "sequence": [{"delay": "1.5s"}]}]}`,
+			expectedDelay: 1500 * time.Millisecond,
+		},
+		{
+			name:        "invalid_delay_string",
+			config:      `{"endpoints": [{"url": "/a", "sequence": [{"delay": "soon"}]}]}`,
+			expectError: true,
+		},
+		{
+			name:        "invalid_delay_type",
+			config:      `{"endpoints": [{"url": "/a", "sequence": [{"delay": true}]}]}`,
+			expectError: true,
+		},
+		{
+			name:        "negative_delay",
+			config:      `{"endpoints": [{"url": "/a", "sequence": [{"delay": -1}]}]}`,
+			expectError: true,
+		},
+		{
+			name:        "unknown_mode",
+			config:      `{"endpoints": [{"url": "/a", "sequence_mode": "shuffle", "sequence": [{}]}]}`,
+			expectError: true,
+		},
+		{
+			name:        "mode_without_sequence",
+			config:      `{"endpoints": [{"url": "/a", "sequence_mode": "loop"}]}`,
+			expectError: true,
+		},
+		{
+			name:        "invalid_step_response",
+			config:      `{"endpoints": [{"url": "/a", "sequence": [{"response": {"$format": ""}}]}]}`,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "config.json")
+			if err := os.WriteFile(configPath, []byte(tt.config), 0o600); err != nil {
+				t.Fatalf("Failed to write config file: %v", err)
+			}
+
+			config, err := LoadConfigFromFile(configPath)
+			if tt.expectError {
+				if err == nil {
+					t.Fatal("Expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if delay := time.Duration(config.Endpoints[0].Sequence[0].Delay); delay != tt.expectedDelay {
+				t.Errorf("Expected delay %v, got %v", tt.expectedDelay, delay)
+			}
+		})
+	}
+}
+
+func TestConfig_ServerSettings(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      string
+		expectError bool
+	}{
+		{name: "custom_admin_prefix", config: `{"admin": {"prefix": "/_mock"}, "endpoints": []}`},
+		{name: "disabled_admin", config: `{"admin": {"disabled": true}, "endpoints": []}`},
+		{name: "relative_admin_prefix", config: `{"admin": {"prefix": "_mock"}, "endpoints": []}`, expectError: true},
+		{name: "root_admin_prefix", config: `{"admin": {"prefix": "/"}, "endpoints": []}`, expectError: true},
+		{name: "journal", config: `{"journal": {"size": 10, "file": "requests.jsonl"}, "endpoints": []}`},
+		{name: "negative_journal_size", config: `{"journal": {"size": -1}, "endpoints": []}`, expectError: true},
+		{name: "negative_journal_body_size", config: `{"journal": {"body_size": -1}, "endpoints": []}`, expectError: true},
+		{name: "fallback", config: `{"fallback": {"upstream": "http://localhost:9000", "record": true}, "endpoints": []}`},
+		{name: "fallback_record_without_upstream", config: `{"fallback": {"record": true}, "endpoints": []}`, expectError: true},
+		{name: "fallback_invalid_upstream", config: `{"fallback": {"upstream": "localhost:9000"}, "endpoints": []}`, expectError: true},
+		{name: "fallback_unparsable_upstream", config: `{"fallback": {"upstream": "http://%zz"}, "endpoints": []}`, expectError: true},
+		{name: "passthrough", config: `{"fallback": {"upstream": "http://localhost:9000"}, "endpoints": [{"url": "/a", "passthrough": true, "patch": {"email": "email"}}]}`},
+		{name: "passthrough_without_upstream", config: `{"endpoints": [{"url": "/a", "passthrough": true}]}`, expectError: true},
+		{name: "patch_without_passthrough", config: `{"fallback": {"upstream": "http://localhost:9000"}, "endpoints": [{"url": "/a", "patch": {"a": "word"}}]}`, expectError: true},
+		{name: "invalid_patch", config: `{"fallback": {"upstream": "http://localhost:9000"}, "endpoints": [{"url": "/a", "passthrough": true, "patch": {"a": {"$regex": "("}}}]}`, expectError: true},
+		{name: "global_fault", config: `{"fault": {"rate": 0.1, "status": 503, "delay": "2s"}, "endpoints": []}`},
+		{name: "endpoint_fault", config: `{"endpoints": [{"url": "/a", "fault": {"response": {"error": "boom"}}}]}`},
+		{name: "fault_rate_too_high", config: `{"fault": {"rate": 1.5}, "endpoints": []}`, expectError: true},
+		{name: "fault_rate_negative", config: `{"endpoints": [{"url": "/a", "fault": {"rate": -0.1}}]}`, expectError: true},
+		{name: "fault_invalid_status", config: `{"fault": {"status": 42}, "endpoints": []}`, expectError: true},
+		{name: "fault_invalid_response", config: `{"fault": {"response": {"$regex": "("}}, "endpoints": []}`, expectError: true},
+		{name: "schema_validation", config: `{"validation": "schema", "endpoints": [{"url": "/a", "type": "POST", "payload": {"age": {"$range": [1, 9]}}}]}`},
+		{name: "unknown_validation", config: `{"validation": "types", "endpoints": []}`, expectError: true},
+		{name: "schema_validation_invalid_payload", config: `{"validation": "schema", "endpoints": [{"url": "/a", "payload": {"a": {"$regex": "("}}}]}`, expectError: true},
+		{name: "structure_validation_ignores_directives", config: `{"validation": "structure", "endpoints": [{"url": "/a", "payload": {"a": {"$regex": "("}}}]}`},
+		{name: "payload_schema", config: `{"endpoints": [{"url": "/a", "type": "POST", "payload_schema": {"$schema": "https://json-schema.org/draft/2020-12/schema", "type": "object", "required": ["id"]}}]}`},
+		{name: "errors", config: `{"errors": {"body": {"message": "{detail}"}, "not_found": {"response": {"error": "missing"}}, "method_not_allowed": {"status": 400}}, "endpoints": []}`},
+		{name: "errors_invalid_status", config: `{"errors": {"not_found": {"status": 1000}}, "endpoints": []}`, expectError: true},
+		{name: "errors_invalid_response", config: `{"errors": {"method_not_allowed": {"response": {"$regex": "("}}}, "endpoints": []}`, expectError: true},
+		{name: "invalid_payload_schema", config: `{"endpoints": [{"url": "/a", "payload_schema": {"properties": {"id": {"pattern": "("}}}}]}`, expectError: true},
+		{name: "query_and_headers", config: `{"endpoints": [{"url": "/a", "query": {"type": "object", "required": ["limit"], "properties": {"limit": {"type": "integer"}}}, "header_schema": {"properties": {"X-Api-Version": {"enum": ["1", "2"]}}}}]}`},
+		{name: "invalid_query", config: `{"endpoints": [{"url": "/a", "query": {"properties": {"q": {"type": "text"}}}}]}`, expectError: true},
+		{name: "query_not_object", config: `{"endpoints": [{"url": "/a", "query": {"type": "array"}}]}`, expectError: true},
+		{name: "files", config: `{"endpoints": [{"url": "/a", "type": "POST", "files": {"avatar": {"min_count": 1, "max_count": 2, "max_size": 1024, "content_types": ["image/*", "application/pdf"]}}}]}`},
+		{name: "files_negative_size", config: `{"endpoints": [{"url": "/a", "files": {"avatar": {"max_size": -1}}}]}`, expectError: true},
+		{name: "files_min_above_max", config: `{"endpoints": [{"url": "/a", "files": {"avatar": {"min_count": 3, "max_count": 2}}}]}`, expectError: true},
+		{name: "files_invalid_content_type", config: `{"endpoints": [{"url": "/a", "files": {"avatar": {"content_types": ["image"]}}}]}`, expectError: true},
+		{name: "format", config: `{"endpoints": [{"url": "/a", "format": "xml", "xml": {"root": "users", "item": "user"}}]}`},
+		{name: "unknown_format", config: `{"endpoints": [{"url": "/a", "format": "toml"}]}`, expectError: true},
+		{name: "invalid_xml_root", config: `{"endpoints": [{"url": "/a", "xml": {"root": "1st"}}]}`, expectError: true},
+		{name: "invalid_xml_item", config: `{"endpoints": [{"url": "/a", "xml": {"item": "an item"}}]}`, expectError: true},
+		{name: "body", config: `{"endpoints": [{"url": "/a", "body": "<p>{{word}}</p>", "template": true, "cases": [{"when": {}, "body_file": "a.pdf"}]}]}`},
+		{name: "body_and_response", config: `{"endpoints": [{"url": "/a", "body": "x", "response": {"id": "uuid"}}]}`, expectError: true},
+		{name: "variant_body_and_body_file", config: `{"endpoints": [{"url": "/a", "variants": {"pdf": {"body": "x", "body_file": "a.pdf"}}}]}`, expectError: true},
+		{name: "template_without_body", config: `{"endpoints": [{"url": "/a", "template": true}]}`, expectError: true},
+		{name: "asset", config: `{"endpoints": [{"url": "/img/{w}x{h}", "asset": {"type": "png", "background": "#ccc", "color": "333333", "text": "Hi"}, "variants": {"pdf": {"asset": {"type": "pdf", "size": 2048}}}}]}`},
+		{name: "asset_and_body", config: `{"endpoints": [{"url": "/a", "body": "x", "asset": {"type": "png"}}]}`, expectError: true},
+		{name: "asset_unknown_type", config: `{"endpoints": [{"url": "/a", "asset": {"type": "gif"}}]}`, expectError: true},
+		{name: "asset_too_wide", config: `{"endpoints": [{"url": "/a", "asset": {"type": "png", "width": 5000}}]}`, expectError: true},
+		{name: "asset_negative_size", config: `{"endpoints": [{"url": "/a", "asset": {"type": "zip", "size": -1}}]}`, expectError: true},
+		{name: "asset_invalid_colour", config: `{"endpoints": [{"url": "/a", "asset": {"type": "svg", "color": "#12"}}]}`, expectError: true},
+		{name: "variant_invalid_asset", config: `{"endpoints": [{"url": "/a", "variants": {"img": {"asset": {"type": "png", "background": "red"}}}}]}`, expectError: true},
+		{name: "stream", config: `{"endpoints": [{"url": "/events", "response": {"id": "uuid"}, "stream": {"event": "tick", "interval": "500ms", "count": 10, "retry": 3000}}]}`},
+		{name: "stream_multiline_event", config: `{"endpoints": [{"url": "/events", "stream": {"event": "a\nb"}}]}`, expectError: true},
+		{name: "stream_negative_interval", config: `{"endpoints": [{"url": "/events", "stream": {"interval": -5}}]}`, expectError: true},
+		{name: "stream_negative_count", config: `{"endpoints": [{"url": "/events", "stream": {"count": -1}}]}`, expectError: true},
+		{name: "socket", config: `{"endpoints": [{"url": "/ws", "socket": {"push": [{"message": {"id": "uuid"}, "interval": "1s", "count": 3}], "replies": [{"when": {"body": {"$.type": "ping"}}, "message": "pong"}, {"when": {}, "payload_schema": {"type": "object", "required": ["bye"]}, "close": {"code": 4001, "reason": "bye"}}], "close": {"code": 1000, "after": "1m"}}}]}`},
+		{name: "socket_post", config: `{"endpoints": [{"url": "/ws", "type": "POST", "socket": {}}]}`, expectError: true},
+		{name: "socket_push_without_message", config: `{"endpoints": [{"url": "/ws", "socket": {"push": [{"interval": 100}]}}]}`, expectError: true},
+		{name: "socket_push_negative_count", config: `{"endpoints": [{"url": "/ws", "socket": {"push": [{"message": "word", "count": -1}]}}]}`, expectError: true},
+		{name: "socket_push_invalid_message", config: `{"endpoints": [{"url": "/ws", "socket": {"push": [{"message": {"code": {"$regex": "("}}}]}}]}`, expectError: true},
+		{name: "socket_reply_invalid_when", config: `{"endpoints": [{"url": "/ws", "socket": {"replies": [{"when": {"body": {"$.a": {"regex": "("}}}}]}}]}`, expectError: true},
+		{name: "socket_reply_invalid_schema", config: `{"endpoints": [{"url": "/ws", "socket": {"replies": [{"when": {}, "payload_schema": {"type": "thing"}}]}}]}`, expectError: true},
+		{name: "socket_reply_invalid_message", config: `{"endpoints": [{"url": "/ws", "socket": {"replies": [{"when": {}, "message": {"n": {"$range": [2, 1]}}}]}}]}`, expectError: true},
+		{name: "socket_reply_invalid_close", config: `{"endpoints": [{"url": "/ws", "socket": {"replies": [{"when": {}, "close": {"code": 1005}}]}}]}`, expectError: true},
+		{name: "socket_close_reserved_code", config: `{"endpoints": [{"url": "/ws", "socket": {"close": {"code": 2000}}}]}`, expectError: true},
+		{name: "socket_close_long_reason", config: `{"endpoints": [{"url": "/ws", "socket": {"close": {"code": 4000, "reason": "` + strings.Repeat("x", 124) + `"}}}]}`, expectError: true},
+		{name: "payload_schema_reference_loop", config: `{"endpoints": [{"url": "/a", "type": "POST", "payload_schema": {"$defs": {"a": {"$ref": "#/$defs/a"}}, "$ref": "#/$defs/a"}}]}`, expectError: true},
+		{name: "endpoint_under_admin_prefix", config: `{"endpoints": [{"url": "/__admin/users"}]}`, expectError: true},
+		{name: "endpoint_at_custom_admin_prefix", config: `{"admin": {"prefix": "/control/"}, "endpoints": [{"url": "/control"}]}`, expectError: true},
+		{name: "endpoint_next_to_admin_prefix", config: `{"endpoints": [{"url": "/__administrator"}]}`},
+		{name: "endpoint_with_admin_disabled", config: `{"admin": {"disabled": true}, "endpoints": [{"url": "/__admin/users"}]}`},
+		{name: "header_schema_not_object", config: `{"endpoints": [{"url": "/a", "header_schema": {"type": "string"}}]}`, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "config.json")
+			if err := os.WriteFile(configPath, []byte(tt.config), 0o600); err != nil {
+				t.Fatalf("Failed to write config file: %v", err)
+			}
+
+			_, err := LoadConfigFromFile(configPath)
+			if tt.expectError && err == nil {
+				t.Fatal("Expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestConfig_DurationMarshal(t *testing.T) {
+	data, err := json.Marshal(Duration(1500 * time.Millisecond))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if string(data) != `"1.5s"` {
+		t.Errorf("Expected \"1.5s\", got %s", data)
+	}
+}
@@ -0,0 +1,23 @@
+package config
+
+// ProblemContentType is the media type of RFC 7807 problem details.
+const ProblemContentType = "application/problem+json"
+
+// Errors configures the responses sent when a request cannot be served, such
+// as an invalid body or an unknown route. They are RFC 7807 problem details
+// unless configured otherwise.
+type Errors struct {
+	// Body replaces the shape of problem details. The strings "{type}",
+	// "{title}", "{status}", "{detail}", "{instance}" and "{violations}" in it
+	// are replaced with the members of the problem.
+	Body interface{} `json:"body,omitempty"`
+	// ContentType of error responses, ProblemContentType by default and
+	// application/json with a custom Body.
+	ContentType string `json:"content_type,omitempty"`
+	// NotFound is sent for requests that no endpoint matches, with status 404
+	// unless it sets another.
+	NotFound *Reply `json:"not_found,omitempty"`
+	// MethodNotAllowed is sent for requests whose path matches an endpoint but
+	// not its method, with status 405 unless it sets another.
+	MethodNotAllowed *Reply `json:"method_not_allowed,omitempty"`
+}
@@ -0,0 +1,9 @@
+package config
+
+// Fallback forwards requests that no endpoint matches to a real backend.
+type Fallback struct {
+	// Upstream is the base URL requests are forwarded to, e.g. "http://localhost:9000".
+	Upstream string `json:"upstream"`
+	// Record captures upstream responses so they can be turned into endpoints.
+	Record bool `json:"record"`
+}
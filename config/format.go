@@ -0,0 +1,20 @@
+package config
+
+// Response formats an endpoint can be encoded in.
+const (
+	FormatJSON    = "json"
+	FormatXML     = "xml"
+	FormatYAML    = "yaml"
+	FormatCSV     = "csv"
+	FormatMsgPack = "msgpack"
+)
+
+// Formats lists the response formats in order of preference.
+var Formats = []string{FormatJSON, FormatXML, FormatYAML, FormatCSV, FormatMsgPack}
+
+// XML names the elements of XML responses. Root defaults to "response" and
+// Item, used for the entries of lists, to "item".
+type XML struct {
+	Root string `json:"root,omitempty"`
+	Item string `json:"item,omitempty"`
+}
@@ -0,0 +1,19 @@
+package config
+
+// DefaultJournalSize is the number of requests the journal keeps unless configured otherwise.
+const DefaultJournalSize = 1000
+
+// DefaultJournalBodySize is the number of bytes of each request body the
+// journal keeps unless configured otherwise.
+const DefaultJournalBodySize = 64 << 10
+
+// Journal configures the record of received requests.
+type Journal struct {
+	// Size is the number of most recent requests kept in memory.
+	Size int `json:"size"`
+	// BodySize is the number of bytes of each request body that are kept.
+	BodySize int `json:"body_size"`
+	// File, when set, gets every request appended as a JSON line.
+	File     string `json:"file"`
+	Disabled bool   `json:"disabled"`
+}
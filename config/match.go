@@ -0,0 +1,67 @@
+package config
+
+import (
+	"encoding/json"
+)
+
+// Reply is a response an endpoint can send instead of its default one.
+type Reply struct {
+	Status   int               `json:"status,omitempty"`
+	Headers  map[string]string `json:"headers,omitempty"`
+	Response interface{}       `json:"response,omitempty"`
+	// Body and BodyFile send raw text or the contents of a file instead of a
+	// generated Response, see Endpoint.Body.
+	Body     string `json:"body,omitempty"`
+	BodyFile string `json:"body_file,omitempty"`
+	Template bool   `json:"template,omitempty"`
+	// Asset sends a generated image or file, see Endpoint.Asset.
+	Asset *Asset `json:"asset,omitempty"`
+	// Delay holds the reply back before it is written.
+	Delay Duration `json:"delay,omitempty"`
+}
+
+// Case is a reply that is sent when the request matches every condition in When.
+type Case struct {
+	When Match `json:"when"`
+	Reply
+}
+
+// Match holds request conditions keyed by query parameter, header, path variable
+// or JSONPath expression into the JSON body.
+type Match struct {
+	Query   map[string]Condition `json:"query"`
+	Headers map[string]Condition `json:"headers"`
+	Path    map[string]Condition `json:"path"`
+	Body    map[string]Condition `json:"body"`
+}
+
+// Condition is a single check of a request value. A plain value is shorthand
+// for {"equals": value}.
+type Condition struct {
+	Equals interface{} `json:"equals,omitempty"`
+	Regex  string      `json:"regex,omitempty"`
+	Exists *bool       `json:"exists,omitempty"`
+}
+
+func (c *Condition) UnmarshalJSON(data []byte) error {
+	// Scalars, arrays and objects with other keys are compared as a whole
+	var operators map[string]json.RawMessage
+	if json.Unmarshal(data, &operators) != nil || !isConditionObject(operators) {
+		return json.Unmarshal(data, &c.Equals)
+	}
+
+	type condition Condition
+	return json.Unmarshal(data, (*condition)(c))
+}
+
+func isConditionObject(fields map[string]json.RawMessage) bool {
+	if len(fields) == 0 {
+		return false
+	}
+	for key := range fields {
+		if key != "equals" && key != "regex" && key != "exists" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,48 @@
+package config
+
+// AnyState is the key of a state reply that applies whatever the current state is.
+const AnyState = "*"
+
+// Scenario is a named state machine shared by the endpoints that refer to it.
+type Scenario struct {
+	Name    string `json:"name"`
+	Initial string `json:"initial"`
+	// Key names a path variable (or query parameter) that gives every value its
+	// own state, e.g. "id" for /jobs/{id}. Without it the state is shared.
+	Key string `json:"key"`
+	// States lists further states that no endpoint has a reply for, such as a
+	// final state in which the endpoints' own replies apply.
+	States []string `json:"states"`
+}
+
+// State is the reply of an endpoint while its scenario is in a given state.
+type State struct {
+	Reply
+	// Next is the state the scenario moves to once this reply is sent.
+	Next string `json:"next"`
+}
+
+// ScenarioStates returns the states of every scenario by name: the initial
+// state, the states it lists and every state the endpoints have a reply for.
+// Scenarios move only to these states.
+func ScenarioStates(scenarios []Scenario, endpoints []Endpoint) map[string]map[string]bool {
+	states := make(map[string]map[string]bool, len(scenarios))
+	for _, scenario := range scenarios {
+		states[scenario.Name] = map[string]bool{scenario.Initial: true}
+		for _, state := range scenario.States {
+			states[scenario.Name][state] = true
+		}
+	}
+	for _, endpoint := range endpoints {
+		known, ok := states[endpoint.Scenario]
+		if !ok {
+			continue
+		}
+		for name := range endpoint.States {
+			if name != AnyState {
+				known[name] = true
+			}
+		}
+	}
+	return states
+}
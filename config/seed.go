@@ -0,0 +1,46 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+)
+
+// SeedRandom picks a new random seed on every start.
+const SeedRandom = "random"
+
+// Seed is the generator seed. It is written either as a number or as "random",
+// and defaults to 0 so that the same config produces the same data on every run.
+type Seed string
+
+// UnmarshalJSON accepts both 42 and "42" (or "random").
+func (s *Seed) UnmarshalJSON(data []byte) error {
+	var text string
+	if json.Unmarshal(data, &text) == nil {
+		*s = Seed(text)
+		return nil
+	}
+	var number json.Number
+	if err := json.Unmarshal(data, &number); err != nil {
+		return fmt.Errorf("invalid seed %s: must be a non-negative integer or %q", data, SeedRandom)
+	}
+	*s = Seed(number)
+	return nil
+}
+
+// Value resolves the seed to a number, drawing a random one for "random".
+func (s Seed) Value() (uint64, error) {
+	switch s {
+	case "":
+		return 0, nil
+	case SeedRandom:
+		return rand.Uint64(), nil
+	}
+
+	seed, err := strconv.ParseUint(string(s), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid seed %q: must be a non-negative integer or %q", string(s), SeedRandom)
+	}
+	return seed, nil
+}
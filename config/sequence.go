@@ -0,0 +1,51 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Sequence modes decide what happens once every reply of a sequence has been sent.
+const (
+	// SequenceStick keeps sending the last reply. It is the default.
+	SequenceStick = "stick"
+	// SequenceLoop starts over with the first reply.
+	SequenceLoop = "loop"
+	// SequenceRandom picks every reply at random instead of in order.
+	SequenceRandom = "random"
+)
+
+// Duration is a delay written either as milliseconds (250) or as a Go duration ("1.5s").
+type Duration time.Duration
+
+// MarshalJSON writes the delay as a duration string such as "1.5s".
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	// The decoder only hands over well-formed values
+	var value any
+	_ = json.Unmarshal(data, &value)
+
+	var duration time.Duration
+	switch v := value.(type) {
+	case float64:
+		duration = time.Duration(v * float64(time.Millisecond))
+	case string:
+		parsed, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("invalid delay %q: %v", v, err)
+		}
+		duration = parsed
+	default:
+		return fmt.Errorf("invalid delay %s: must be milliseconds or a duration string", data)
+	}
+
+	if duration < 0 {
+		return fmt.Errorf("invalid delay %s: must not be negative", data)
+	}
+	*d = Duration(duration)
+	return nil
+}
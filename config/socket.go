@@ -0,0 +1,105 @@
+package config
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/paqstd-team/fake-cli/jsonschema"
+)
+
+// maxCloseReason is the longest close reason that fits in a close frame.
+const maxCloseReason = 123
+
+// Socket turns an endpoint into a WebSocket route. Push sends generated
+// messages on a schedule, the first of Replies that matches an incoming
+// message answers it, and Close ends the connection from the server side.
+// Requests that don't ask for a WebSocket get the endpoint's usual reply.
+type Socket struct {
+	Push    []Push        `json:"push,omitempty"`
+	Replies []SocketReply `json:"replies,omitempty"`
+	Close   *SocketClose  `json:"close,omitempty"`
+}
+
+// Push sends a message generated from the Message template after Delay, then
+// every Interval. Count limits the number of messages; without it a push is
+// sent once, or forever when it has an interval.
+type Push struct {
+	Message  interface{} `json:"message"`
+	Delay    Duration    `json:"delay,omitempty"`
+	Interval Duration    `json:"interval,omitempty"`
+	Count    int         `json:"count,omitempty"`
+}
+
+// SocketReply answers incoming messages that match When, whose body conditions
+// apply to the message, and PayloadSchema. Other conditions apply to the
+// handshake request. It sends a message generated from Message after Delay,
+// then closes the connection when Close is set.
+type SocketReply struct {
+	When          Match              `json:"when"`
+	PayloadSchema *jsonschema.Schema `json:"payload_schema,omitempty"`
+	Message       interface{}        `json:"message,omitempty"`
+	Delay         Duration           `json:"delay,omitempty"`
+	Close         *SocketClose       `json:"close,omitempty"`
+}
+
+// SocketClose closes a connection with a close code and reason, After the
+// connection opened or the reply was sent.
+type SocketClose struct {
+	Code   int      `json:"code"`
+	Reason string   `json:"reason,omitempty"`
+	After  Duration `json:"after,omitempty"`
+}
+
+// validateSocket checks the socket of an endpoint, which may be nil.
+func validateSocket(socket *Socket, method string) error {
+	if socket == nil {
+		return nil
+	}
+	if method != "" && method != http.MethodGet {
+		return fmt.Errorf("socket requires a GET endpoint, not %s", method)
+	}
+	for i, push := range socket.Push {
+		path := fmt.Sprintf("socket.push[%d]", i)
+		if push.Message == nil {
+			return fmt.Errorf("%s: message is required", path)
+		}
+		if push.Count < 0 {
+			return fmt.Errorf("%s: count %d must not be negative", path, push.Count)
+		}
+		if err := validateTemplate(push.Message, path+".message"); err != nil {
+			return err
+		}
+	}
+	for i, reply := range socket.Replies {
+		path := fmt.Sprintf("socket.replies[%d]", i)
+		if err := validateMatch(reply.When, path+".when"); err != nil {
+			return err
+		}
+		if err := validateSchema(reply.PayloadSchema, path+".payload_schema", false); err != nil {
+			return err
+		}
+		if err := validateTemplate(reply.Message, path+".message"); err != nil {
+			return err
+		}
+		if err := validateSocketClose(reply.Close, path+".close"); err != nil {
+			return err
+		}
+	}
+	return validateSocketClose(socket.Close, "socket.close")
+}
+
+// validateSocketClose checks a close, which may be nil. Codes that must not be
+// sent in a close frame are refused.
+func validateSocketClose(close *SocketClose, path string) error {
+	if close == nil {
+		return nil
+	}
+	code := close.Code
+	if code < 1000 || code > 4999 || (code > 1003 && code < 1007) || (code > 1014 && code < 3000) {
+		return fmt.Errorf("%s: code %d can't be sent, use 1000-1003, 1007-1014 or 3000-4999", path, code)
+	}
+	if len(close.Reason) > maxCloseReason {
+		return fmt.Errorf("%s: reason must be at most %d bytes", path, maxCloseReason)
+	}
+	return nil
+}
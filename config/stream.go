@@ -0,0 +1,35 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultStreamInterval is the time between events unless configured otherwise.
+const DefaultStreamInterval = Duration(time.Second)
+
+// Stream sends the endpoint's generated response as Server-Sent Events, one
+// freshly generated event per Interval. Count ends the stream after that many
+// events, otherwise it runs until the client disconnects. Retry tells clients
+// how long to wait before they reconnect.
+type Stream struct {
+	Event    string   `json:"event,omitempty"`
+	Interval Duration `json:"interval,omitempty"`
+	Count    int      `json:"count,omitempty"`
+	Retry    Duration `json:"retry,omitempty"`
+}
+
+// validateStream checks a stream, which may be nil.
+func validateStream(stream *Stream) error {
+	if stream == nil {
+		return nil
+	}
+	if strings.ContainsAny(stream.Event, "\r\n") {
+		return fmt.Errorf("stream: event %q must be a single line", stream.Event)
+	}
+	if stream.Count < 0 {
+		return fmt.Errorf("stream: count %d must not be negative", stream.Count)
+	}
+	return nil
+}
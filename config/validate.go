@@ -0,0 +1,357 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"mime"
+	"net/url"
+	"regexp"
+	"regexp/syntax"
+	"slices"
+	"strings"
+
+	"github.com/paqstd-team/fake-cli/jsonpath"
+	"github.com/paqstd-team/fake-cli/jsonschema"
+)
+
+// xmlName matches element names, or nothing for the default one.
+var xmlName = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_.-]*)?$`)
+
+// Validate checks the parts of the configuration that can only fail at request
+// time otherwise, such as malformed generator directives in response templates.
+func Validate(config Config) error {
+	if _, err := config.Seed.Value(); err != nil {
+		return err
+	}
+	if prefix := config.Admin.Prefix; prefix != "" && (!strings.HasPrefix(prefix, "/") || prefix == "/") {
+		return fmt.Errorf("admin prefix %q must be a path such as %q", prefix, DefaultAdminPrefix)
+	}
+	if config.Journal.Size < 0 {
+		return fmt.Errorf("journal size %d must not be negative", config.Journal.Size)
+	}
+	if config.Journal.BodySize < 0 {
+		return fmt.Errorf("journal body size %d must not be negative", config.Journal.BodySize)
+	}
+	switch config.Validation {
+	case "", ValidationStructure, ValidationSchema:
+	default:
+		return fmt.Errorf("unknown validation %q: must be %q or %q", config.Validation, ValidationStructure, ValidationSchema)
+	}
+	if err := validateFallback(config.Fallback); err != nil {
+		return err
+	}
+	if err := ValidateFault(config.Fault); err != nil {
+		return err
+	}
+	if err := validateErrors(config.Errors); err != nil {
+		return err
+	}
+	scenarios := make(map[string]bool, len(config.Scenarios))
+	for _, scenario := range config.Scenarios {
+		if scenario.Name == "" || scenario.Initial == "" {
+			return fmt.Errorf("scenario %q: name and initial state are required", scenario.Name)
+		}
+		if scenarios[scenario.Name] {
+			return fmt.Errorf("scenario %q is defined twice", scenario.Name)
+		}
+		scenarios[scenario.Name] = true
+		for _, state := range scenario.States {
+			if state == "" || state == AnyState {
+				return fmt.Errorf("scenario %q: invalid state %q", scenario.Name, state)
+			}
+		}
+	}
+	states := ScenarioStates(config.Scenarios, config.Endpoints)
+
+	for _, endpoint := range config.Endpoints {
+		if endpoint.Scenario != "" && !scenarios[endpoint.Scenario] {
+			return fmt.Errorf("endpoint %s: unknown scenario %q", endpoint.URL, endpoint.Scenario)
+		}
+		if endpoint.Scenario == "" && len(endpoint.States) > 0 {
+			return fmt.Errorf("endpoint %s: states require a scenario", endpoint.URL)
+		}
+		for _, name := range slices.Sorted(maps.Keys(endpoint.States)) {
+			if next := endpoint.States[name].Next; next != "" && !states[endpoint.Scenario][next] {
+				return fmt.Errorf("endpoint %s: states.%s: scenario %q has no state %q", endpoint.URL, name, endpoint.Scenario, next)
+			}
+		}
+		if config.Admin.Covers(endpoint.URL) {
+			return fmt.Errorf("endpoint %s: the path is reserved for the admin API under %s", endpoint.URL, config.Admin.PathPrefix())
+		}
+		if endpoint.Passthrough && config.Fallback.Upstream == "" {
+			return fmt.Errorf("endpoint %s: passthrough requires a fallback upstream", endpoint.URL)
+		}
+		if endpoint.Patch != nil && !endpoint.Passthrough {
+			return fmt.Errorf("endpoint %s: patch requires passthrough", endpoint.URL)
+		}
+		// Directives in payloads only have a meaning for schema validation
+		if config.Validation == ValidationSchema {
+			if err := validateTemplate(endpoint.Payload, "payload"); err != nil {
+				return fmt.Errorf("endpoint %s: %w", endpoint.URL, err)
+			}
+		}
+		if err := validateEndpoint(endpoint); err != nil {
+			return fmt.Errorf("endpoint %s: %w", endpoint.URL, err)
+		}
+	}
+	return nil
+}
+
+func validateEndpoint(endpoint Endpoint) error {
+	if err := validateReply(endpoint.Reply(), ""); err != nil {
+		return err
+	}
+	if err := validateTemplate(endpoint.Patch, "patch"); err != nil {
+		return err
+	}
+	if err := validateSchema(endpoint.PayloadSchema, "payload_schema", false); err != nil {
+		return err
+	}
+	if err := validateSchema(endpoint.Query, "query", true); err != nil {
+		return err
+	}
+	if err := validateSchema(endpoint.HeaderSchema, "header_schema", true); err != nil {
+		return err
+	}
+	for field, upload := range endpoint.Files {
+		if err := validateUpload(upload); err != nil {
+			return fmt.Errorf("files.%s: %w", field, err)
+		}
+	}
+	for i, c := range endpoint.Cases {
+		path := fmt.Sprintf("cases[%d]", i)
+		if err := validateMatch(c.When, path+".when"); err != nil {
+			return err
+		}
+		if err := validateReply(c.Reply, path); err != nil {
+			return err
+		}
+	}
+	for name, variant := range endpoint.Variants {
+		if name == "default" {
+			return fmt.Errorf("variant name %q is reserved for the endpoint's own response", name)
+		}
+		if err := validateReply(variant, "variants."+name); err != nil {
+			return err
+		}
+	}
+	for name, state := range endpoint.States {
+		if err := validateReply(state.Reply, "states."+name); err != nil {
+			return err
+		}
+	}
+	for i, step := range endpoint.Sequence {
+		if err := validateReply(step, fmt.Sprintf("sequence[%d]", i)); err != nil {
+			return err
+		}
+	}
+	switch endpoint.SequenceMode {
+	case "", SequenceStick, SequenceLoop, SequenceRandom:
+	default:
+		return fmt.Errorf("unknown sequence_mode %q: must be %q, %q or %q", endpoint.SequenceMode, SequenceStick, SequenceLoop, SequenceRandom)
+	}
+	if endpoint.SequenceMode != "" && len(endpoint.Sequence) == 0 {
+		return fmt.Errorf("sequence_mode requires a sequence")
+	}
+	if err := ValidateFault(endpoint.Fault); err != nil {
+		return err
+	}
+	if err := validateStream(endpoint.Stream); err != nil {
+		return err
+	}
+	if err := validateSocket(endpoint.Socket, endpoint.Type); err != nil {
+		return err
+	}
+	if endpoint.Format != "" && !slices.Contains(Formats, endpoint.Format) {
+		return fmt.Errorf("unknown format %q: must be one of %s", endpoint.Format, strings.Join(Formats, ", "))
+	}
+	if xml := endpoint.XML; xml != nil && (!xmlName.MatchString(xml.Root) || !xmlName.MatchString(xml.Item)) {
+		return fmt.Errorf("xml: root %q and item %q must be valid element names", xml.Root, xml.Item)
+	}
+	if _, ok := endpoint.Variants[endpoint.Variant]; endpoint.Variant != "" && !ok {
+		return fmt.Errorf("unknown variant %q", endpoint.Variant)
+	}
+	return nil
+}
+
+// ValidateFault checks a fault, which may be nil.
+func ValidateFault(fault *Fault) error {
+	if fault == nil {
+		return nil
+	}
+	if rate := fault.Rate; rate != nil && (*rate < 0 || *rate > 1) {
+		return fmt.Errorf("fault rate %v must be between 0 and 1", *rate)
+	}
+	if fault.Status != 0 && (fault.Status < 100 || fault.Status > 599) {
+		return fmt.Errorf("fault status %d is not a valid HTTP status", fault.Status)
+	}
+	return validateReply(fault.Reply, "fault")
+}
+
+// validateSchema checks a schema, which may be nil. Query parameters and
+// headers are name/value pairs, so their schemas must describe an object.
+func validateSchema(schema *jsonschema.Schema, path string, object bool) error {
+	if schema == nil {
+		return nil
+	}
+	if err := schema.Check(); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if object && len(schema.Type) > 0 && schema.Type.Primary() != "object" {
+		return fmt.Errorf("%s: must be an object schema", path)
+	}
+	return nil
+}
+
+func validateUpload(upload Upload) error {
+	if upload.MinCount < 0 || upload.MaxCount < 0 || upload.MaxSize < 0 {
+		return fmt.Errorf("min_count, max_count and max_size must not be negative")
+	}
+	if upload.MaxCount > 0 && upload.MinCount > upload.MaxCount {
+		return fmt.Errorf("min_count %d is greater than max_count %d", upload.MinCount, upload.MaxCount)
+	}
+	for _, contentType := range upload.ContentTypes {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if major, minor, ok := strings.Cut(mediaType, "/"); err != nil || !ok || major == "" || minor == "" {
+			return fmt.Errorf("invalid content type %q", contentType)
+		}
+	}
+	return nil
+}
+
+func validateErrors(errors Errors) error {
+	for name, reply := range map[string]*Reply{"not_found": errors.NotFound, "method_not_allowed": errors.MethodNotAllowed} {
+		if reply == nil {
+			continue
+		}
+		if reply.Status != 0 && (reply.Status < 100 || reply.Status > 599) {
+			return fmt.Errorf("errors.%s: status %d is not a valid HTTP status", name, reply.Status)
+		}
+		if err := validateReply(*reply, "errors."+name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateFallback(fallback Fallback) error {
+	if fallback.Upstream == "" {
+		if fallback.Record {
+			return fmt.Errorf("fallback: record requires an upstream")
+		}
+		return nil
+	}
+	upstream, err := url.Parse(fallback.Upstream)
+	if err != nil || (upstream.Scheme != "http" && upstream.Scheme != "https") || upstream.Host == "" {
+		return fmt.Errorf("fallback: upstream %q must be an http or https URL", fallback.Upstream)
+	}
+	return nil
+}
+
+// validateReply checks the template of a reply and that it has one source of
+// content at most. path prefixes the fields in errors.
+func validateReply(reply Reply, path string) error {
+	if path != "" {
+		path += "."
+	}
+	if err := validateTemplate(reply.Response, path+"response"); err != nil {
+		return err
+	}
+	sources := 0
+	for _, set := range []bool{reply.Response != nil, reply.Body != "", reply.BodyFile != "", reply.Asset != nil} {
+		if set {
+			sources++
+		}
+	}
+	if sources > 1 {
+		return fmt.Errorf("%sresponse, %sbody, %sbody_file and %sasset are mutually exclusive", path, path, path, path)
+	}
+	if err := validateAsset(reply.Asset); err != nil {
+		return fmt.Errorf("%s%w", path, err)
+	}
+	if reply.Template && reply.Body == "" && reply.BodyFile == "" {
+		return fmt.Errorf("%stemplate requires a body or body_file", path)
+	}
+	return nil
+}
+
+func validateMatch(match Match, path string) error {
+	sources := map[string]map[string]Condition{
+		"query":   match.Query,
+		"headers": match.Headers,
+		"path":    match.Path,
+		"body":    match.Body,
+	}
+	for source, conditions := range sources {
+		for key, condition := range conditions {
+			if source == "body" {
+				if _, err := jsonpath.Parse(key); err != nil {
+					return fmt.Errorf("%s.body: %v", path, err)
+				}
+			}
+			if _, err := regexp.Compile(condition.Regex); err != nil {
+				return fmt.Errorf("%s.%s.%s: invalid regex %q: %v", path, source, key, condition.Regex, err)
+			}
+		}
+	}
+	return nil
+}
+
+func validateTemplate(template any, path string) error {
+	switch t := template.(type) {
+	case map[string]any:
+		if len(t) == 1 {
+			if _, ok := t["$literal"]; ok {
+				return nil
+			}
+			if pattern, ok := t["$regex"]; ok {
+				return validateRegex(pattern, path)
+			}
+			if mask, ok := t["$format"]; ok {
+				return validateFormat(mask, path)
+			}
+			if bounds, ok := t["$range"]; ok {
+				return validateRange(bounds, path)
+			}
+		}
+		for key, value := range t {
+			if err := validateTemplate(value, path+"."+key); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for i, item := range t {
+			if err := validateTemplate(item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func validateRegex(pattern any, path string) error {
+	s, ok := pattern.(string)
+	if !ok {
+		return fmt.Errorf("%s: $regex must be a string", path)
+	}
+	if _, err := syntax.Parse(s, syntax.Perl); err != nil {
+		return fmt.Errorf("%s: invalid $regex %q: %v", path, s, err)
+	}
+	return nil
+}
+
+func validateRange(bounds any, path string) error {
+	if _, ok := jsonschema.ParseRange(bounds); !ok {
+		return fmt.Errorf("%s: $range must be [min, max] or [min, max, \"integer\" or \"number\"] with min <= max, integer bounds whole and at most %d from zero",
+			path, jsonschema.MaxRangeInteger)
+	}
+	return nil
+}
+
+func validateFormat(mask any, path string) error {
+	s, ok := mask.(string)
+	if !ok || s == "" {
+		return fmt.Errorf("%s: $format must be a non-empty string", path)
+	}
+	return nil
+}
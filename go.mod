@@ -5,4 +5,6 @@ go 1.25
 require (
 	github.com/brianvoe/gofakeit/v7 v7.7.3
 	github.com/gorilla/mux v1.8.1
+	github.com/gorilla/websocket v1.5.3
+	gopkg.in/yaml.v3 v3.0.1
 )
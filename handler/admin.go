@@ -0,0 +1,315 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/paqstd-team/fake-cli/config"
+)
+
+// registerAdmin serves the runtime control API under the configured prefix.
+func (s *server) registerAdmin(router *mux.Router) {
+	api := router.PathPrefix(s.config.Admin.PathPrefix()).Subrouter()
+	api.HandleFunc("/endpoints", s.listEndpoints).Methods(http.MethodGet)
+	api.HandleFunc("/endpoints", s.addEndpointRoute).Methods(http.MethodPost)
+	api.HandleFunc("/endpoints", s.removeEndpointRoute).Methods(http.MethodDelete)
+	api.HandleFunc("/cache", s.listCache).Methods(http.MethodGet)
+	api.HandleFunc("/reset", s.resetAll).Methods(http.MethodPost)
+	api.HandleFunc("/seed", s.setSeed).Methods(http.MethodPut)
+	api.HandleFunc("/faults", s.listFaults).Methods(http.MethodGet)
+	api.HandleFunc("/faults", s.setFault).Methods(http.MethodPut)
+	if s.journal != nil {
+		api.HandleFunc("/requests", s.listRequests).Methods(http.MethodGet)
+		api.HandleFunc("/requests", s.clearRequests).Methods(http.MethodDelete)
+		api.HandleFunc("/requests/verify", s.verifyRequests).Methods(http.MethodPost)
+	}
+	if s.recorder != nil {
+		api.HandleFunc("/recordings", s.listRecordings).Methods(http.MethodGet)
+		api.HandleFunc("/recordings", s.clearRecordings).Methods(http.MethodDelete)
+		api.HandleFunc("/recordings/config", s.recordedConfig).Methods(http.MethodGet)
+	}
+	api.HandleFunc("/variants", s.listVariants).Methods(http.MethodGet)
+	api.HandleFunc("/variants", s.setVariant).Methods(http.MethodPut)
+	api.HandleFunc("/scenarios", s.listScenarios).Methods(http.MethodGet)
+	api.HandleFunc("/scenarios", s.setScenario).Methods(http.MethodPut)
+	api.HandleFunc("/scenarios/reset", s.resetScenarios).Methods(http.MethodPost)
+}
+
+// endpointRef names an endpoint in admin requests.
+type endpointRef struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+}
+
+type endpointState struct {
+	endpointRef
+	Config config.Endpoint `json:"config"`
+}
+
+// newEndpointState describes an endpoint with its runtime settings applied.
+func newEndpointState(h *endpointHandler) endpointState {
+	endpoint := h.endpoint
+	endpoint.Variant = h.currentVariant()
+	endpoint.Fault = h.fault.get()
+	return endpointState{endpointRef: endpointRef{Method: h.method, URL: endpoint.URL}, Config: endpoint}
+}
+
+// listEndpoints returns every endpoint with its resolved config.
+func (s *server) listEndpoints(w http.ResponseWriter, r *http.Request) {
+	states := []endpointState{}
+	for _, h := range s.handlers() {
+		states = append(states, newEndpointState(h))
+	}
+	writeJSON(w, http.StatusOK, states)
+}
+
+// addEndpointRoute serves a new endpoint, replacing one with the same method and url.
+func (s *server) addEndpointRoute(w http.ResponseWriter, r *http.Request) {
+	var endpoint config.Endpoint
+	if err := json.NewDecoder(r.Body).Decode(&endpoint); err != nil {
+		s.fail(w, r, http.StatusBadRequest, "Invalid JSON body")
+		return
+	}
+
+	h, err := s.addEndpoint(endpoint)
+	if err != nil {
+		s.fail(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusCreated, newEndpointState(h))
+}
+
+// removeEndpointRoute stops serving an endpoint.
+func (s *server) removeEndpointRoute(w http.ResponseWriter, r *http.Request) {
+	var req endpointRef
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.fail(w, r, http.StatusBadRequest, "Invalid JSON body")
+		return
+	}
+	if err := s.removeEndpoint(req.Method, req.URL); err != nil {
+		s.fail(w, r, http.StatusNotFound, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type cacheState struct {
+	endpointRef
+	Entries map[string]json.RawMessage `json:"entries"`
+}
+
+// listCache returns the cached responses of every endpoint that has a cache.
+// JSON responses are listed as they are, other formats as strings, and
+// binary ones such as MessagePack base64 encoded.
+func (s *server) listCache(w http.ResponseWriter, r *http.Request) {
+	states := []cacheState{}
+	for _, h := range s.handlers() {
+		if h.cache == nil {
+			continue
+		}
+		entries := make(map[string]json.RawMessage)
+		for key, value := range h.cache.Entries() {
+			entries[key] = value.(cachedReply).listed()
+		}
+		states = append(states, cacheState{endpointRef: endpointRef{Method: h.method, URL: h.endpoint.URL}, Entries: entries})
+	}
+	if err := writeJSON(w, http.StatusOK, states); err != nil {
+		s.fail(w, r, http.StatusInternalServerError, fmt.Sprintf("Error listing cache: %v", err))
+	}
+}
+
+// listed returns the cached response as it appears in the cache listing.
+func (c cachedReply) listed() json.RawMessage {
+	if c.format == config.FormatJSON {
+		return c.body
+	}
+	var text any = string(c.body)
+	if encoders[c.format].binary {
+		text = c.body
+	}
+	listed, _ := json.Marshal(text)
+	return listed
+}
+
+// resetAll clears caches, sequences, scenario states and runtime variants.
+func (s *server) resetAll(w http.ResponseWriter, r *http.Request) {
+	s.reset()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// setSeed restarts data generation from a new seed.
+func (s *server) setSeed(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Seed config.Seed `json:"seed"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.fail(w, r, http.StatusBadRequest, "Invalid JSON body")
+		return
+	}
+	seed, err := req.Seed.Value()
+	if err != nil {
+		s.fail(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.seeds.reset(seed)
+	writeJSON(w, http.StatusOK, map[string]uint64{"seed": seed})
+}
+
+type faultState struct {
+	endpointRef
+	Fault *config.Fault `json:"fault"`
+}
+
+// listFaults returns the global fault and every endpoint fault.
+func (s *server) listFaults(w http.ResponseWriter, r *http.Request) {
+	endpoints := []faultState{}
+	for _, h := range s.handlers() {
+		if fault := h.fault.get(); fault != nil {
+			endpoints = append(endpoints, faultState{endpointRef: endpointRef{Method: h.method, URL: h.endpoint.URL}, Fault: fault})
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"fault":     s.globalFault.get(),
+		"endpoints": endpoints,
+	})
+}
+
+// setFault turns a fault on, or off with a null fault. Without a url the
+// global fault is changed.
+func (s *server) setFault(w http.ResponseWriter, r *http.Request) {
+	var req faultState
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.fail(w, r, http.StatusBadRequest, "Invalid JSON body")
+		return
+	}
+	if err := config.ValidateFault(req.Fault); err != nil {
+		s.fail(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if req.URL == "" {
+		s.globalFault.set(req.Fault)
+		s.listFaults(w, r)
+		return
+	}
+	h := s.find(req.Method, req.URL)
+	if h == nil {
+		s.fail(w, r, http.StatusNotFound, "Unknown endpoint")
+		return
+	}
+	h.fault.set(req.Fault)
+	s.listFaults(w, r)
+}
+
+type variantState struct {
+	Method   string   `json:"method"`
+	URL      string   `json:"url"`
+	Variant  string   `json:"variant"`
+	Variants []string `json:"variants"`
+}
+
+func newVariantState(h *endpointHandler) variantState {
+	variant := h.currentVariant()
+	if variant == "" {
+		variant = defaultVariant
+	}
+	return variantState{Method: h.method, URL: h.endpoint.URL, Variant: variant, Variants: h.variantNames()}
+}
+
+// listVariants returns the current variant of every endpoint that has variants.
+func (s *server) listVariants(w http.ResponseWriter, r *http.Request) {
+	states := []variantState{}
+	for _, h := range s.handlers() {
+		if len(h.endpoint.Variants) > 0 {
+			states = append(states, newVariantState(h))
+		}
+	}
+	writeJSON(w, http.StatusOK, states)
+}
+
+// setVariant changes the variant an endpoint serves by default.
+func (s *server) setVariant(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Method  string `json:"method"`
+		URL     string `json:"url"`
+		Variant string `json:"variant"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.fail(w, r, http.StatusBadRequest, "Invalid JSON body")
+		return
+	}
+
+	h := s.find(req.Method, req.URL)
+	if h == nil {
+		s.fail(w, r, http.StatusNotFound, "Unknown endpoint")
+		return
+	}
+	if err := h.setVariant(req.Variant); err != nil {
+		s.fail(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newVariantState(h))
+}
+
+// listScenarios returns the current states of every scenario.
+func (s *server) listScenarios(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.scenarios.snapshot())
+}
+
+// setScenario moves a scenario to a given state.
+func (s *server) setScenario(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name  string `json:"name"`
+		Key   string `json:"key"`
+		State string `json:"state"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.fail(w, r, http.StatusBadRequest, "Invalid JSON body")
+		return
+	}
+	if states, ok := s.scenarioStates(s.handlers())[req.Name]; ok && !states[req.State] {
+		s.fail(w, r, http.StatusBadRequest, fmt.Sprintf("scenario %q has no state %q", req.Name, req.State))
+		return
+	}
+	if err := s.scenarios.set(req.Name, req.Key, req.State); err != nil {
+		s.fail(w, r, http.StatusNotFound, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, s.scenarios.snapshot())
+}
+
+// resetScenarios puts scenarios back into their initial state. Without a body
+// every scenario is reset.
+func (s *server) resetScenarios(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name string  `json:"name"`
+		Key  *string `json:"key"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		s.fail(w, r, http.StatusBadRequest, "Invalid JSON body")
+		return
+	}
+	if err := s.scenarios.reset(req.Name, req.Key); err != nil {
+		s.fail(w, r, http.StatusNotFound, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, s.scenarios.snapshot())
+}
+
+// writeJSON sends v as JSON. Nothing is written when v cannot be encoded.
+func writeJSON(w http.ResponseWriter, status int, v any) error {
+	var encoded bytes.Buffer
+	if err := json.NewEncoder(&encoded).Encode(v); err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(encoded.Bytes())
+	return nil
+}
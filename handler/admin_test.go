@@ -0,0 +1,234 @@
+package handler
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paqstd-team/fake-cli/config"
+)
+
+func TestAdmin_ListEndpoints(t *testing.T) {
+	cacheSize := 1
+	handler := MakeHandler(config.Config{Endpoints: []config.Endpoint{
+		{URL: "/users", Response: map[string]any{"id": "uuid"}, Cache: &cacheSize},
+		{URL: "/users", Type: http.MethodPost, Status: http.StatusCreated, Variants: map[string]config.Reply{"error": {Status: 500}}},
+	}})
+	adminRequest(handler, http.MethodPut, "/__admin/variants", `{"method": "POST", "url": "/users", "variant": "error"}`)
+
+	var states []endpointState
+	w := serve(handler, "/__admin/endpoints", nil)
+	if err := json.Unmarshal(w.Body.Bytes(), &states); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+
+	if len(states) != 2 {
+		t.Fatalf("Expected 2 endpoints, got %d", len(states))
+	}
+	if states[0].Method != http.MethodGet || states[0].Config.Type != http.MethodGet || *states[0].Config.Cache != 1 {
+		t.Errorf("Expected resolved GET endpoint, got %+v", states[0])
+	}
+	if states[1].Config.Variant != "error" {
+		t.Errorf("Expected runtime variant in config, got %q", states[1].Config.Variant)
+	}
+}
+
+func TestAdmin_AddAndRemoveEndpoints(t *testing.T) {
+	handler := MakeHandler(config.Config{})
+
+	if w := serve(handler, "/orders", nil); w.Code != http.StatusNotFound {
+		t.Fatalf("Expected status 404 before adding, got %d", w.Code)
+	}
+
+	w := adminRequest(handler, http.MethodPost, "/__admin/endpoints", `{"url": "/orders", "response": {"total": "10"}}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := serve(handler, "/orders", nil); w.Body.String() != `{"total":"10"}` {
+		t.Errorf("Expected added endpoint, got %s", w.Body.String())
+	}
+
+	// Adding the same method and url replaces the endpoint
+	adminRequest(handler, http.MethodPost, "/__admin/endpoints", `{"url": "/orders", "type": "GET", "response": {"total": "20"}}`)
+	if w := serve(handler, "/orders", nil); w.Body.String() != `{"total":"20"}` {
+		t.Errorf("Expected replaced endpoint, got %s", w.Body.String())
+	}
+
+	w = adminRequest(handler, http.MethodDelete, "/__admin/endpoints", `{"url": "/orders"}`)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("Expected status 204, got %d", w.Code)
+	}
+	if w := serve(handler, "/orders", nil); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 after removing, got %d", w.Code)
+	}
+}
+
+func TestAdmin_AddEndpointWithBodyFile(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "page.html"), []byte("<p>hi</p>"), 0o600); err != nil {
+		t.Fatalf("Failed to write body file: %v", err)
+	}
+	handler := MakeHandler(config.Config{Dir: dir})
+
+	// Relative body files are found next to the config file, as in the config itself
+	w := adminRequest(handler, http.MethodPost, "/__admin/endpoints", `{"url": "/page", "body_file": "page.html"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := serve(handler, "/page", nil); w.Code != http.StatusOK || w.Body.String() != "<p>hi</p>" {
+		t.Errorf("Expected body file, got %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestAdmin_Errors(t *testing.T) {
+	handler := MakeHandler(config.Config{Endpoints: []config.Endpoint{{URL: "/users"}}})
+
+	tests := []struct {
+		name           string
+		method         string
+		target         string
+		body           string
+		expectedStatus int
+	}{
+		{name: "add_invalid_json", method: http.MethodPost, target: "/__admin/endpoints", body: `{`, expectedStatus: http.StatusBadRequest},
+		{name: "add_invalid_endpoint", method: http.MethodPost, target: "/__admin/endpoints", body: `{"url": "/a", "scenario": "nope"}`, expectedStatus: http.StatusBadRequest},
+		{name: "add_under_admin_prefix", method: http.MethodPost, target: "/__admin/endpoints", body: `{"url": "/__admin/users"}`, expectedStatus: http.StatusBadRequest},
+		{name: "remove_invalid_json", method: http.MethodDelete, target: "/__admin/endpoints", body: `{`, expectedStatus: http.StatusBadRequest},
+		{name: "remove_unknown", method: http.MethodDelete, target: "/__admin/endpoints", body: `{"method": "POST", "url": "/users"}`, expectedStatus: http.StatusNotFound},
+		{name: "seed_invalid_json", method: http.MethodPut, target: "/__admin/seed", body: `{`, expectedStatus: http.StatusBadRequest},
+		{name: "seed_invalid", method: http.MethodPut, target: "/__admin/seed", body: `{"seed": "abc"}`, expectedStatus: http.StatusBadRequest},
+		{name: "fault_invalid_json", method: http.MethodPut, target: "/__admin/faults", body: `{`, expectedStatus: http.StatusBadRequest},
+		{name: "fault_invalid", method: http.MethodPut, target: "/__admin/faults", body: `{"fault": {"rate": 2}}`, expectedStatus: http.StatusBadRequest},
+		{name: "fault_unknown_endpoint", method: http.MethodPut, target: "/__admin/faults", body: `{"url": "/nope", "fault": {}}`, expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := adminRequest(handler, tt.method, tt.target, tt.body)
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+		})
+	}
+}
+
+func TestAdmin_CacheAndReset(t *testing.T) {
+	cacheSize := -1
+	handler := MakeHandler(config.Config{
+		Scenarios: []config.Scenario{{Name: "flow", Initial: "start", States: []string{"done"}}},
+		Endpoints: []config.Endpoint{
+			{URL: "/cached", Response: map[string]any{"id": "uuid"}, Cache: &cacheSize},
+			{URL: "/plain", Response: map[string]any{"ok": true}},
+			{URL: "/steps", Sequence: []config.Reply{{Status: 500}, {Status: 200}}},
+			{URL: "/flow", Scenario: "flow", States: map[string]config.State{"start": {Reply: config.Reply{Status: 202}, Next: "done"}}},
+			{URL: "/variant", Variant: "slow", Variants: map[string]config.Reply{"slow": {Status: 503}, "fast": {Status: 200}}},
+		},
+	})
+
+	first := serve(handler, "/cached", nil).Body.String()
+	serve(handler, "/steps", nil)
+	serve(handler, "/flow", nil)
+	adminRequest(handler, http.MethodPut, "/__admin/variants", `{"url": "/variant", "variant": "fast"}`)
+
+	var caches []cacheState
+	if err := json.Unmarshal(serve(handler, "/__admin/cache", nil).Body.Bytes(), &caches); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if len(caches) != 1 || caches[0].URL != "/cached" || len(caches[0].Entries) != 1 {
+		t.Fatalf("Expected one cached entry, got %+v", caches)
+	}
+	for _, entry := range caches[0].Entries {
+		if string(entry) != first {
+			t.Errorf("Expected cached %s, got %s", first, entry)
+		}
+	}
+
+	if w := adminRequest(handler, http.MethodPost, "/__admin/reset", ""); w.Code != http.StatusNoContent {
+		t.Fatalf("Expected status 204, got %d", w.Code)
+	}
+
+	if second := serve(handler, "/cached", nil).Body.String(); second == first {
+		t.Error("Expected cache to be cleared")
+	}
+	if w := serve(handler, "/steps", nil); w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected sequence to start over, got %d", w.Code)
+	}
+	if w := serve(handler, "/flow", nil); w.Code != http.StatusAccepted {
+		t.Errorf("Expected scenario to start over, got %d", w.Code)
+	}
+	if w := serve(handler, "/variant", nil); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected configured variant, got %d", w.Code)
+	}
+}
+
+func TestAdmin_CacheFormats(t *testing.T) {
+	cacheSize := -1
+	handler := MakeHandler(config.Config{Endpoints: []config.Endpoint{
+		{URL: "/json", Response: map[string]any{"id": "uuid"}, Cache: &cacheSize},
+		{URL: "/yaml", Response: map[string]any{"id": "uuid"}, Format: config.FormatYAML, Cache: &cacheSize},
+		{URL: "/msgpack", Response: map[string]any{"id": "uuid"}, Format: config.FormatMsgPack, Cache: &cacheSize},
+	}})
+
+	expected := map[string]any{}
+	for _, target := range []string{"/json", "/yaml", "/msgpack"} {
+		expected[target] = serve(handler, target, nil).Body.String()
+	}
+
+	w := serve(handler, "/__admin/cache", nil)
+	var caches []struct {
+		URL     string         `json:"url"`
+		Entries map[string]any `json:"entries"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &caches); err != nil {
+		t.Fatalf("Failed to unmarshal response %q: %v", w.Body.String(), err)
+	}
+	if len(caches) != 3 {
+		t.Fatalf("Expected three cached endpoints, got %+v", caches)
+	}
+	for _, cache := range caches {
+		for _, entry := range cache.Entries {
+			switch cache.URL {
+			case "/json":
+				if encoded, _ := json.Marshal(entry); string(encoded) != expected["/json"] {
+					t.Errorf("Expected JSON entry %s, got %s", expected["/json"], encoded)
+				}
+			case "/yaml":
+				if entry != expected["/yaml"] {
+					t.Errorf("Expected YAML entry as a string %q, got %v", expected["/yaml"], entry)
+				}
+			case "/msgpack":
+				if decoded, _ := base64.StdEncoding.DecodeString(entry.(string)); string(decoded) != expected["/msgpack"] {
+					t.Errorf("Expected base64 of %q, got %v", expected["/msgpack"], entry)
+				}
+			}
+		}
+	}
+
+	// An entry that can't be listed is reported instead of an empty body
+	s := handler.(*server)
+	s.find(http.MethodGet, "/json").cache.Set("broken", cachedReply{format: config.FormatJSON, body: []byte("{")})
+	if w := serve(handler, "/__admin/cache", nil); w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status 500, got %d", w.Code)
+	}
+}
+
+func TestAdmin_SetSeed(t *testing.T) {
+	handler := MakeHandler(config.Config{Endpoints: []config.Endpoint{{URL: "/users", Response: map[string]any{"id": "uuid"}}}})
+
+	setSeed := func() {
+		t.Helper()
+		if w := adminRequest(handler, http.MethodPut, "/__admin/seed", `{"seed": 7}`); w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+	}
+
+	setSeed()
+	first := serve(handler, "/users", nil).Body.String()
+	setSeed()
+	if second := serve(handler, "/users", nil).Body.String(); second != first {
+		t.Errorf("Expected the same data after setting the same seed, got %s and %s", first, second)
+	}
+}
@@ -0,0 +1,322 @@
+package handler
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/gorilla/mux"
+	"github.com/paqstd-team/fake-cli/config"
+)
+
+// Defaults of generated assets.
+const (
+	defaultAssetWidth      = 640
+	defaultAssetHeight     = 480
+	defaultAssetSize       = 1024
+	defaultAssetBackground = "#cccccc"
+	defaultAssetColor      = "#333333"
+)
+
+// assetKinds generates each type of asset. Images are drawn from the asset
+// alone, files are filled with data from the faker.
+var assetKinds = map[string]struct {
+	contentType string
+	generate    func(asset config.Asset, f *gofakeit.Faker) []byte
+}{
+	config.AssetPNG:  {"image/png", generatePNG},
+	config.AssetJPEG: {"image/jpeg", generateJPEG},
+	config.AssetSVG:  {"image/svg+xml", generateSVG},
+	config.AssetCSV:  {"text/csv", generateCSV},
+	config.AssetPDF:  {"application/pdf", generatePDF},
+	config.AssetZIP:  {"application/zip", generateZIP},
+}
+
+// serveAsset generates the asset of a reply and writes it, see serveContent.
+// It reports whether the asset was served, see respond.
+func (h *endpointHandler) serveAsset(w http.ResponseWriter, r *http.Request, reply config.Reply, status int, gen *generator) bool {
+	asset, err := requestAsset(r, *reply.Asset)
+	if err != nil {
+		h.fail(w, r, http.StatusBadRequest, fmt.Sprintf("Invalid asset: %v", err))
+		return false
+	}
+	kind := assetKinds[asset.Type]
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", kind.contentType)
+	}
+	if asset.Filename != "" {
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": asset.Filename}))
+	}
+	serveContent(w, r, status, time.Time{}, bytes.NewReader(kind.generate(asset, gen.faker)))
+	return true
+}
+
+// requestAsset applies the path variables w and h and the query parameters
+// w, h, size, bg, color and text of the request to the asset, then fills in
+// the defaults.
+func requestAsset(r *http.Request, asset config.Asset) (config.Asset, error) {
+	vars := mux.Vars(r)
+	query := r.URL.Query()
+	for _, param := range []struct {
+		name   string
+		target *int
+		max    int
+	}{
+		{"w", &asset.Width, config.MaxAssetDimension},
+		{"h", &asset.Height, config.MaxAssetDimension},
+		{"size", &asset.Size, config.MaxAssetSize},
+	} {
+		value := vars[param.name]
+		if query.Has(param.name) {
+			value = query.Get(param.name)
+		}
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > param.max {
+			return asset, fmt.Errorf("%s must be a number between 1 and %d, got %q", param.name, param.max, value)
+		}
+		*param.target = n
+	}
+	for _, param := range []struct {
+		name   string
+		target *string
+	}{
+		{"bg", &asset.Background},
+		{"color", &asset.Color},
+		{"text", &asset.Text},
+	} {
+		if query.Has(param.name) {
+			*param.target = query.Get(param.name)
+		}
+	}
+
+	if asset.Width == 0 {
+		asset.Width = defaultAssetWidth
+	}
+	if asset.Height == 0 {
+		asset.Height = defaultAssetHeight
+	}
+	if asset.Size == 0 {
+		asset.Size = defaultAssetSize
+	}
+	if asset.Background == "" {
+		asset.Background = defaultAssetBackground
+	}
+	if asset.Color == "" {
+		asset.Color = defaultAssetColor
+	}
+	if asset.Text == "" {
+		asset.Text = fmt.Sprintf("%dx%d", asset.Width, asset.Height)
+	}
+	for _, c := range []string{asset.Background, asset.Color} {
+		if _, err := config.ParseColor(c); err != nil {
+			return asset, err
+		}
+	}
+	return asset, nil
+}
+
+func generatePNG(asset config.Asset, _ *gofakeit.Faker) []byte {
+	var b bytes.Buffer
+	// Encoding into memory can't fail
+	png.Encode(&b, placeholder(asset))
+	return b.Bytes()
+}
+
+func generateJPEG(asset config.Asset, _ *gofakeit.Faker) []byte {
+	var b bytes.Buffer
+	jpeg.Encode(&b, placeholder(asset), &jpeg.Options{Quality: 90})
+	return b.Bytes()
+}
+
+// placeholder draws the text of the asset, centred, on its background.
+func placeholder(asset config.Asset) image.Image {
+	background, _ := config.ParseColor(asset.Background)
+	foreground, _ := config.ParseColor(asset.Color)
+	img := image.NewRGBA(image.Rect(0, 0, asset.Width, asset.Height))
+	draw.Draw(img, img.Bounds(), &image.Uniform{C: background}, image.Point{}, draw.Src)
+	drawText(img, strings.ToUpper(asset.Text), foreground)
+	return img
+}
+
+// glyphs is a 3x5 pixel font, row by row from the top.
+var glyphs = map[rune]string{
+	'0': "111101101101111", '1': "010110010010111", '2': "111001111100111", '3': "111001111001111",
+	'4': "101101111001001", '5': "111100111001111", '6': "111100111101111", '7': "111001001001001",
+	'8': "111101111101111", '9': "111101111001111", 'A': "010101111101101", 'B': "110101110101110",
+	'C': "011100100100011", 'D': "110101101101110", 'E': "111100110100111", 'F': "111100110100100",
+	'G': "011100101101011", 'H': "101101111101101", 'I': "111010010010111", 'J': "001001001101010",
+	'K': "101101110101101", 'L': "100100100100111", 'M': "101111111101101", 'N': "110101101101101",
+	'O': "010101101101010", 'P': "110101110100100", 'Q': "010101101110011", 'R': "110101110101101",
+	'S': "011100010001110", 'T': "111010010010010", 'U': "101101101101111", 'V': "101101101101010",
+	'W': "101101111111101", 'X': "101101010101101", 'Y': "101101010010010", 'Z': "111001010100111",
+	' ': "000000000000000", '.': "000000000000010", '-': "000000111000000", ':': "000010000010000",
+	'/': "001001010100100", '?': "111001010000010",
+}
+
+// drawText writes text as large as it fits in 80% of the width and half of
+// the height, or not at all when the image is too small.
+func drawText(img *image.RGBA, text string, c color.Color) {
+	runes := []rune(text)
+	width := 4*len(runes) - 1
+	bounds := img.Bounds()
+	scale := min(bounds.Dx()*8/10/width, bounds.Dy()/2/5)
+	if scale < 1 {
+		return
+	}
+	x0 := (bounds.Dx() - width*scale) / 2
+	y0 := (bounds.Dy() - 5*scale) / 2
+	ink := &image.Uniform{C: c}
+	for i, ch := range runes {
+		glyph, ok := glyphs[ch]
+		if !ok {
+			glyph = glyphs['?']
+		}
+		for pixel, bit := range glyph {
+			if bit == '1' {
+				x := x0 + (i*4+pixel%3)*scale
+				y := y0 + pixel/3*scale
+				draw.Draw(img, image.Rect(x, y, x+scale, y+scale), ink, image.Point{}, draw.Src)
+			}
+		}
+	}
+}
+
+func generateSVG(asset config.Asset, _ *gofakeit.Faker) []byte {
+	fontSize := max(min(asset.Height/4, 4*asset.Width/(3*len([]rune(asset.Text)))), 1)
+	var text bytes.Buffer
+	xml.EscapeText(&text, []byte(asset.Text))
+	return fmt.Appendf(nil, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+
+		`<rect width="100%%" height="100%%" fill="%s"/>`+
+		`<text x="50%%" y="50%%" dominant-baseline="middle" text-anchor="middle" font-family="sans-serif" font-size="%d" fill="%s">%s</text>`+
+		`</svg>`,
+		asset.Width, asset.Height, asset.Width, asset.Height, svgColor(asset.Background), fontSize, svgColor(asset.Color), text.String())
+}
+
+func svgColor(s string) string {
+	c, _ := config.ParseColor(s)
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// generateCSV writes a header and as many whole rows of people as fit in the
+// size of the asset.
+func generateCSV(asset config.Asset, f *gofakeit.Faker) []byte {
+	var b, row bytes.Buffer
+	writeRow := func(buf *bytes.Buffer, record []string) {
+		w := csv.NewWriter(buf)
+		w.Write(record)
+		w.Flush()
+	}
+	writeRow(&b, []string{"id", "name", "email", "city", "amount"})
+	for {
+		row.Reset()
+		writeRow(&row, []string{f.UUID(), f.Name(), f.Email(), f.City(), strconv.FormatFloat(f.Price(1, 1000), 'f', 2, 64)})
+		if b.Len()+row.Len() > asset.Size {
+			return b.Bytes()
+		}
+		b.Write(row.Bytes())
+	}
+}
+
+// generatePDF writes a one-page document of sentences. Its content stream is
+// padded with a comment so that the file has exactly the size of the asset,
+// unless the document alone is larger.
+func generatePDF(asset config.Asset, f *gofakeit.Faker) []byte {
+	var text strings.Builder
+	text.WriteString("BT /F1 12 Tf 72 720 Td 16 TL\n")
+	replacer := strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`)
+	for range 20 {
+		fmt.Fprintf(&text, "(%s) Tj T*\n", replacer.Replace(f.Sentence(10)))
+	}
+	text.WriteString("ET\n")
+
+	document := pdf(text.String())
+	if padding := asset.Size - len(document); padding > 0 {
+		comment := "\n"
+		if padding > 1 {
+			comment = "%" + strings.Repeat("-", padding-2) + "\n"
+		}
+		document = pdf(text.String() + comment)
+	}
+	return document
+}
+
+// pdf builds a document around a page content stream. Numbers have a fixed
+// width, so the size of the document only depends on the length of content.
+func pdf(content string) []byte {
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents 4 0 R /Resources << /Font << /F1 5 0 R >> >> >>",
+		fmt.Sprintf("<< /Length %010d >>\nstream\n%sendstream", len(content), content),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+	}
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%-10d\n%%%%EOF\n", len(objects)+1, xref)
+	return b.Bytes()
+}
+
+// generateZIP writes an archive with a readme and random data that makes the
+// archive exactly the size of the asset, unless the readme alone is larger.
+func generateZIP(asset config.Asset, f *gofakeit.Faker) []byte {
+	readme := []byte(f.Paragraph(2, 4, 10, "\n\n") + "\n")
+	archive := zipArchive(readme, nil)
+	if n := asset.Size - len(archive); n > 0 {
+		data := make([]byte, (n+7)/8*8)
+		for i := 0; i < len(data); i += 8 {
+			binary.LittleEndian.PutUint64(data[i:], f.Uint64())
+		}
+		archive = zipArchive(readme, data[:n])
+	}
+	return archive
+}
+
+// zipArchive stores the files uncompressed, so that their size adds up.
+func zipArchive(readme, data []byte) []byte {
+	var b bytes.Buffer
+	w := zip.NewWriter(&b)
+	for _, file := range []struct {
+		name    string
+		content []byte
+	}{{"README.txt", readme}, {"data.bin", data}} {
+		// Writing into memory can't fail
+		entry, _ := w.CreateRaw(&zip.FileHeader{
+			Name:               file.name,
+			Method:             zip.Store,
+			CRC32:              crc32.ChecksumIEEE(file.content),
+			CompressedSize64:   uint64(len(file.content)),
+			UncompressedSize64: uint64(len(file.content)),
+		})
+		entry.Write(file.content)
+	}
+	w.Close()
+	return b.Bytes()
+}
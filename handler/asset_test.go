@@ -0,0 +1,294 @@
+package handler
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"image"
+	"image/png"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/paqstd-team/fake-cli/config"
+)
+
+func TestAsset_Serve(t *testing.T) {
+	tests := []struct {
+		name                string
+		asset               config.Asset
+		reply               *config.Reply
+		target              string
+		header              http.Header
+		expectedStatus      int
+		expectedContentType string
+		expectedWidth       int
+		expectedHeight      int
+		expectedSize        int
+		bodyContains        string
+	}{
+		{
+			name:                "png_default_size",
+			asset:               config.Asset{Type: config.AssetPNG},
+			target:              "/asset",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "image/png",
+			expectedWidth:       640,
+			expectedHeight:      480,
+		},
+		{
+			name:                "png_path_size",
+			asset:               config.Asset{Type: config.AssetPNG, Text: "Hi ~"},
+			target:              "/img/300x200",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "image/png",
+			expectedWidth:       300,
+			expectedHeight:      200,
+		},
+		{
+			name:                "query_overrides_path",
+			asset:               config.Asset{Type: config.AssetJPEG},
+			target:              "/img/300x200?w=50&h=40&bg=000&color=fff",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "image/jpeg",
+			expectedWidth:       50,
+			expectedHeight:      40,
+		},
+		{
+			name:                "image_too_small_for_text",
+			asset:               config.Asset{Type: config.AssetPNG, Width: 2, Height: 2},
+			target:              "/asset",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "image/png",
+			expectedWidth:       2,
+			expectedHeight:      2,
+		},
+		{
+			name:                "svg",
+			asset:               config.Asset{Type: config.AssetSVG, Background: "#fff", Text: "<Hello>"},
+			target:              "/asset?h=100",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "image/svg+xml",
+			bodyContains:        `height="100"`,
+		},
+		{
+			name:                "svg_escapes_text",
+			asset:               config.Asset{Type: config.AssetSVG, Text: "<b>"},
+			target:              "/asset",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "image/svg+xml",
+			bodyContains:        `fill="#333333">&lt;b&gt;</text>`,
+		},
+		{
+			name:                "csv",
+			asset:               config.Asset{Type: config.AssetCSV, Size: 2048},
+			target:              "/asset",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "text/csv",
+			bodyContains:        "id,name,email,city,amount\n",
+		},
+		{
+			name:                "pdf",
+			asset:               config.Asset{Type: config.AssetPDF, Size: 8000},
+			target:              "/asset",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/pdf",
+			expectedSize:        8000,
+			bodyContains:        "%PDF-1.4",
+		},
+		{
+			name:                "pdf_query_size",
+			asset:               config.Asset{Type: config.AssetPDF},
+			target:              "/asset?size=5000",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/pdf",
+			expectedSize:        5000,
+		},
+		{
+			name:                "pdf_smaller_than_document",
+			asset:               config.Asset{Type: config.AssetPDF, Size: 10},
+			target:              "/asset",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/pdf",
+			bodyContains:        "%%EOF",
+		},
+		{
+			name:                "zip",
+			asset:               config.Asset{Type: config.AssetZIP, Size: 4099},
+			target:              "/asset",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/zip",
+			expectedSize:        4099,
+		},
+		{
+			name:                "zip_smaller_than_readme",
+			asset:               config.Asset{Type: config.AssetZIP, Size: 1},
+			target:              "/asset",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/zip",
+		},
+		{
+			name:                "reply_content_type",
+			reply:               &config.Reply{Asset: &config.Asset{Type: config.AssetSVG}, Headers: map[string]string{"Content-Type": "text/plain"}},
+			target:              "/asset",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "text/plain",
+		},
+		{
+			name:           "status_without_ranges",
+			reply:          &config.Reply{Status: http.StatusTeapot, Asset: &config.Asset{Type: config.AssetPDF, Size: 2000}},
+			target:         "/asset",
+			header:         http.Header{"Range": {"bytes=0-3"}},
+			expectedStatus: http.StatusTeapot,
+			expectedSize:   2000,
+		},
+		{
+			name:           "range",
+			asset:          config.Asset{Type: config.AssetPDF, Size: 2000},
+			target:         "/asset",
+			header:         http.Header{"Range": {"bytes=0-3"}},
+			expectedStatus: http.StatusPartialContent,
+			expectedSize:   4,
+			bodyContains:   "%PDF",
+		},
+		{
+			name:           "invalid_width",
+			asset:          config.Asset{Type: config.AssetPNG},
+			target:         "/asset?w=wide",
+			expectedStatus: http.StatusBadRequest,
+			bodyContains:   `w must be a number between 1 and 4096, got \"wide\"`,
+		},
+		{
+			name:           "width_too_large",
+			asset:          config.Asset{Type: config.AssetPNG},
+			target:         "/img/5000x10",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "size_too_large",
+			asset:          config.Asset{Type: config.AssetZIP},
+			target:         "/asset?size=1000000000",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid_colour",
+			asset:          config.Asset{Type: config.AssetPNG},
+			target:         "/asset?bg=red",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			endpoints := []config.Endpoint{{URL: "/asset"}, {URL: "/img/{w:[0-9]+}x{h:[0-9]+}"}}
+			for i := range endpoints {
+				if tt.reply != nil {
+					endpoints[i].Cases = []config.Case{{Reply: *tt.reply}}
+				} else {
+					asset := tt.asset
+					endpoints[i].Asset = &asset
+				}
+			}
+			handler := MakeHandler(config.Config{Endpoints: endpoints})
+
+			w := serve(handler, tt.target, tt.header)
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedContentType != "" && w.Header().Get("Content-Type") != tt.expectedContentType {
+				t.Errorf("Expected content type %s, got %s", tt.expectedContentType, w.Header().Get("Content-Type"))
+			}
+			if tt.expectedWidth != 0 {
+				cfg, _, err := image.DecodeConfig(bytes.NewReader(w.Body.Bytes()))
+				if err != nil {
+					t.Fatalf("Expected an image, got %v", err)
+				}
+				if cfg.Width != tt.expectedWidth || cfg.Height != tt.expectedHeight {
+					t.Errorf("Expected %dx%d, got %dx%d", tt.expectedWidth, tt.expectedHeight, cfg.Width, cfg.Height)
+				}
+			}
+			if tt.expectedSize != 0 && w.Body.Len() != tt.expectedSize {
+				t.Errorf("Expected %d bytes, got %d", tt.expectedSize, w.Body.Len())
+			}
+			if !strings.Contains(w.Body.String(), tt.bodyContains) {
+				t.Errorf("Expected body containing %q, got %q", tt.bodyContains, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestAsset_Content(t *testing.T) {
+	serveAsset := func(asset config.Asset, target string) []byte {
+		handler := MakeHandler(config.Config{Endpoints: []config.Endpoint{{URL: "/asset", Asset: &asset}}})
+		w := serve(handler, target, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		return w.Body.Bytes()
+	}
+
+	t.Run("image_draws_text", func(t *testing.T) {
+		body := serveAsset(config.Asset{Type: config.AssetPNG, Width: 100, Height: 50, Background: "#ffffff", Color: "#ff0000"}, "/asset")
+		img, err := png.Decode(bytes.NewReader(body))
+		if err != nil {
+			t.Fatalf("Expected a PNG, got %v", err)
+		}
+		if r, g, b, _ := img.At(0, 0).RGBA(); r>>8 != 0xff || g>>8 != 0xff || b>>8 != 0xff {
+			t.Errorf("Expected a white background, got %v", img.At(0, 0))
+		}
+		found := false
+		for y := range 50 {
+			for x := range 100 {
+				if r, g, _, _ := img.At(x, y).RGBA(); r>>8 == 0xff && g == 0 {
+					found = true
+				}
+			}
+		}
+		if !found {
+			t.Error("Expected red text pixels")
+		}
+	})
+
+	t.Run("csv_fits_size", func(t *testing.T) {
+		body := serveAsset(config.Asset{Type: config.AssetCSV}, "/asset?size=500")
+		if len(body) > 500 {
+			t.Errorf("Expected at most 500 bytes, got %d", len(body))
+		}
+		records, err := csv.NewReader(bytes.NewReader(body)).ReadAll()
+		if err != nil {
+			t.Fatalf("Expected valid CSV, got %v", err)
+		}
+		if len(records) < 2 {
+			t.Errorf("Expected rows after the header, got %d records", len(records))
+		}
+	})
+
+	t.Run("zip_entries", func(t *testing.T) {
+		body := serveAsset(config.Asset{Type: config.AssetZIP, Size: 3000}, "/asset")
+		archive, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+		if err != nil {
+			t.Fatalf("Expected a ZIP archive, got %v", err)
+		}
+		var names []string
+		for _, file := range archive.File {
+			names = append(names, file.Name)
+			rc, err := file.Open()
+			if err != nil {
+				t.Fatalf("Expected to open %s, got %v", file.Name, err)
+			}
+			rc.Close()
+		}
+		if strings.Join(names, ",") != "README.txt,data.bin" {
+			t.Errorf("Expected README.txt and data.bin, got %v", names)
+		}
+	})
+
+	t.Run("filename_header", func(t *testing.T) {
+		asset := config.Asset{Type: config.AssetCSV, Filename: "report 1.csv"}
+		handler := MakeHandler(config.Config{Endpoints: []config.Endpoint{{URL: "/asset", Asset: &asset}}})
+		w := serve(handler, "/asset", nil)
+		if got := w.Header().Get("Content-Disposition"); got != `attachment; filename="report 1.csv"` {
+			t.Errorf("Expected attachment disposition, got %q", got)
+		}
+	})
+}
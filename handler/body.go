@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/paqstd-team/fake-cli/config"
+)
+
+// hasBody reports whether the reply sends a raw body or an asset instead of a
+// generated response.
+func hasBody(reply config.Reply) bool {
+	return reply.Body != "" || reply.BodyFile != "" || reply.Asset != nil
+}
+
+// serveBody writes the raw body of a reply, see serveContent. It reports
+// whether the body was served, see respond.
+func (h *endpointHandler) serveBody(w http.ResponseWriter, r *http.Request, reply config.Reply, status int, gen *generator) bool {
+	var content io.ReadSeeker
+	var modTime time.Time
+	if reply.BodyFile != "" && !reply.Template {
+		// Files are streamed, so large fixtures are not held in memory
+		file, err := os.Open(h.bodyPath(reply.BodyFile))
+		if err != nil {
+			h.fail(w, r, http.StatusInternalServerError, fmt.Sprintf("Error reading body file: %v", err))
+			return false
+		}
+		defer file.Close()
+		if info, err := file.Stat(); err == nil {
+			modTime = info.ModTime()
+		}
+		content = file
+	} else {
+		data := []byte(reply.Body)
+		if reply.BodyFile != "" {
+			var err error
+			if data, err = os.ReadFile(h.bodyPath(reply.BodyFile)); err != nil {
+				h.fail(w, r, http.StatusInternalServerError, fmt.Sprintf("Error reading body file: %v", err))
+				return false
+			}
+		}
+		if reply.Template {
+			data = []byte(formatTemplateValue(gen.interpolate(string(data))))
+		}
+		content = bytes.NewReader(data)
+	}
+
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", detectContentType(reply.BodyFile, content))
+	}
+	serveContent(w, r, status, modTime, content)
+	return true
+}
+
+// serveContent writes content. Successful replies support range and
+// conditional requests; other statuses are written as is.
+func serveContent(w http.ResponseWriter, r *http.Request, status int, modTime time.Time, content io.ReadSeeker) {
+	if status != http.StatusOK {
+		w.WriteHeader(status)
+		io.Copy(w, content)
+		return
+	}
+	http.ServeContent(w, r, "", modTime, content)
+}
+
+// detectContentType guesses the content type from the file extension, then
+// from the first bytes of the content, telling JSON apart from plain text.
+func detectContentType(name string, content io.ReadSeeker) string {
+	if contentType := mime.TypeByExtension(filepath.Ext(name)); contentType != "" {
+		return contentType
+	}
+	var sniff [512]byte
+	n, _ := io.ReadFull(content, sniff[:])
+	content.Seek(0, io.SeekStart)
+	contentType := http.DetectContentType(sniff[:n])
+	if trimmed := bytes.TrimSpace(sniff[:n]); strings.HasPrefix(contentType, "text/plain") && len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+		return "application/json"
+	}
+	return contentType
+}
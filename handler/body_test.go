@@ -0,0 +1,170 @@
+package handler
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/paqstd-team/fake-cli/config"
+)
+
+func TestBody_Serve(t *testing.T) {
+	dir := t.TempDir()
+	png := "\x89PNG\r\n\x1a\n\x00\x00\x00\x0dIHDR"
+	files := map[string]string{
+		"report.pdf":   "%PDF-1.4 report",
+		"image":        png,
+		"digits.txt":   "0123456789",
+		"greeting.tpl": "Hello {{first_name}}, your id is {{unknown}}",
+		"empty":        "",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name                string
+		endpoint            config.Endpoint
+		header              http.Header
+		expectedStatus      int
+		expectedContentType string
+		expectedBody        string
+		bodyPattern         string
+	}{
+		{
+			name:                "inline_json",
+			endpoint:            config.Endpoint{Body: ` {"id": 1}`},
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/json",
+			expectedBody:        ` {"id": 1}`,
+		},
+		{
+			name:                "inline_html",
+			endpoint:            config.Endpoint{Body: "<html><body>hi</body></html>"},
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "text/html; charset=utf-8",
+			expectedBody:        "<html><body>hi</body></html>",
+		},
+		{
+			name:                "inline_text",
+			endpoint:            config.Endpoint{Body: "plain"},
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "text/plain; charset=utf-8",
+			expectedBody:        "plain",
+		},
+		{
+			name:                "header_sets_content_type",
+			endpoint:            config.Endpoint{Cases: []config.Case{{Reply: config.Reply{Body: "a,b", Headers: map[string]string{"Content-Type": "text/csv"}}}}},
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "text/csv",
+			expectedBody:        "a,b",
+		},
+		{
+			name:                "file_by_extension",
+			endpoint:            config.Endpoint{BodyFile: filepath.Join(dir, "report.pdf")},
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/pdf",
+			expectedBody:        "%PDF-1.4 report",
+		},
+		{
+			name:                "file_by_content",
+			endpoint:            config.Endpoint{BodyFile: filepath.Join(dir, "image")},
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "image/png",
+			expectedBody:        png,
+		},
+		{
+			name:                "empty_file",
+			endpoint:            config.Endpoint{BodyFile: filepath.Join(dir, "empty")},
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "text/plain; charset=utf-8",
+		},
+		{
+			name:                "template_file",
+			endpoint:            config.Endpoint{BodyFile: filepath.Join(dir, "greeting.tpl"), Template: true},
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "text/plain; charset=utf-8",
+			bodyPattern:         `^Hello \w+, your id is \{\{unknown\}\}$`,
+		},
+		{
+			name:                "template_inline",
+			endpoint:            config.Endpoint{Body: "{{int}}", Template: true},
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "text/plain; charset=utf-8",
+			bodyPattern:         `^-?\d+$`,
+		},
+		{
+			name:                "range",
+			endpoint:            config.Endpoint{BodyFile: filepath.Join(dir, "digits.txt")},
+			header:              http.Header{"Range": {"bytes=2-4"}},
+			expectedStatus:      http.StatusPartialContent,
+			expectedContentType: "text/plain; charset=utf-8",
+			expectedBody:        "234",
+		},
+		{
+			name:           "unsatisfiable_range",
+			endpoint:       config.Endpoint{Body: "short"},
+			header:         http.Header{"Range": {"bytes=100-"}},
+			expectedStatus: http.StatusRequestedRangeNotSatisfiable,
+		},
+		{
+			name:           "not_modified",
+			endpoint:       config.Endpoint{BodyFile: filepath.Join(dir, "digits.txt")},
+			header:         http.Header{"If-Modified-Since": {time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)}},
+			expectedStatus: http.StatusNotModified,
+		},
+		{
+			name:                "status_without_ranges",
+			endpoint:            config.Endpoint{Status: http.StatusNotFound, Body: "<h1>Not here</h1>"},
+			header:              http.Header{"Range": {"bytes=0-1"}},
+			expectedStatus:      http.StatusNotFound,
+			expectedContentType: "text/html; charset=utf-8",
+			expectedBody:        "<h1>Not here</h1>",
+		},
+		{
+			name:                "accept_is_ignored",
+			endpoint:            config.Endpoint{Body: "plain"},
+			header:              http.Header{"Accept": {"image/png"}},
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "text/plain; charset=utf-8",
+			expectedBody:        "plain",
+		},
+		{
+			name:           "missing_file",
+			endpoint:       config.Endpoint{BodyFile: filepath.Join(dir, "missing.txt")},
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name:           "missing_template_file",
+			endpoint:       config.Endpoint{BodyFile: filepath.Join(dir, "missing.txt"), Template: true},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			endpoint := tt.endpoint
+			endpoint.URL = "/asset"
+			handler := MakeHandler(config.Config{Endpoints: []config.Endpoint{endpoint}})
+
+			w := serve(handler, "/asset", tt.header)
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedContentType != "" && w.Header().Get("Content-Type") != tt.expectedContentType {
+				t.Errorf("Expected content type %s, got %s", tt.expectedContentType, w.Header().Get("Content-Type"))
+			}
+			if tt.expectedBody != "" && w.Body.String() != tt.expectedBody {
+				t.Errorf("Expected body %q, got %q", tt.expectedBody, w.Body.String())
+			}
+			if tt.bodyPattern != "" && !regexp.MustCompile(tt.bodyPattern).MatchString(w.Body.String()) {
+				t.Errorf("Expected body matching %s, got %q", tt.bodyPattern, w.Body.String())
+			}
+		})
+	}
+}
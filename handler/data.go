@@ -2,498 +2,633 @@ package handler
 
 import (
 	"fmt"
+	"maps"
+	"slices"
+	"strings"
 
 	"github.com/brianvoe/gofakeit/v7"
+	"github.com/paqstd-team/fake-cli/jsonschema"
 )
 
-func generateField(value string) interface{} {
-	switch value {
+// Directive keys turn a single-key object in a template into a generated value,
+// e.g. {"$regex": "^[A-Z]{3}-\\d{4}$"} or {"$format": "ORD-####-??"}.
+// {"$range": [1, 100]} is a number between the bounds, an integer when both are
+// whole, and {"$range": [1, 100, "number"]} sets the type explicitly.
+// A {"$literal": ...} object is returned as is, without any substitution.
+const (
+	regexDirective   = "$regex"
+	formatDirective  = "$format"
+	rangeDirective   = "$range"
+	literalDirective = "$literal"
+)
+
+// String prefixes that make the meaning of a template value explicit: "$word"
+// is always a type and, in strict mode, "=word" is the literal text "word".
+// Outside strict mode a leading "=" is kept, as it always was.
+const (
+	typePrefix    = "$"
+	literalPrefix = "="
+)
+
+// generator turns response templates into data.
+type generator struct {
+	// strict only treats prefixed strings ("$word") and {{word}} tokens as types,
+	// so bare strings that happen to equal a type name are returned as is.
+	strict bool
+	// faker is the source of random data, usually owned by a single request.
+	faker *gofakeit.Faker
+	// files are the files uploaded with the request, for {{files.*}} tokens.
+	files map[string][]uploadedFile
+}
+
+// withFaker returns a copy of the generator that draws data from f.
+func (g *generator) withFaker(f *gofakeit.Faker) *generator {
+	clone := *g
+	clone.faker = f
+	return &clone
+}
+
+func (g *generator) generateField(value string) interface{} {
+	if g.strict && strings.HasPrefix(value, literalPrefix) {
+		return value[len(literalPrefix):]
+	}
+	if isTemplate(value) {
+		return g.interpolate(value)
+	}
+	if strings.HasPrefix(value, typePrefix) {
+		if data, ok := fakeValue(g.faker, value[len(typePrefix):]); ok {
+			return data
+		}
+		return value
+	}
+	if g.strict {
+		return value
+	}
+	if data, ok := fakeValue(g.faker, value); ok {
+		return data
+	}
+	return value
+}
+
+// isFakeType reports whether name is a known type. It generates a throwaway value.
+func isFakeType(name string) bool {
+	_, ok := fakeValue(newFaker(0), name)
+	return ok
+}
+
+// fakeValue generates a value for a known type name. The second return value
+// reports whether the name is a known type.
+func fakeValue(f *gofakeit.Faker, name string) (interface{}, bool) {
+	switch name {
 	// id
 	case "uuid":
-		return gofakeit.UUID()
+		return f.UUID(), true
 	// geography
 	case "city":
-		return gofakeit.City()
+		return f.City(), true
 	case "state":
-		return gofakeit.State()
+		return f.State(), true
 	case "country":
-		return gofakeit.Country()
+		return f.Country(), true
 	case "latitude":
-		return gofakeit.Latitude()
+		return f.Latitude(), true
 	case "longitude":
-		return gofakeit.Longitude()
+		return f.Longitude(), true
 	case "address":
-		return gofakeit.Address().Address
+		return f.Address().Address, true
 	case "street":
-		return gofakeit.Address().Street
+		return f.Address().Street, true
 	case "zip":
-		return gofakeit.Address().Zip
+		return f.Address().Zip, true
 	case "postal_code":
-		return gofakeit.Address().Zip
+		return f.Address().Zip, true
 	case "timezone":
-		return gofakeit.TimeZone()
+		return f.TimeZone(), true
 	case "timezone_abbr":
-		return gofakeit.TimeZoneAbv()
+		return f.TimeZoneAbv(), true
 	case "timezone_full":
-		return gofakeit.TimeZoneFull()
+		return f.TimeZoneFull(), true
 	// person
 	case "name":
-		return gofakeit.Name()
+		return f.Name(), true
 	case "name_prefix":
-		return gofakeit.NamePrefix()
+		return f.NamePrefix(), true
 	case "name_suffix":
-		return gofakeit.NameSuffix()
+		return f.NameSuffix(), true
 	case "first_name":
-		return gofakeit.FirstName()
+		return f.FirstName(), true
 	case "last_name":
-		return gofakeit.LastName()
+		return f.LastName(), true
 	case "gender":
-		return gofakeit.Gender()
+		return f.Gender(), true
 	case "ssn":
-		return gofakeit.SSN()
+		return f.SSN(), true
 	case "hobby":
-		return gofakeit.Hobby()
+		return f.Hobby(), true
 	case "email":
-		return gofakeit.Email()
+		return f.Email(), true
 	case "phone":
-		return gofakeit.Phone()
+		return f.Phone(), true
 	case "username":
-		return gofakeit.Username()
+		return f.Username(), true
 	case "password":
-		return gofakeit.Password(true, true, true, true, true, 8)
+		return f.Password(true, true, true, true, true, 8), true
 	case "company":
-		return gofakeit.Company()
+		return f.Company(), true
 	case "job_title":
-		return gofakeit.JobTitle()
+		return f.JobTitle(), true
 	case "job_descriptor":
-		return gofakeit.JobDescriptor()
+		return f.JobDescriptor(), true
 	case "job_level":
-		return gofakeit.JobLevel()
+		return f.JobLevel(), true
 	case "bs":
-		return gofakeit.BS()
+		return f.BS(), true
 	// text
 	case "paragraph":
-		return gofakeit.Paragraph(5, 10, 3, "\n")
+		return f.Paragraph(5, 10, 3, "\n"), true
 	case "sentence":
-		return gofakeit.Sentence(5)
+		return f.Sentence(5), true
 	case "phrase":
-		return gofakeit.Phrase()
+		return f.Phrase(), true
 	case "quote":
-		return gofakeit.Quote()
+		return f.Quote(), true
 	case "word":
-		return gofakeit.Word()
+		return f.Word(), true
 	// data
 	case "date":
-		return gofakeit.Date()
+		return f.Date(), true
 	case "second":
-		return gofakeit.Second()
+		return f.Second(), true
 	case "minute":
-		return gofakeit.Minute()
+		return f.Minute(), true
 	case "hour":
-		return gofakeit.Hour()
+		return f.Hour(), true
 	case "month":
-		return gofakeit.Month()
+		return f.Month(), true
 	case "day":
-		return gofakeit.Day()
+		return f.Day(), true
 	case "year":
-		return gofakeit.Year()
+		return f.Year(), true
 	case "datetime":
-		return gofakeit.Date()
+		return f.Date(), true
 	case "time":
-		return gofakeit.Date()
+		return f.Date(), true
 	case "weekday":
-		return gofakeit.WeekDay()
+		return f.WeekDay(), true
 	case "month_string":
-		return gofakeit.MonthString()
+		return f.MonthString(), true
 	case "price":
-		return gofakeit.Price(0.50, 1000.00)
+		return f.Price(0.50, 1000.00), true
 	case "currency":
-		return gofakeit.CurrencyShort()
+		return f.CurrencyShort(), true
 	case "currency_long":
-		return gofakeit.CurrencyLong()
+		return f.CurrencyLong(), true
 	case "currency_code":
-		return gofakeit.CurrencyShort()
+		return f.CurrencyShort(), true
 	case "credit_card":
-		return gofakeit.CreditCardNumber(nil)
+		return f.CreditCardNumber(nil), true
 	case "credit_card_cvv":
-		return gofakeit.CreditCardCvv()
+		return f.CreditCardCvv(), true
 	case "credit_card_exp":
-		return gofakeit.CreditCardExp()
+		return f.CreditCardExp(), true
 	case "credit_card_type":
-		return gofakeit.CreditCardType()
+		return f.CreditCardType(), true
 	case "cvv":
-		return gofakeit.CreditCardCvv()
+		return f.CreditCardCvv(), true
 	case "cvc":
-		return gofakeit.CreditCardCvv()
+		return f.CreditCardCvv(), true
 	case "expiry":
-		return gofakeit.CreditCardExp()
+		return f.CreditCardExp(), true
 	case "expiration":
-		return gofakeit.CreditCardExp()
+		return f.CreditCardExp(), true
 	// Banking
 	case "bank_name":
-		return gofakeit.BankName()
+		return f.BankName(), true
 	case "bank_type":
-		return gofakeit.BankType()
+		return f.BankType(), true
 	case "ein":
-		return gofakeit.EIN()
+		return f.EIN(), true
 	case "ach_account":
-		return gofakeit.AchAccount()
+		return f.AchAccount(), true
 	case "ach_routing":
-		return gofakeit.AchRouting()
+		return f.AchRouting(), true
 	// internet
 	case "url":
-		return gofakeit.URL()
+		return f.URL(), true
 	case "domain":
-		return fmt.Sprintf("%s.%s", gofakeit.DomainName(), gofakeit.DomainSuffix())
+		return fmt.Sprintf("%s.%s", f.DomainName(), f.DomainSuffix()), true
 	case "domain_name":
-		return gofakeit.DomainName()
+		return f.DomainName(), true
 	case "domain_suffix":
-		return gofakeit.DomainSuffix()
+		return f.DomainSuffix(), true
 	case "ip":
-		return gofakeit.IPv4Address()
+		return f.IPv4Address(), true
 	case "ipv4":
-		return gofakeit.IPv4Address()
+		return f.IPv4Address(), true
 	case "ipv6":
-		return gofakeit.IPv6Address()
+		return f.IPv6Address(), true
 	case "mac_address":
-		return gofakeit.MacAddress()
+		return f.MacAddress(), true
 	case "http_method":
-		return gofakeit.HTTPMethod()
+		return f.HTTPMethod(), true
 	case "http_status_code":
-		return gofakeit.HTTPStatusCode()
+		return f.HTTPStatusCode(), true
 	case "http_status_simple":
-		return gofakeit.HTTPStatusCodeSimple()
+		return f.HTTPStatusCodeSimple(), true
 	case "user_agent":
-		return gofakeit.UserAgent()
+		return f.UserAgent(), true
 	case "chrome_user_agent":
-		return gofakeit.ChromeUserAgent()
+		return f.ChromeUserAgent(), true
 	case "firefox_user_agent":
-		return gofakeit.FirefoxUserAgent()
+		return f.FirefoxUserAgent(), true
 	case "safari_user_agent":
-		return gofakeit.SafariUserAgent()
+		return f.SafariUserAgent(), true
 	case "opera_user_agent":
-		return gofakeit.OperaUserAgent()
+		return f.OperaUserAgent(), true
 	// products
 	case "product_name":
-		return gofakeit.ProductName()
+		return f.ProductName(), true
 	case "product_category":
-		return gofakeit.ProductCategory()
+		return f.ProductCategory(), true
 	case "product_description":
-		return gofakeit.ProductDescription()
+		return f.ProductDescription(), true
 	case "product_feature":
-		return gofakeit.ProductFeature()
+		return f.ProductFeature(), true
 	case "product_material":
-		return gofakeit.ProductMaterial()
+		return f.ProductMaterial(), true
 	case "product_upc":
-		return gofakeit.ProductUPC()
+		return f.ProductUPC(), true
 	case "product_audience":
-		return gofakeit.ProductAudience()
+		return f.ProductAudience(), true
 	case "product_benefit":
-		return gofakeit.ProductBenefit()
+		return f.ProductBenefit(), true
 	case "product_dimension":
-		return gofakeit.ProductDimension()
+		return f.ProductDimension(), true
 	case "product_isbn":
-		return gofakeit.ProductISBN(nil)
+		return f.ProductISBN(nil), true
 	case "product_suffix":
-		return gofakeit.ProductSuffix()
+		return f.ProductSuffix(), true
 	case "product_use_case":
-		return gofakeit.ProductUseCase()
+		return f.ProductUseCase(), true
 	case "brand":
-		return gofakeit.CarMaker()
+		return f.CarMaker(), true
 	case "color":
-		return gofakeit.Color()
+		return f.Color(), true
 	case "hex_color":
-		return gofakeit.HexColor()
+		return f.HexColor(), true
 	case "rgb_color":
-		return gofakeit.RGBColor()
+		return f.RGBColor(), true
 	case "safe_color":
-		return gofakeit.SafeColor()
+		return f.SafeColor(), true
 	// animals
 	case "animal":
-		return gofakeit.Animal()
+		return f.Animal(), true
 	case "animal_type":
-		return gofakeit.AnimalType()
+		return f.AnimalType(), true
 	case "bird":
-		return gofakeit.Bird()
+		return f.Bird(), true
 	case "cat":
-		return gofakeit.Cat()
+		return f.Cat(), true
 	case "dog":
-		return gofakeit.Dog()
+		return f.Dog(), true
 	case "farm_animal":
-		return gofakeit.FarmAnimal()
+		return f.FarmAnimal(), true
 	case "pet_name":
-		return gofakeit.PetName()
+		return f.PetName(), true
 	// food
 	case "breakfast":
-		return gofakeit.Breakfast()
+		return f.Breakfast(), true
 	case "lunch":
-		return gofakeit.Lunch()
+		return f.Lunch(), true
 	case "dinner":
-		return gofakeit.Dinner()
+		return f.Dinner(), true
 	case "snack":
-		return gofakeit.Snack()
+		return f.Snack(), true
 	case "dessert":
-		return gofakeit.Dessert()
+		return f.Dessert(), true
 	case "drink":
-		return gofakeit.Drink()
+		return f.Drink(), true
 	case "fruit":
-		return gofakeit.Fruit()
+		return f.Fruit(), true
 	case "vegetable":
-		return gofakeit.Vegetable()
+		return f.Vegetable(), true
 	// beer
 	case "beer_name":
-		return gofakeit.BeerName()
+		return f.BeerName(), true
 	case "beer_style":
-		return gofakeit.BeerStyle()
+		return f.BeerStyle(), true
 	case "beer_hop":
-		return gofakeit.BeerHop()
+		return f.BeerHop(), true
 	case "beer_malt":
-		return gofakeit.BeerMalt()
+		return f.BeerMalt(), true
 	case "beer_yeast":
-		return gofakeit.BeerYeast()
+		return f.BeerYeast(), true
 	case "beer_alcohol":
-		return gofakeit.BeerAlcohol()
+		return f.BeerAlcohol(), true
 	case "beer_blg":
-		return gofakeit.BeerBlg()
+		return f.BeerBlg(), true
 	case "beer_ibu":
-		return gofakeit.BeerIbu()
+		return f.BeerIbu(), true
 	// cars
 	case "car_maker":
-		return gofakeit.CarMaker()
+		return f.CarMaker(), true
 	case "car_model":
-		return gofakeit.CarModel()
+		return f.CarModel(), true
 	case "car_type":
-		return gofakeit.CarType()
+		return f.CarType(), true
 	case "car_fuel_type":
-		return gofakeit.CarFuelType()
+		return f.CarFuelType(), true
 	case "car_transmission_type":
-		return gofakeit.CarTransmissionType()
+		return f.CarTransmissionType(), true
 	// movies
 	case "movie_name":
-		return gofakeit.MovieName()
+		return f.MovieName(), true
 	case "movie_genre":
-		return gofakeit.MovieGenre()
+		return f.MovieGenre(), true
 	// books
 	case "book_title":
-		return gofakeit.BookTitle()
+		return f.BookTitle(), true
 	case "book_author":
-		return gofakeit.BookAuthor()
+		return f.BookAuthor(), true
 	case "book_genre":
-		return gofakeit.BookGenre()
+		return f.BookGenre(), true
 	// music
 	case "song":
-		return gofakeit.Song()
+		return f.Song(), true
 	case "song_artist":
-		return gofakeit.SongArtist()
+		return f.SongArtist(), true
 	case "song_genre":
-		return gofakeit.SongGenre()
+		return f.SongGenre(), true
 	case "song_name":
-		return gofakeit.SongName()
+		return f.SongName(), true
 	// apps
 	case "app_name":
-		return gofakeit.AppName()
+		return f.AppName(), true
 	case "app_author":
-		return gofakeit.AppAuthor()
+		return f.AppAuthor(), true
 	case "app_version":
-		return gofakeit.AppVersion()
+		return f.AppVersion(), true
 	// numbers
 	case "int":
-		return gofakeit.Int32()
+		return f.Int32(), true
 	case "int8":
-		return gofakeit.Int8()
+		return f.Int8(), true
 	case "int16":
-		return gofakeit.Int16()
+		return f.Int16(), true
 	case "int32":
-		return gofakeit.Int32()
+		return f.Int32(), true
 	case "int64":
-		return gofakeit.Int64()
+		return f.Int64(), true
 	case "uint8":
-		return gofakeit.Uint8()
+		return f.Uint8(), true
 	case "uint16":
-		return gofakeit.Uint16()
+		return f.Uint16(), true
 	case "uint32":
-		return gofakeit.Uint32()
+		return f.Uint32(), true
 	case "uint64":
-		return gofakeit.Uint64()
+		return f.Uint64(), true
 	case "float":
-		return gofakeit.Float32()
+		return f.Float32(), true
 	case "float32":
-		return gofakeit.Float32()
+		return f.Float32(), true
 	case "float64":
-		return gofakeit.Float64()
+		return f.Float64(), true
 	case "bool":
-		return gofakeit.Bool()
+		return f.Bool(), true
 	case "number":
-		return gofakeit.Number(1, 100)
+		return f.Number(1, 100), true
 	case "int_n":
-		return gofakeit.IntN(10)
+		return f.IntN(10), true
 	case "uint_n":
-		return gofakeit.UintN(10)
+		return f.UintN(10), true
 	case "float32_range":
-		return gofakeit.Float32Range(0.0, 100.0)
+		return f.Float32Range(0.0, 100.0), true
 	case "float64_range":
-		return gofakeit.Float64Range(0.0, 100.0)
+		return f.Float64Range(0.0, 100.0), true
 	case "digit":
-		return gofakeit.Digit()
+		return f.Digit(), true
 	case "digit_n":
-		return gofakeit.DigitN(3)
+		return f.DigitN(3), true
 	case "letter":
-		return gofakeit.Letter()
+		return f.Letter(), true
 	case "letter_n":
-		return gofakeit.LetterN(5)
+		return f.LetterN(5), true
 	case "vowel":
-		return gofakeit.Vowel()
+		return f.Vowel(), true
 	// crypto
 	case "bitcoin_address":
-		return gofakeit.BitcoinAddress()
+		return f.BitcoinAddress(), true
 	case "bitcoin_private_key":
-		return gofakeit.BitcoinPrivateKey()
+		return f.BitcoinPrivateKey(), true
 	// other
 	case "emoji":
-		return gofakeit.Emoji()
+		return f.Emoji(), true
 	case "emoji_alias":
-		return gofakeit.EmojiAlias()
+		return f.EmojiAlias(), true
 	case "emoji_category":
-		return gofakeit.EmojiCategory()
+		return f.EmojiCategory(), true
 	case "emoji_description":
-		return gofakeit.EmojiDescription()
+		return f.EmojiDescription(), true
 	case "emoji_tag":
-		return gofakeit.EmojiTag()
+		return f.EmojiTag(), true
 	case "gamertag":
-		return gofakeit.Gamertag()
+		return f.Gamertag(), true
 	// minecraft
 	case "minecraft_animal":
-		return gofakeit.MinecraftAnimal()
+		return f.MinecraftAnimal(), true
 	case "minecraft_armor_part":
-		return gofakeit.MinecraftArmorPart()
+		return f.MinecraftArmorPart(), true
 	case "minecraft_armor_tier":
-		return gofakeit.MinecraftArmorTier()
+		return f.MinecraftArmorTier(), true
 	case "minecraft_biome":
-		return gofakeit.MinecraftBiome()
+		return f.MinecraftBiome(), true
 	case "minecraft_dye":
-		return gofakeit.MinecraftDye()
+		return f.MinecraftDye(), true
 	case "minecraft_food":
-		return gofakeit.MinecraftFood()
+		return f.MinecraftFood(), true
 	case "minecraft_mob_boss":
-		return gofakeit.MinecraftMobBoss()
+		return f.MinecraftMobBoss(), true
 	case "minecraft_mob_hostile":
-		return gofakeit.MinecraftMobHostile()
+		return f.MinecraftMobHostile(), true
 	case "minecraft_mob_neutral":
-		return gofakeit.MinecraftMobNeutral()
+		return f.MinecraftMobNeutral(), true
 	case "minecraft_mob_passive":
-		return gofakeit.MinecraftMobPassive()
+		return f.MinecraftMobPassive(), true
 	case "minecraft_ore":
-		return gofakeit.MinecraftOre()
+		return f.MinecraftOre(), true
 	case "minecraft_tool":
-		return gofakeit.MinecraftTool()
+		return f.MinecraftTool(), true
 	case "minecraft_villager_job":
-		return gofakeit.MinecraftVillagerJob()
+		return f.MinecraftVillagerJob(), true
 	case "minecraft_villager_level":
-		return gofakeit.MinecraftVillagerLevel()
+		return f.MinecraftVillagerLevel(), true
 	case "minecraft_villager_station":
-		return gofakeit.MinecraftVillagerStation()
+		return f.MinecraftVillagerStation(), true
 	case "minecraft_weapon":
-		return gofakeit.MinecraftWeapon()
+		return f.MinecraftWeapon(), true
 	case "minecraft_weather":
-		return gofakeit.MinecraftWeather()
+		return f.MinecraftWeather(), true
 	case "minecraft_wood":
-		return gofakeit.MinecraftWood()
+		return f.MinecraftWood(), true
 	case "slogan":
-		return gofakeit.Slogan()
+		return f.Slogan(), true
 	case "blurb":
-		return gofakeit.Blurb()
+		return f.Blurb(), true
 	case "comment":
-		return gofakeit.Comment()
+		return f.Comment(), true
 	case "question":
-		return gofakeit.Question()
+		return f.Question(), true
 	case "interjection":
-		return gofakeit.Interjection()
+		return f.Interjection(), true
 	case "connective":
-		return gofakeit.Connective()
+		return f.Connective(), true
 	case "buzzword":
-		return gofakeit.BuzzWord()
+		return f.BuzzWord(), true
 	case "hipster_word":
-		return gofakeit.HipsterWord()
+		return f.HipsterWord(), true
 	case "hipster_sentence":
-		return gofakeit.HipsterSentence(5)
+		return f.HipsterSentence(5), true
 	case "hipster_paragraph":
-		return gofakeit.HipsterParagraph(5, 10, 3, "\n")
+		return f.HipsterParagraph(5, 10, 3, "\n"), true
 	case "hacker_phrase":
-		return gofakeit.HackerPhrase()
+		return f.HackerPhrase(), true
 	case "hacker_abbreviation":
-		return gofakeit.HackerAbbreviation()
+		return f.HackerAbbreviation(), true
 	case "hacker_adjective":
-		return gofakeit.HackerAdjective()
+		return f.HackerAdjective(), true
 	case "hacker_noun":
-		return gofakeit.HackerNoun()
+		return f.HackerNoun(), true
 	case "hacker_verb":
-		return gofakeit.HackerVerb()
+		return f.HackerVerb(), true
 	case "hackering_verb":
-		return gofakeit.HackeringVerb()
+		return f.HackeringVerb(), true
 	case "lorem_ipsum_word":
-		return gofakeit.LoremIpsumWord()
+		return f.LoremIpsumWord(), true
 	case "lorem_ipsum_sentence":
-		return gofakeit.LoremIpsumSentence(5)
+		return f.LoremIpsumSentence(5), true
 	case "lorem_ipsum_paragraph":
-		return gofakeit.LoremIpsumParagraph(5, 10, 3, "\n")
+		return f.LoremIpsumParagraph(5, 10, 3, "\n"), true
 	case "flip_coin":
-		return gofakeit.FlipACoin()
+		return f.FlipACoin(), true
 	case "dice":
-		return gofakeit.Dice(1, []uint{1, 2, 3, 4, 5, 6})
+		return f.Dice(1, []uint{1, 2, 3, 4, 5, 6}), true
 	case "weight":
-		weighted, _ := gofakeit.Weighted([]interface{}{"light", "medium", "heavy"}, []float32{0.1, 0.3, 0.6})
-		return weighted
+		weighted, _ := f.Weighted([]interface{}{"light", "medium", "heavy"}, []float32{0.1, 0.3, 0.6})
+		return weighted, true
 	// Units
 	case "unit":
-		return gofakeit.Unit()
+		return f.Unit(), true
 	default:
-		return value
+		return nil, false
+	}
+}
+
+// generateDirective resolves single-key directive objects such as {"$regex": "..."}.
+// The second return value reports whether the object was a directive at all.
+func (g *generator) generateDirective(fields map[string]interface{}) (interface{}, bool) {
+	if len(fields) != 1 {
+		return nil, false
+	}
+	if literal, ok := fields[literalDirective]; ok {
+		return literal, true
+	}
+	if pattern, ok := fields[regexDirective].(string); ok {
+		return g.faker.Regex(pattern), true
+	}
+	if mask, ok := fields[formatDirective].(string); ok {
+		return g.generateFormat(mask), true
+	}
+	if bounds, ok := fields[rangeDirective]; ok {
+		return g.generateRange(bounds)
+	}
+	return nil, false
+}
+
+// generateRange returns a number between the bounds of a $range directive,
+// see jsonschema.ParseRange.
+func (g *generator) generateRange(bounds interface{}) (interface{}, bool) {
+	r, ok := jsonschema.ParseRange(bounds)
+	if !ok {
+		return nil, false
+	}
+	if r.Integer {
+		return g.faker.IntRange(int(r.Min), int(r.Max)), true
+	}
+	return g.faker.Float64Range(r.Min, r.Max), true
+}
+
+// generateFormat fills a mask: '#' becomes a digit, '?' an upper-case letter and
+// '*' a lower-case hex digit. A backslash emits the following character as is.
+// gofakeit's Numerify, Lexify and Generate don't fit: Numerify turns a leading
+// '0' of the result into another digit, even a literal one, Lexify mixes the
+// case of letters and Generate expands {tags}, and none of them escape.
+func (g *generator) generateFormat(mask string) string {
+	const hexDigits = "0123456789abcdef"
+
+	var b strings.Builder
+	escaped := false
+	for _, r := range mask {
+		if escaped {
+			b.WriteRune(r)
+			escaped = false
+			continue
+		}
+		switch r {
+		case '\\':
+			escaped = true
+		case '#':
+			b.WriteString(g.faker.Digit())
+		case '?':
+			b.WriteString(strings.ToUpper(g.faker.Letter()))
+		case '*':
+			b.WriteByte(hexDigits[g.faker.IntN(len(hexDigits))])
+		default:
+			b.WriteRune(r)
+		}
 	}
+	return b.String()
 }
 
-func generateData(fields interface{}) interface{} {
+func (g *generator) generateData(fields interface{}) interface{} {
 	switch f := fields.(type) {
+	case string:
+		return g.generateField(f)
 	case map[string]string:
 		data := make(map[string]interface{})
-		for key, value := range f {
-			data[key] = generateField(value)
+		for _, key := range slices.Sorted(maps.Keys(f)) {
+			data[key] = g.generateField(f[key])
 		}
 		return data
 	case map[string]interface{}:
+		if value, ok := g.generateDirective(f); ok {
+			return value
+		}
+		// Keys are visited in order so a seed always yields the same data
 		data := make(map[string]interface{})
-		for key, value := range f {
-			switch v := value.(type) {
-			case string:
-				data[key] = generateField(v)
-			default:
-				data[key] = generateData(value)
-			}
+		for _, key := range slices.Sorted(maps.Keys(f)) {
+			data[key] = g.generateData(f[key])
 		}
 		return data
 	case []interface{}:
 		data := make([]interface{}, len(f))
 		for i, item := range f {
-			switch v := item.(type) {
-			case string:
-				data[i] = generateField(v)
-			default:
-				data[i] = generateData(item)
-			}
+			data[i] = g.generateData(item)
 		}
 		return data
 	default:
-		return fmt.Sprintf("Unsupported type: %T", fields)
+		// Numbers, booleans and null are literal values
+		return fields
 	}
 }
 
-func generateDataList(fields interface{}, page int, perPage int) []interface{} {
+func (g *generator) generateDataList(fields interface{}, page int, perPage int) []interface{} {
 	if page < 1 || perPage < 1 {
 		return make([]interface{}, 0)
 	}
 
 	dataList := make([]interface{}, perPage)
 	for i := 0; i < perPage; i++ {
-		dataList[i] = generateData(fields)
+		dataList[i] = g.generateData(fields)
 	}
 
 	return dataList
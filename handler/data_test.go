@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"regexp"
 	"testing"
 
 	"github.com/paqstd-team/fake-cli/config"
@@ -722,12 +723,247 @@ func TestData_GenerateFieldCoverage(t *testing.T) {
 		"company", "job_title", "job_descriptor", "job_level", "bs",
 	}
 
+	gen := &generator{faker: newFaker(1)}
 	for _, fieldType := range testCases {
 		t.Run(fieldType, func(t *testing.T) {
-			result := generateField(fieldType)
+			result := gen.generateField(fieldType)
 			if result == nil {
 				t.Errorf("generateField(%s) returned nil", fieldType)
 			}
 		})
 	}
 }
+
+func TestData_Directives(t *testing.T) {
+	tests := []struct {
+		name    string
+		field   any
+		pattern string
+	}{
+		{
+			name:    "regex_sku",
+			field:   map[string]any{"$regex": `^[A-Z]{3}-\d{4}$`},
+			pattern: `^[A-Z]{3}-\d{4}$`,
+		},
+		{
+			name:    "format_order_id",
+			field:   map[string]any{"$format": "ORD-2024-######"},
+			pattern: `^ORD-2024-\d{6}$`,
+		},
+		{
+			name:    "format_letters_and_hex",
+			field:   map[string]any{"$format": "??-****"},
+			pattern: `^[A-Z]{2}-[0-9a-f]{4}$`,
+		},
+		{
+			name:    "format_escaped_placeholders",
+			field:   map[string]any{"$format": `\#\?\*\\-#`},
+			pattern: `^#\?\*\\-\d$`,
+		},
+		{
+			name:    "format_literal_leading_zero",
+			field:   map[string]any{"$format": "0##"},
+			pattern: `^0\d{2}$`,
+		},
+		{
+			name:    "format_braces",
+			field:   map[string]any{"$format": "{?}-{#}"},
+			pattern: `^\{[A-Z]\}-\{\d\}$`,
+		},
+	}
+
+	gen := &generator{faker: newFaker(1)}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			re := regexp.MustCompile(tt.pattern)
+			for i := 0; i < 20; i++ {
+				data := gen.generateData(tt.field)
+				value, ok := data.(string)
+				if !ok {
+					t.Fatalf("Expected string, got %T", data)
+				}
+				if !re.MatchString(value) {
+					t.Fatalf("Expected %q to match %s", value, tt.pattern)
+				}
+			}
+		})
+	}
+}
+
+func TestData_RangeDirective(t *testing.T) {
+	tests := []struct {
+		name        string
+		bounds      []any
+		expectInt   bool
+		min, max    float64
+		isDirective bool
+	}{
+		{name: "integer", bounds: []any{float64(5), float64(7)}, expectInt: true, min: 5, max: 7, isDirective: true},
+		{name: "float", bounds: []any{0.5, 0.75}, min: 0.5, max: 0.75, isDirective: true},
+		{name: "single_value", bounds: []any{float64(3), float64(3)}, expectInt: true, min: 3, max: 3, isDirective: true},
+		{name: "typed_number", bounds: []any{float64(5), float64(7), "number"}, min: 5, max: 7, isDirective: true},
+		{name: "typed_integer", bounds: []any{float64(5), float64(7), "integer"}, expectInt: true, min: 5, max: 7, isDirective: true},
+		{name: "beyond_integers", bounds: []any{float64(0), 1e300}, min: 0, max: 1e300, isDirective: true},
+		{name: "reversed", bounds: []any{float64(7), float64(5)}},
+		{name: "not_numbers", bounds: []any{"a", "b"}},
+		{name: "wrong_length", bounds: []any{float64(1)}},
+	}
+
+	gen := &generator{faker: newFaker(1)}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, ok := gen.generateDirective(map[string]any{"$range": tt.bounds})
+			if ok != tt.isDirective {
+				t.Fatalf("Expected directive %v, got %v", tt.isDirective, ok)
+			}
+			if !ok {
+				return
+			}
+
+			var number float64
+			switch v := value.(type) {
+			case int:
+				if !tt.expectInt {
+					t.Fatalf("Expected float, got %d", v)
+				}
+				number = float64(v)
+			case float64:
+				if tt.expectInt {
+					t.Fatalf("Expected integer, got %v", v)
+				}
+				number = v
+			default:
+				t.Fatalf("Expected number, got %T", value)
+			}
+			if number < tt.min || number > tt.max {
+				t.Errorf("Expected value between %v and %v, got %v", tt.min, tt.max, number)
+			}
+		})
+	}
+}
+
+func TestData_DirectivesInTemplates(t *testing.T) {
+	cfg := config.Config{
+		Endpoints: []config.Endpoint{
+			{
+				URL: "/api/orders",
+				Response: []any{
+					map[string]any{
+						"id":   map[string]any{"$format": "ORD-####"},
+						"skus": []any{map[string]any{"$regex": `[A-Z]{3}`}},
+						// Objects with several keys are regular nested objects
+						"meta": map[string]any{"$regex": "word", "other": "word"},
+						// Unknown single keys are regular nested objects too
+						"extra": map[string]any{"$unknown": "word"},
+					},
+				},
+			},
+		},
+	}
+	handler := MakeHandler(cfg)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/orders?per_page=3", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	var response []map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if len(response) != 3 {
+		t.Fatalf("Expected 3 items, got %d", len(response))
+	}
+
+	for _, item := range response {
+		if id, _ := item["id"].(string); !regexp.MustCompile(`^ORD-\d{4}$`).MatchString(id) {
+			t.Errorf("Expected id to match mask, got %v", item["id"])
+		}
+		skus, _ := item["skus"].([]any)
+		if len(skus) != 1 || !regexp.MustCompile(`^[A-Z]{3}$`).MatchString(skus[0].(string)) {
+			t.Errorf("Expected skus to match regex, got %v", item["skus"])
+		}
+		if _, ok := item["meta"].(map[string]any); !ok {
+			t.Errorf("Expected meta to stay an object, got %T", item["meta"])
+		}
+		if _, ok := item["extra"].(map[string]any); !ok {
+			t.Errorf("Expected extra to stay an object, got %T", item["extra"])
+		}
+	}
+}
+
+func TestData_NonStringLiterals(t *testing.T) {
+	tests := []struct {
+		name     string
+		response any
+		expected string
+	}{
+		{
+			name:     "object_with_literals",
+			response: map[string]any{"active": true, "count": float64(3), "ratio": 0.5, "parent": nil},
+			expected: `{"active":true,"count":3,"parent":null,"ratio":0.5}`,
+		},
+		{
+			name:     "nested_array_literals",
+			response: map[string]any{"flags": []any{false, float64(1), nil}},
+			expected: `{"flags":[false,1,null]}`,
+		},
+		{
+			name:     "top_level_number",
+			response: float64(42),
+			expected: `42`,
+		},
+		{
+			name:     "top_level_bool",
+			response: true,
+			expected: `true`,
+		},
+		{
+			name:     "top_level_null",
+			response: nil,
+			expected: `null`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.Config{
+				Endpoints: []config.Endpoint{{URL: "/api/literals", Response: tt.response}},
+			}
+			handler := MakeHandler(cfg)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/literals", nil)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Body.String() != tt.expected {
+				t.Errorf("Expected body %s, got %s", tt.expected, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestData_ListWithLiterals(t *testing.T) {
+	cfg := config.Config{
+		Endpoints: []config.Endpoint{
+			{
+				URL:      "/api/items",
+				Response: []any{map[string]any{"id": "uuid", "enabled": true, "version": float64(2)}},
+			},
+		},
+	}
+	handler := MakeHandler(cfg)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/items?per_page=2", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	var response []map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	for _, item := range response {
+		if item["enabled"] != true || item["version"] != float64(2) {
+			t.Errorf("Expected literals to pass through, got %v", item)
+		}
+	}
+}
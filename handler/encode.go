@@ -0,0 +1,273 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"maps"
+	"mime"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/paqstd-team/fake-cli/config"
+	"gopkg.in/yaml.v3"
+)
+
+// encoder writes response data in one format.
+type encoder struct {
+	// contentType is sent with the response, mediaTypes are also accepted for it
+	contentType string
+	mediaTypes  []string
+	// lists is set for formats that can only encode lists
+	lists bool
+	// binary is set for formats whose output is not text
+	binary bool
+	encode func(data any, endpoint config.Endpoint) ([]byte, error)
+}
+
+// encoders holds the response formats by name, see config.Formats.
+var encoders = map[string]encoder{
+	config.FormatJSON: {
+		contentType: "application/json",
+		encode:      func(data any, _ config.Endpoint) ([]byte, error) { return JSONMarshal(data) },
+	},
+	config.FormatXML: {
+		contentType: "application/xml",
+		mediaTypes:  []string{"text/xml"},
+		encode:      encodeXML,
+	},
+	config.FormatYAML: {
+		contentType: "application/yaml",
+		mediaTypes:  []string{"application/x-yaml", "text/yaml"},
+		encode: func(data any, _ config.Endpoint) ([]byte, error) {
+			return normalized(data, yaml.Marshal)
+		},
+	},
+	config.FormatCSV: {
+		contentType: "text/csv",
+		lists:       true,
+		encode:      encodeCSV,
+	},
+	config.FormatMsgPack: {
+		contentType: "application/msgpack",
+		mediaTypes:  []string{"application/x-msgpack", "application/vnd.msgpack"},
+		binary:      true,
+		encode: func(data any, _ config.Endpoint) ([]byte, error) {
+			return normalized(data, encodeMsgPack)
+		},
+	},
+}
+
+// negotiate picks the format of a response: the endpoint's own, or the one
+// the Accept header prefers. It returns false when nothing is acceptable.
+func negotiate(endpoint config.Endpoint, accept string, list bool) (string, bool) {
+	if endpoint.Format != "" {
+		return endpoint.Format, list || !encoders[endpoint.Format].lists
+	}
+	if strings.TrimSpace(accept) == "" {
+		return config.FormatJSON, true
+	}
+
+	best, bestQuality, bestSpecificity := "", 0.0, -1
+	for _, name := range config.Formats {
+		if encoders[name].lists && !list {
+			continue
+		}
+		quality, specificity := acceptance(accept, encoders[name])
+		if quality > bestQuality || (quality == bestQuality && quality > 0 && specificity > bestSpecificity) {
+			best, bestQuality, bestSpecificity = name, quality, specificity
+		}
+	}
+	return best, best != ""
+}
+
+// acceptance returns the quality the Accept header gives to a format, and how
+// specific the media range that gave it was: 2 for type/subtype, 1 for type/*
+// and 0 for */*.
+func acceptance(accept string, enc encoder) (float64, int) {
+	quality, specificity := 0.0, -1
+	for _, part := range strings.Split(accept, ",") {
+		mediaRange, params, err := mime.ParseMediaType(part)
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(value, 64); err != nil {
+				continue
+			}
+		}
+		s := rangeSpecificity(mediaRange, enc)
+		if s > specificity {
+			quality, specificity = q, s
+		}
+	}
+	return quality, specificity
+}
+
+func rangeSpecificity(mediaRange string, enc encoder) int {
+	if mediaRange == "*/*" {
+		return 0
+	}
+	for _, mediaType := range append([]string{enc.contentType}, enc.mediaTypes...) {
+		if mediaRange == mediaType {
+			return 2
+		}
+		if major, _, _ := strings.Cut(mediaType, "/"); mediaRange == major+"/*" {
+			return 1
+		}
+	}
+	return -1
+}
+
+// normalized turns generated data into what JSON makes of it, so that every
+// format sees plain maps, slices, strings, numbers and booleans, then encodes it.
+func normalized(data any, encode func(any) ([]byte, error)) ([]byte, error) {
+	jsonData, err := JSONMarshal(data)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(jsonData))
+	decoder.UseNumber()
+	var value any
+	// JSONMarshal only produces valid JSON
+	_ = decoder.Decode(&value)
+	return encode(plainNumbers(value))
+}
+
+// plainNumbers replaces json.Number with int64 or float64.
+func plainNumbers(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, item := range v {
+			v[key] = plainNumbers(item)
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = plainNumbers(item)
+		}
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		f, _ := v.Float64()
+		return f
+	}
+	return value
+}
+
+// invalidXMLName matches the characters that can't appear in element names.
+var invalidXMLName = regexp.MustCompile(`[^A-Za-z0-9_.-]`)
+
+func encodeXML(data any, endpoint config.Endpoint) ([]byte, error) {
+	root, item := "response", "item"
+	if endpoint.XML != nil && endpoint.XML.Root != "" {
+		root = endpoint.XML.Root
+	}
+	if endpoint.XML != nil && endpoint.XML.Item != "" {
+		item = endpoint.XML.Item
+	}
+	return normalized(data, func(value any) ([]byte, error) {
+		var b bytes.Buffer
+		b.WriteString(xml.Header)
+		e := xml.NewEncoder(&b)
+		writeXML(e, root, item, value)
+		err := e.Flush()
+		return b.Bytes(), err
+	})
+}
+
+// writeXML writes value as an element: objects as child elements named by
+// their keys, lists as item elements and null as an empty element.
+func writeXML(e *xml.Encoder, name, item string, value any) {
+	name = invalidXMLName.ReplaceAllString(name, "_")
+	if name == "" || !strings.ContainsAny(name[:1], "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz_") {
+		name = "_" + name
+	}
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	e.EncodeToken(start)
+	switch v := value.(type) {
+	case map[string]any:
+		for _, key := range slices.Sorted(maps.Keys(v)) {
+			writeXML(e, key, item, v[key])
+		}
+	case []any:
+		for _, entry := range v {
+			writeXML(e, item, item, entry)
+		}
+	case nil:
+	default:
+		e.EncodeToken(xml.CharData(fmt.Sprint(v)))
+	}
+	e.EncodeToken(start.End())
+}
+
+// errNotList is returned for data that only lists can be encoded as.
+var errNotList = errors.New("only lists can be encoded as CSV")
+
+// encodeCSV writes a row for every entry of a list. Nested values are
+// flattened into columns such as "address.city" and "tags.0".
+func encodeCSV(data any, _ config.Endpoint) ([]byte, error) {
+	return normalized(data, func(value any) ([]byte, error) {
+		list, ok := value.([]any)
+		if !ok {
+			return nil, errNotList
+		}
+		rows := make([]map[string]string, len(list))
+		columns := map[string]bool{}
+		for i, entry := range list {
+			rows[i] = map[string]string{}
+			flatten(rows[i], "", entry)
+			for column := range rows[i] {
+				columns[column] = true
+			}
+		}
+		header := slices.Sorted(maps.Keys(columns))
+
+		var b bytes.Buffer
+		w := csv.NewWriter(&b)
+		w.Write(header)
+		for _, row := range rows {
+			record := make([]string, len(header))
+			for i, column := range header {
+				record[i] = row[column]
+			}
+			w.Write(record)
+		}
+		w.Flush()
+		return b.Bytes(), w.Error()
+	})
+}
+
+func flatten(row map[string]string, prefix string, value any) {
+	join := func(key string) string {
+		if prefix == "" {
+			return key
+		}
+		return prefix + "." + key
+	}
+	switch v := value.(type) {
+	case map[string]any:
+		for key, item := range v {
+			flatten(row, join(key), item)
+		}
+	case []any:
+		for i, item := range v {
+			flatten(row, join(strconv.Itoa(i)), item)
+		}
+	default:
+		// Lists of scalars get a single column
+		if prefix == "" {
+			prefix = "value"
+		}
+		row[prefix] = ""
+		if v != nil {
+			row[prefix] = fmt.Sprint(v)
+		}
+	}
+}
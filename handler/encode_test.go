@@ -0,0 +1,231 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/paqstd-team/fake-cli/config"
+)
+
+func TestEncode_Negotiation(t *testing.T) {
+	tests := []struct {
+		name                string
+		endpoint            config.Endpoint
+		accept              string
+		expectedStatus      int
+		expectedContentType string
+	}{
+		{name: "no_accept", accept: "", expectedStatus: http.StatusOK, expectedContentType: "application/json"},
+		{name: "any", accept: "*/*", expectedStatus: http.StatusOK, expectedContentType: "application/json"},
+		{name: "xml", accept: "application/xml", expectedStatus: http.StatusOK, expectedContentType: "application/xml"},
+		{name: "xml_alias", accept: "text/xml", expectedStatus: http.StatusOK, expectedContentType: "application/xml"},
+		{name: "yaml_alias", accept: "application/x-yaml", expectedStatus: http.StatusOK, expectedContentType: "application/yaml"},
+		{name: "msgpack", accept: "application/vnd.msgpack", expectedStatus: http.StatusOK, expectedContentType: "application/msgpack"},
+		{name: "quality", accept: "application/yaml;q=0.5, application/json;q=0.9", expectedStatus: http.StatusOK, expectedContentType: "application/json"},
+		{name: "specific_over_wildcard", accept: "*/*, application/yaml", expectedStatus: http.StatusOK, expectedContentType: "application/yaml"},
+		{name: "excluded", accept: "*/*;q=0.1, application/json;q=0", expectedStatus: http.StatusOK, expectedContentType: "application/xml"},
+		{name: "type_wildcard", accept: "text/*", expectedStatus: http.StatusOK, expectedContentType: "application/xml"},
+		{name: "invalid_ranges_skipped", accept: "bad;;, application/xml;q=high, application/msgpack", expectedStatus: http.StatusOK, expectedContentType: "application/msgpack"},
+		{name: "csv_for_object", accept: "text/csv", expectedStatus: http.StatusNotAcceptable},
+		{name: "unsupported", accept: "image/png", expectedStatus: http.StatusNotAcceptable},
+		{name: "all_excluded", accept: "application/json;q=0", expectedStatus: http.StatusNotAcceptable},
+		{name: "csv_for_list", endpoint: config.Endpoint{Response: []any{map[string]any{"id": "uuid"}}}, accept: "text/csv", expectedStatus: http.StatusOK, expectedContentType: "text/csv"},
+		{name: "fixed_format", endpoint: config.Endpoint{Format: config.FormatYAML}, accept: "application/json", expectedStatus: http.StatusOK, expectedContentType: "application/yaml"},
+		{name: "fixed_csv_for_object", endpoint: config.Endpoint{Format: config.FormatCSV}, expectedStatus: http.StatusNotAcceptable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			endpoint := tt.endpoint
+			endpoint.URL = "/items"
+			if endpoint.Response == nil {
+				endpoint.Response = map[string]any{"id": "uuid"}
+			}
+			handler := MakeHandler(config.Config{Endpoints: []config.Endpoint{endpoint}})
+
+			w := serve(handler, "/items", http.Header{"Accept": {tt.accept}})
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+			if got := w.Header().Get("Content-Type"); got != tt.expectedContentType {
+				t.Errorf("Expected content type %s, got %s", tt.expectedContentType, got)
+			}
+			if vary := w.Header().Get("Vary"); (vary == "Accept") != (tt.endpoint.Format == "") {
+				t.Errorf("Unexpected Vary header %q", vary)
+			}
+		})
+	}
+}
+
+func TestEncode_Formats(t *testing.T) {
+	user := map[string]any{
+		"name":       "Ada",
+		"age":        36.0,
+		"score":      1.5,
+		"ok":         true,
+		"note":       nil,
+		"tags":       []any{"a", "b"},
+		"address":    map[string]any{"city": "London"},
+		"first name": "Ada",
+		"1st":        "yes",
+	}
+
+	tests := []struct {
+		name     string
+		endpoint config.Endpoint
+		accept   string
+		target   string
+		expected string
+	}{
+		{
+			name:     "xml",
+			endpoint: config.Endpoint{Response: user},
+			accept:   "application/xml",
+			expected: `<?xml version="1.0" encoding="UTF-8"?>` + "\n" + `<response><_1st>yes</_1st><address><city>London</city></address><age>36</age><first_name>Ada</first_name><name>Ada</name><note></note><ok>true</ok><score>1.5</score><tags><item>a</item><item>b</item></tags></response>`,
+		},
+		{
+			name:     "xml_names",
+			endpoint: config.Endpoint{Response: []any{"Ada"}, XML: &config.XML{Root: "users", Item: "user"}},
+			accept:   "application/xml",
+			target:   "?per_page=2",
+			expected: `<?xml version="1.0" encoding="UTF-8"?>` + "\n" + `<users><user>Ada</user><user>Ada</user></users>`,
+		},
+		{
+			name:     "xml_empty_key",
+			endpoint: config.Endpoint{Response: map[string]any{"": "x"}},
+			accept:   "application/xml",
+			expected: `<?xml version="1.0" encoding="UTF-8"?>` + "\n" + `<response><_>x</_></response>`,
+		},
+		{
+			name:     "yaml",
+			endpoint: config.Endpoint{Response: map[string]any{"name": "Ada", "age": 36.0, "tags": []any{"a"}}},
+			accept:   "application/yaml",
+			expected: "age: 36\nname: Ada\ntags:\n    - a\n",
+		},
+		{
+			name:     "csv",
+			endpoint: config.Endpoint{Response: []any{map[string]any{"name": "Ada, Lady", "address": map[string]any{"city": "London"}, "tags": []any{"a"}, "note": nil}}},
+			accept:   "text/csv",
+			target:   "?per_page=2",
+			expected: "address.city,name,note,tags.0\nLondon,\"Ada, Lady\",,a\nLondon,\"Ada, Lady\",,a\n",
+		},
+		{
+			name:     "csv_scalars",
+			endpoint: config.Endpoint{Response: []any{"x"}},
+			accept:   "text/csv",
+			target:   "?per_page=1",
+			expected: "value\nx\n",
+		},
+		{
+			name:     "msgpack",
+			endpoint: config.Endpoint{Response: map[string]any{"a": 1.0, "b": []any{true, nil, "x"}, "c": 1.5}},
+			accept:   "application/msgpack",
+			expected: "\x83\xa1a\x01\xa1b\x93\xc3\xc0\xa1x\xa1c\xcb\x3f\xf8\x00\x00\x00\x00\x00\x00",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			endpoint := tt.endpoint
+			endpoint.URL = "/items"
+			handler := MakeHandler(config.Config{Endpoints: []config.Endpoint{endpoint}})
+
+			w := serve(handler, "/items"+tt.target, http.Header{"Accept": {tt.accept}})
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+			}
+			if got := w.Body.String(); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestEncode_CachePerFormat(t *testing.T) {
+	cacheSize := 5
+	handler := MakeHandler(config.Config{Endpoints: []config.Endpoint{{URL: "/items", Cache: &cacheSize, Response: map[string]any{"id": "uuid"}}}})
+
+	first := serve(handler, "/items", nil)
+	xml := serve(handler, "/items", http.Header{"Accept": {"application/xml"}})
+	again := serve(handler, "/items", nil)
+	if !strings.HasPrefix(xml.Body.String(), "<?xml") {
+		t.Errorf("Expected an XML response, got %s", xml.Body.String())
+	}
+	if first.Body.String() != again.Body.String() {
+		t.Errorf("Expected the cached JSON response, got %s and %s", first.Body.String(), again.Body.String())
+	}
+}
+
+func TestEncode_MarshalError(t *testing.T) {
+	original := JSONMarshal
+	JSONMarshal = func(v any) ([]byte, error) { return nil, errors.New("marshal error") }
+	defer func() { JSONMarshal = original }()
+
+	handler := MakeHandler(config.Config{Endpoints: []config.Endpoint{{URL: "/items", Response: map[string]any{"id": "uuid"}}}})
+	w := serve(handler, "/items", http.Header{"Accept": {"application/yaml"}})
+	if w.Code != http.StatusInternalServerError || !strings.Contains(w.Body.String(), "Error generating YAML: marshal error") {
+		t.Errorf("Expected a YAML error, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestEncode_CSVRequiresList(t *testing.T) {
+	if _, err := encodeCSV(map[string]any{}, config.Endpoint{}); !errors.Is(err, errNotList) {
+		t.Errorf("Expected errNotList, got %v", err)
+	}
+}
+
+func TestMsgPack_Encode(t *testing.T) {
+	long := func(n int) []any { return make([]any, n) }
+	object := func(n int) map[string]any {
+		m := make(map[string]any, n)
+		for i := range n {
+			m[strings.Repeat("k", i+1)] = nil
+		}
+		return m
+	}
+
+	tests := []struct {
+		name   string
+		value  any
+		prefix []byte
+	}{
+		{name: "false", value: false, prefix: []byte{0xc2}},
+		{name: "negative_fixint", value: int64(-32), prefix: []byte{0xe0}},
+		{name: "int8", value: int64(-100), prefix: []byte{0xd0, 0x9c}},
+		{name: "int16", value: int64(300), prefix: []byte{0xd1, 0x01, 0x2c}},
+		{name: "int32", value: int64(70000), prefix: []byte{0xd2, 0x00, 0x01, 0x11, 0x70}},
+		{name: "int64", value: int64(1 << 40), prefix: []byte{0xd3, 0x00, 0x00, 0x01, 0x00}},
+		{name: "str8", value: strings.Repeat("a", 32), prefix: []byte{0xd9, 32}},
+		{name: "str16", value: strings.Repeat("a", 256), prefix: []byte{0xda, 0x01, 0x00}},
+		{name: "str32", value: strings.Repeat("a", 65536), prefix: []byte{0xdb, 0x00, 0x01, 0x00, 0x00}},
+		{name: "array16", value: long(16), prefix: []byte{0xdc, 0x00, 0x10}},
+		{name: "array32", value: long(65536), prefix: []byte{0xdd, 0x00, 0x01, 0x00, 0x00}},
+		{name: "map16", value: object(16), prefix: []byte{0xde, 0x00, 0x10}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := encodeMsgPack(tt.value)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !bytes.HasPrefix(got, tt.prefix) {
+				t.Errorf("Expected prefix %x, got %x", tt.prefix, got[:min(len(got), 8)])
+			}
+		})
+	}
+}
+
+func TestMsgPack_UnsupportedType(t *testing.T) {
+	for _, value := range []any{int32(1), []any{int32(1)}, map[string]any{"a": int32(1)}} {
+		if _, err := encodeMsgPack(value); err == nil {
+			t.Errorf("Expected an error for %v", value)
+		}
+	}
+}
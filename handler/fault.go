@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/paqstd-team/fake-cli/config"
+)
+
+// faultSwitch holds a fault that can be changed at runtime. A nil fault is off.
+type faultSwitch struct {
+	mutex sync.Mutex
+	fault *config.Fault
+}
+
+func newFaultSwitch(fault *config.Fault) *faultSwitch {
+	return &faultSwitch{fault: fault}
+}
+
+func (s *faultSwitch) get() *config.Fault {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.fault
+}
+
+func (s *faultSwitch) set(fault *config.Fault) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.fault = fault
+}
+
+// injectedFault returns the reply of the endpoint's fault, or of the global one,
+// when it hits the request.
+func (h *endpointHandler) injectedFault() (config.Reply, bool) {
+	fault := h.fault.get()
+	if fault == nil {
+		fault = h.globalFault.get()
+	}
+	if fault == nil || (fault.Rate != nil && h.random(seedFault).Float64() >= *fault.Rate) {
+		return config.Reply{}, false
+	}
+
+	reply := fault.Reply
+	if reply.Status == 0 {
+		reply.Status = http.StatusInternalServerError
+	}
+	if reply.Response == nil {
+		reply.Response = map[string]any{"error": "injected fault"}
+	}
+	return reply, true
+}
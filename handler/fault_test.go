@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/paqstd-team/fake-cli/config"
+)
+
+func floatPtr(f float64) *float64 {
+	return &f
+}
+
+func TestFault_Injection(t *testing.T) {
+	tests := []struct {
+		name           string
+		global         *config.Fault
+		endpoint       *config.Fault
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "no_fault",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"ok":true}`,
+		},
+		{
+			name:           "global_fault_defaults",
+			global:         &config.Fault{},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"error":"injected fault"}`,
+		},
+		{
+			name:           "endpoint_fault_overrides_global",
+			global:         &config.Fault{},
+			endpoint:       &config.Fault{Reply: config.Reply{Status: http.StatusBadGateway, Response: map[string]any{"reason": "upstream"}}},
+			expectedStatus: http.StatusBadGateway,
+			expectedBody:   `{"reason":"upstream"}`,
+		},
+		{
+			name:           "zero_rate_never_fails",
+			global:         &config.Fault{Rate: floatPtr(0)},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"ok":true}`,
+		},
+		{
+			name:           "full_rate_always_fails",
+			endpoint:       &config.Fault{Rate: floatPtr(1), Reply: config.Reply{Status: http.StatusServiceUnavailable}},
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedBody:   `{"error":"injected fault"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := MakeHandler(config.Config{
+				Fault:     tt.global,
+				Endpoints: []config.Endpoint{{URL: "/items", Response: map[string]any{"ok": true}, Fault: tt.endpoint}},
+			})
+
+			w := serve(handler, "/items", nil)
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if w.Body.String() != tt.expectedBody {
+				t.Errorf("Expected body %s, got %s", tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestFault_Toggle(t *testing.T) {
+	handler := MakeHandler(config.Config{Endpoints: []config.Endpoint{
+		{URL: "/items", Response: map[string]any{"ok": true}},
+		{URL: "/other", Response: map[string]any{"ok": true}},
+	}})
+
+	w := adminRequest(handler, http.MethodPut, "/__admin/faults", `{"url": "/items", "fault": {"status": 503}}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var state struct {
+		Fault     *config.Fault `json:"fault"`
+		Endpoints []faultState  `json:"endpoints"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &state); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if state.Fault != nil || len(state.Endpoints) != 1 || state.Endpoints[0].URL != "/items" {
+		t.Errorf("Unexpected fault state %+v", state)
+	}
+
+	if w := serve(handler, "/items", nil); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503, got %d", w.Code)
+	}
+	if w := serve(handler, "/other", nil); w.Code != http.StatusOK {
+		t.Errorf("Expected other endpoint to be unaffected, got %d", w.Code)
+	}
+
+	// Without a url the global fault is changed
+	adminRequest(handler, http.MethodPut, "/__admin/faults", `{"fault": {"status": 500}}`)
+	if w := serve(handler, "/other", nil); w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status 500, got %d", w.Code)
+	}
+
+	// A null fault turns it off
+	adminRequest(handler, http.MethodPut, "/__admin/faults", `{"fault": null}`)
+	adminRequest(handler, http.MethodPut, "/__admin/faults", `{"url": "/items", "fault": null}`)
+	for _, target := range []string{"/items", "/other"} {
+		if w := serve(handler, target, nil); w.Code != http.StatusOK {
+			t.Errorf("Expected %s to recover, got %d", target, w.Code)
+		}
+	}
+}
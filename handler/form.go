@@ -0,0 +1,234 @@
+package handler
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/paqstd-team/fake-cli/config"
+	"github.com/paqstd-team/fake-cli/jsonschema"
+)
+
+// Content types of form bodies.
+const (
+	formURLEncoded = "application/x-www-form-urlencoded"
+	formMultipart  = "multipart/form-data"
+)
+
+// filesPrefix starts template tokens such as {{files.avatar.name}}.
+const filesPrefix = "files."
+
+// formOverhead is what a multipart body may hold besides its files: fields,
+// part headers and boundaries.
+const formOverhead = 1 << 20
+
+// uploadedFile describes a file sent in a multipart body.
+type uploadedFile struct {
+	name        string
+	contentType string
+	size        int64
+	sha256      string
+	// tooLarge is set when the file exceeds the max size of its field, which
+	// stops reading it, so size and sha256 are unknown
+	tooLarge bool
+}
+
+// filesKey holds the uploaded files in the request context until the reply is generated.
+type filesKey struct{}
+
+// isForm reports whether the request body is a form rather than JSON.
+func isForm(r *http.Request) bool {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return mediaType == formURLEncoded || mediaType == formMultipart
+}
+
+// isMultipart reports whether the request body is a multipart form, which
+// may carry files.
+func isMultipart(r *http.Request) bool {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return mediaType == formMultipart
+}
+
+// maxFormSize returns the largest multipart body the upload limits allow: the
+// largest files of every field plus formOverhead. It is 0, for no limit,
+// unless every field limits both the size and the count of its files.
+func maxFormSize(limits map[string]config.Upload) int64 {
+	if len(limits) == 0 {
+		return 0
+	}
+	size := int64(formOverhead)
+	for _, upload := range limits {
+		if upload.MaxSize == 0 || upload.MaxCount == 0 {
+			return 0
+		}
+		size += upload.MaxSize * int64(upload.MaxCount)
+	}
+	return size
+}
+
+// parseForm decodes a URL-encoded or multipart body into its fields and files.
+// Files are hashed as they are read and never held in memory. Reading a file
+// stops at the max size of its field, the rest of it is skipped.
+func parseForm(contentType string, body io.Reader, limits map[string]config.Upload) (url.Values, map[string][]uploadedFile, error) {
+	mediaType, params, _ := mime.ParseMediaType(contentType)
+	if mediaType == formURLEncoded {
+		data, err := io.ReadAll(body)
+		if err != nil {
+			return nil, nil, err
+		}
+		fields, err := url.ParseQuery(string(data))
+		return fields, nil, err
+	}
+
+	fields := url.Values{}
+	files := map[string][]uploadedFile{}
+	reader := multipart.NewReader(body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			return fields, files, nil
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if part.FileName() == "" {
+			data, err := io.ReadAll(part)
+			if err != nil {
+				return nil, nil, err
+			}
+			fields.Add(part.FormName(), string(data))
+			continue
+		}
+		file, err := readFile(part, limits[part.FormName()].MaxSize)
+		if err != nil {
+			return nil, nil, err
+		}
+		files[part.FormName()] = append(files[part.FormName()], file)
+	}
+}
+
+// readFile hashes a file part, reading at most one byte more than maxSize
+// unless it is 0.
+func readFile(part *multipart.Part, maxSize int64) (uploadedFile, error) {
+	file := uploadedFile{
+		name:        part.FileName(),
+		contentType: part.Header.Get("Content-Type"),
+	}
+	if file.contentType == "" {
+		file.contentType = "application/octet-stream"
+	}
+	var content io.Reader = part
+	if maxSize > 0 {
+		content = io.LimitReader(part, maxSize+1)
+	}
+	hash := sha256.New()
+	size, err := io.Copy(hash, content)
+	if err != nil {
+		return file, err
+	}
+	if maxSize > 0 && size > maxSize {
+		file.tooLarge = true
+		return file, nil
+	}
+	file.size = size
+	file.sha256 = hex.EncodeToString(hash.Sum(nil))
+	return file, nil
+}
+
+// checkFiles checks the uploaded files against the endpoint's limits.
+func (h *endpointHandler) checkFiles(files map[string][]uploadedFile) []jsonschema.Violation {
+	var violations []jsonschema.Violation
+	for _, field := range slices.Sorted(maps.Keys(h.endpoint.Files)) {
+		upload := h.endpoint.Files[field]
+		pointer := "/" + field
+		uploaded := files[field]
+		switch {
+		case len(uploaded) == 0 && upload.MinCount > 0:
+			violations = append(violations, jsonschema.Violation{Pointer: pointer, Message: "is required"})
+		case len(uploaded) < upload.MinCount:
+			violations = append(violations, jsonschema.Violation{Pointer: pointer, Message: fmt.Sprintf("must have at least %d files", upload.MinCount)})
+		case upload.MaxCount > 0 && len(uploaded) > upload.MaxCount:
+			violations = append(violations, jsonschema.Violation{Pointer: pointer, Message: fmt.Sprintf("must have at most %d files", upload.MaxCount)})
+		}
+		for i, file := range uploaded {
+			filePointer := pointer + "/" + strconv.Itoa(i)
+			if file.tooLarge {
+				violations = append(violations, jsonschema.Violation{Pointer: filePointer, Message: fmt.Sprintf("must be at most %d bytes", upload.MaxSize)})
+			}
+			if len(upload.ContentTypes) > 0 && !contentTypeAllowed(file.contentType, upload.ContentTypes) {
+				violations = append(violations, jsonschema.Violation{Pointer: filePointer, Message: fmt.Sprintf("must be of type %s, got %s", strings.Join(upload.ContentTypes, " or "), file.contentType)})
+			}
+		}
+	}
+	return violations
+}
+
+// contentTypeAllowed matches a content type against patterns such as "image/*".
+func contentTypeAllowed(contentType string, patterns []string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(strings.ToLower(pattern), mediaType); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// withFiles returns the request carrying the uploaded files for the reply.
+func withFiles(r *http.Request, files map[string][]uploadedFile) *http.Request {
+	if len(files) == 0 {
+		return r
+	}
+	return r.WithContext(context.WithValue(r.Context(), filesKey{}, files))
+}
+
+// requestFiles returns the files uploaded with the request, if any.
+func requestFiles(r *http.Request) map[string][]uploadedFile {
+	files, _ := r.Context().Value(filesKey{}).(map[string][]uploadedFile)
+	return files
+}
+
+// fileValue resolves a token such as "files.avatar.size" to metadata of the
+// first file uploaded under the field, or "files.avatar.count" to their number.
+func (g *generator) fileValue(name string) (any, bool) {
+	rest, ok := strings.CutPrefix(name, filesPrefix)
+	dot := strings.LastIndex(rest, ".")
+	if !ok || dot < 0 {
+		return nil, false
+	}
+	uploaded := g.files[rest[:dot]]
+	attribute := rest[dot+1:]
+	if attribute == "count" {
+		return len(uploaded), true
+	}
+	if len(uploaded) == 0 {
+		return nil, false
+	}
+	file := uploaded[0]
+	switch attribute {
+	case "name":
+		return file.name, true
+	case "content_type":
+		return file.contentType, true
+	case "size":
+		return file.size, true
+	case "sha256":
+		return file.sha256, true
+	}
+	return nil, false
+}
@@ -0,0 +1,396 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/paqstd-team/fake-cli/config"
+)
+
+// formFile is a file part of a multipart body.
+type formFile struct {
+	field, name, contentType, content string
+}
+
+// multipartBody encodes fields and files, returning the body and its content type.
+func multipartBody(fields map[string]string, files ...formFile) (string, string) {
+	var b bytes.Buffer
+	writer := multipart.NewWriter(&b)
+	for name, value := range fields {
+		writer.WriteField(name, value)
+	}
+	for _, file := range files {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Disposition", `form-data; name="`+file.field+`"; filename="`+file.name+`"`)
+		if file.contentType != "" {
+			header.Set("Content-Type", file.contentType)
+		}
+		part, _ := writer.CreatePart(header)
+		part.Write([]byte(file.content))
+	}
+	writer.Close()
+	return b.String(), writer.FormDataContentType()
+}
+
+func formRequest(handler http.Handler, contentType, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w
+}
+
+func TestForm_Fields(t *testing.T) {
+	multipart, multipartType := multipartBody(map[string]string{"title": "Report", "age": "30"})
+
+	tests := []struct {
+		name           string
+		validation     string
+		endpoint       config.Endpoint
+		contentType    string
+		body           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "urlencoded_structure",
+			endpoint:       config.Endpoint{Payload: map[string]any{"title": "word", "tags": []any{"word"}}},
+			contentType:    formURLEncoded,
+			body:           "title=Report&tags=a&tags=b",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "urlencoded_missing_field",
+			endpoint:       config.Endpoint{Payload: map[string]any{"title": "word"}},
+			contentType:    formURLEncoded,
+			body:           "name=Report",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "empty_form",
+			endpoint:       config.Endpoint{Payload: map[string]any{"title": "word"}},
+			contentType:    formURLEncoded,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid_urlencoded",
+			contentType:    formURLEncoded + "; charset=utf-8",
+			body:           "title=%zz",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "multipart_schema",
+			validation:     config.ValidationSchema,
+			endpoint:       config.Endpoint{Payload: map[string]any{"title": "word", "age": "int"}},
+			contentType:    multipartType,
+			body:           multipart,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "multipart_schema_mistyped",
+			validation:     config.ValidationSchema,
+			endpoint:       config.Endpoint{Payload: map[string]any{"title": "int"}},
+			contentType:    multipartType,
+			body:           multipart,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"type":"about:blank","title":"Unprocessable Entity","status":422,"detail":"Payload does not match schema","instance":"/upload","violations":[{"pointer":"/title","message":"must be of type integer, got string"}]}`,
+		},
+		{
+			name:           "multipart_without_boundary",
+			contentType:    formMultipart,
+			body:           multipart,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "truncated_multipart",
+			contentType:    multipartType,
+			body:           multipart[:len(multipart)/2],
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "case_matches_field",
+			endpoint: config.Endpoint{Cases: []config.Case{{
+				When:  config.Match{Body: map[string]config.Condition{"$.title": {Equals: "Report"}}},
+				Reply: config.Reply{Status: http.StatusAccepted},
+			}}},
+			contentType:    formURLEncoded,
+			body:           "title=Report",
+			expectedStatus: http.StatusAccepted,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			endpoint := tt.endpoint
+			endpoint.URL = "/upload"
+			endpoint.Type = http.MethodPost
+			handler := MakeHandler(config.Config{Validation: tt.validation, Endpoints: []config.Endpoint{endpoint}})
+
+			w := formRequest(handler, tt.contentType, tt.body)
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedBody != "" && strings.TrimSpace(w.Body.String()) != tt.expectedBody {
+				t.Errorf("Expected body %s, got %s", tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestForm_Files(t *testing.T) {
+	png := formFile{field: "avatar", name: "me.png", contentType: "image/png", content: "png data"}
+	files := map[string]config.Upload{
+		"avatar":      {MinCount: 1, MaxCount: 2, MaxSize: 10, ContentTypes: []string{"image/*", "text/plain"}},
+		"attachments": {MinCount: 2},
+	}
+
+	tests := []struct {
+		name           string
+		files          []formFile
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "valid",
+			files:          []formFile{png, {field: "attachments", name: "a.txt"}, {field: "attachments", name: "b.txt"}},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "missing",
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"type":"about:blank","title":"Unprocessable Entity","status":422,"detail":"Files do not match limits","instance":"/upload","violations":[{"pointer":"/attachments","message":"is required"},{"pointer":"/avatar","message":"is required"}]}`,
+		},
+		{
+			name: "wrong_counts_sizes_and_types",
+			files: []formFile{
+				png,
+				{field: "avatar", name: "big.png", contentType: "image/png", content: "far too much data"},
+				{field: "avatar", name: "doc.pdf", contentType: "application/pdf"},
+				{field: "attachments", name: "a.txt"},
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"type":"about:blank","title":"Unprocessable Entity","status":422,"detail":"Files do not match limits","instance":"/upload","violations":[{"pointer":"/attachments","message":"must have at least 2 files"},{"pointer":"/avatar","message":"must have at most 2 files"},{"pointer":"/avatar/1","message":"must be at most 10 bytes"},{"pointer":"/avatar/2","message":"must be of type image/* or text/plain, got application/pdf"}]}`,
+		},
+		{
+			name: "invalid_content_type",
+			files: []formFile{
+				{field: "avatar", name: "me.png", contentType: "image/png;;"},
+				{field: "attachments", name: "a.txt"}, {field: "attachments", name: "b.txt"},
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := MakeHandler(config.Config{Endpoints: []config.Endpoint{{URL: "/upload", Type: http.MethodPost, Files: files}}})
+
+			body, contentType := multipartBody(nil, tt.files...)
+			w := formRequest(handler, contentType, body)
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedBody != "" && strings.TrimSpace(w.Body.String()) != tt.expectedBody {
+				t.Errorf("Expected body %s, got %s", tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestForm_FileTemplates(t *testing.T) {
+	handler := MakeHandler(config.Config{Endpoints: []config.Endpoint{{
+		URL:  "/upload",
+		Type: http.MethodPost,
+		Response: map[string]any{
+			"name":    "{{files.avatar.name}}",
+			"type":    "{{files.avatar.content_type}}",
+			"size":    "{{files.avatar.size}}",
+			"hash":    "{{files.avatar.sha256}}",
+			"count":   "{{files.avatar.count}}",
+			"label":   "{{files.avatar.name}} ({{files.avatar.size}} bytes)",
+			"missing": "{{files.cover.name}}",
+			"none":    "{{files.cover.count}}",
+			"unknown": "{{files.avatar.owner}}",
+			"field":   "{{files.avatar}}",
+		},
+	}}})
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		expected    map[string]any
+	}{
+		{
+			name: "multipart",
+			expected: map[string]any{
+				"name":    "me.png",
+				"type":    "application/octet-stream",
+				"size":    float64(3),
+				"hash":    "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad",
+				"count":   float64(1),
+				"label":   "me.png (3 bytes)",
+				"missing": "{{files.cover.name}}",
+				"none":    float64(0),
+				"unknown": "{{files.avatar.owner}}",
+				"field":   "{{files.avatar}}",
+			},
+		},
+		{
+			name:        "json",
+			contentType: "application/json",
+			body:        `{}`,
+			expected: map[string]any{
+				"name":    "{{files.avatar.name}}",
+				"type":    "{{files.avatar.content_type}}",
+				"size":    "{{files.avatar.size}}",
+				"hash":    "{{files.avatar.sha256}}",
+				"count":   float64(0),
+				"label":   "{{files.avatar.name}} ({{files.avatar.size}} bytes)",
+				"missing": "{{files.cover.name}}",
+				"none":    float64(0),
+				"unknown": "{{files.avatar.owner}}",
+				"field":   "{{files.avatar}}",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, contentType := multipartBody(map[string]string{"title": "Me"}, formFile{field: "avatar", name: "me.png", content: "abc"})
+			if tt.contentType != "" {
+				body, contentType = tt.body, tt.contentType
+			}
+			w := formRequest(handler, contentType, body)
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+			}
+			var got map[string]any
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestForm_ReadError(t *testing.T) {
+	handler := MakeHandler(config.Config{Endpoints: []config.Endpoint{{URL: "/upload", Type: http.MethodPost}}})
+
+	req := httptest.NewRequest(http.MethodPost, "/upload", &errorReader{})
+	req.Header.Set("Content-Type", formURLEncoded)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
+
+func TestForm_Limits(t *testing.T) {
+	files := map[string]config.Upload{"doc": {MaxCount: 1, MaxSize: 10}}
+	small, smallType := multipartBody(nil, formFile{field: "doc", name: "a.txt", content: "small"})
+	large, largeType := multipartBody(map[string]string{"note": strings.Repeat("x", formOverhead)}, formFile{field: "doc", name: "a.txt", content: "small"})
+	partial, partialType := multipartBody(nil, formFile{field: "doc", name: "a.txt", content: "0123456789"})
+	partial = partial[:strings.Index(partial, "01234")+3]
+
+	tests := []struct {
+		name           string
+		files          map[string]config.Upload
+		passthrough    bool
+		contentType    string
+		body           io.Reader
+		expectedStatus int
+		expectedDetail string
+	}{
+		{
+			name:           "within_limit",
+			files:          files,
+			contentType:    smallType,
+			body:           strings.NewReader(small),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "body_too_large",
+			files:          files,
+			contentType:    largeType,
+			body:           strings.NewReader(large),
+			expectedStatus: http.StatusRequestEntityTooLarge,
+			expectedDetail: "Request body is larger than 1048586 bytes",
+		},
+		{
+			name:           "passed_through_body_too_large",
+			files:          files,
+			passthrough:    true,
+			contentType:    largeType,
+			body:           strings.NewReader(large),
+			expectedStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:           "unlimited_count",
+			files:          map[string]config.Upload{"doc": {MaxSize: 10}},
+			contentType:    largeType,
+			body:           strings.NewReader(large),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "read_error_in_file",
+			files:          files,
+			contentType:    partialType,
+			body:           io.MultiReader(strings.NewReader(partial), &errorReader{}),
+			expectedStatus: http.StatusBadRequest,
+			expectedDetail: "Invalid form body: read error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.Config{Endpoints: []config.Endpoint{{URL: "/upload", Type: http.MethodPost, Files: tt.files, Passthrough: tt.passthrough}}}
+			if tt.passthrough {
+				cfg.Fallback.Upstream = "http://127.0.0.1:1"
+			}
+			handler := MakeHandler(cfg)
+
+			req := httptest.NewRequest(http.MethodPost, "/upload", tt.body)
+			req.Header.Set("Content-Type", tt.contentType)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if !strings.Contains(w.Body.String(), tt.expectedDetail) {
+				t.Errorf("Expected detail %q, got %s", tt.expectedDetail, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestForm_ParseReadError(t *testing.T) {
+	if _, _, err := parseForm(formURLEncoded, &errorReader{}, nil); err == nil {
+		t.Error("Expected the read error")
+	}
+}
+
+func TestForm_NotJournaled(t *testing.T) {
+	handler := MakeHandler(config.Config{Endpoints: []config.Endpoint{{URL: "/upload", Type: http.MethodPost}}})
+	body, contentType := multipartBody(map[string]string{"title": "Report"}, formFile{field: "doc", name: "a.txt", content: "secret file"})
+	formRequest(handler, contentType, body)
+
+	w := serve(handler, "/__admin/requests", nil)
+	var entries []struct{ Body string }
+	if err := json.Unmarshal(w.Body.Bytes(), &entries); err != nil || len(entries) != 1 {
+		t.Fatalf("Expected one journal entry, got %s", w.Body.String())
+	}
+	if entries[0].Body != "" {
+		t.Errorf("Expected the multipart body not to be recorded, got %q", entries[0].Body)
+	}
+}
@@ -1,14 +1,30 @@
 package handler
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
 
-	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
 	"github.com/paqstd-team/fake-cli/cache"
 	"github.com/paqstd-team/fake-cli/config"
+	"github.com/paqstd-team/fake-cli/jsonschema"
+	"github.com/paqstd-team/fake-cli/record"
+)
+
+// Reply keys of the endpoint's own reply and of an injected fault.
+const (
+	defaultReply = "default"
+	faultReply   = "fault"
 )
 
 // JSONMarshal is used to marshal response data. It is a variable to allow tests
@@ -16,106 +32,409 @@ import (
 var JSONMarshal = json.Marshal
 
 func MakeHandler(config config.Config) http.Handler {
-	mux := mux.NewRouter()
+	// The seed is validated when the config is loaded
+	seed, _ := config.Seed.Value()
+	s := &server{
+		shared: &shared{
+			gen:         &generator{strict: config.Strict},
+			seeds:       newSeedSource(seed),
+			scenarios:   newScenarioStore(config.Scenarios),
+			globalFault: newFaultSwitch(config.Fault),
+			validation:  config.Validation,
+			errors:      config.Errors,
+			dir:         config.Dir,
+		},
+		config: config,
+	}
+	if !config.Journal.Disabled {
+		s.journal = newJournal(config.Journal)
+	}
+	if config.Fallback.Upstream != "" {
+		// The upstream is validated when the config is loaded
+		s.upstream, _ = url.Parse(config.Fallback.Upstream)
+		if config.Fallback.Record {
+			s.recorder = record.NewRecorder()
+		}
+		s.proxy = newFallbackProxy(s.upstream, s.recorder)
+		s.proxy.ErrorHandler = s.proxyError
+	}
+
+	if config.Strict {
+		for _, note := range strictModeNotes(config) {
+			log.Print(note)
+		}
+	}
 
 	for _, endpoint := range config.Endpoints {
-		method := endpoint.Type
-		if method == "" {
-			method = http.MethodGet
+		s.endpoints = append(s.endpoints, newEndpointHandler(endpoint, s.shared))
+	}
+	s.route()
+
+	return s
+}
+
+// endpointHandler serves a single configured endpoint.
+type endpointHandler struct {
+	*shared
+	endpoint config.Endpoint
+	method   string
+	// cache is nil unless the endpoint configures one
+	cache *cache.Cache
+	cases []*requestMatcher
+	// sequence is nil unless the endpoint configures one
+	sequence *sequence
+	fault    *faultSwitch
+	// proxy forwards requests for passthrough endpoints, nil otherwise
+	proxy *httputil.ReverseProxy
+	// payloadSchema validates request bodies, nil to only check their structure
+	payloadSchema *jsonschema.Schema
+	// socketReplies are the compiled conditions of the socket's replies
+	socketReplies []*requestMatcher
+
+	mutex sync.Mutex
+	// variant is the runtime default variant, empty for the endpoint's own reply
+	variant string
+}
+
+func newEndpointHandler(endpoint config.Endpoint, shared *shared) *endpointHandler {
+	if endpoint.Type == "" {
+		endpoint.Type = http.MethodGet
+	}
+	h := &endpointHandler{
+		shared:   shared,
+		endpoint: endpoint,
+		method:   endpoint.Type,
+		fault:    newFaultSwitch(endpoint.Fault),
+		variant:  endpoint.Variant,
+	}
+
+	// Create individual cache for this endpoint if cache is specified
+	if endpoint.Cache != nil {
+		h.cache = cache.NewCache(*endpoint.Cache)
+	}
+
+	for _, c := range endpoint.Cases {
+		h.cases = append(h.cases, compileMatch(c.When))
+	}
+
+	if endpoint.Socket != nil {
+		for _, reply := range endpoint.Socket.Replies {
+			h.socketReplies = append(h.socketReplies, compileMatch(reply.When))
 		}
+	}
 
-		// Create individual cache for this endpoint if cache is specified
-		var endpointCache *cache.Cache
-		if endpoint.Cache != nil {
-			endpointCache = cache.NewCache(*endpoint.Cache)
+	if len(endpoint.Sequence) > 0 {
+		h.sequence = newSequence(endpoint.Sequence, endpoint.SequenceMode, func(n int) int {
+			return h.random(seedSequence).IntN(n)
+		})
+	}
+
+	h.payloadSchema = endpoint.PayloadSchema
+	if h.payloadSchema == nil && endpoint.Payload != nil && shared.validation == config.ValidationSchema {
+		h.payloadSchema = jsonschema.FromPayload(endpoint.Payload, shared.gen.describeField)
+		// Templates are validated when the config is loaded, so this only
+		// compiles the patterns
+		_ = h.payloadSchema.Check()
+	}
+
+	if endpoint.Passthrough {
+		var modify func(*http.Response) error
+		if endpoint.Patch != nil {
+			modify = h.patchResponse
 		}
+		h.proxy = newProxy(shared.upstream, modify)
+		h.proxy.ErrorHandler = shared.proxyError
+	}
+
+	return h
+}
 
-		handlerFunc := makeHandlerFunc(endpoint.Response, endpoint.Status, endpoint.Payload, endpointCache, method)
-		mux.HandleFunc(endpoint.URL, handlerFunc).Methods(method)
+// reset clears the runtime state of the endpoint.
+func (h *endpointHandler) reset() {
+	if h.cache != nil {
+		h.cache.Clear()
 	}
+	if h.sequence != nil {
+		h.sequence.reset()
+	}
+	// The configured variant is known to exist
+	_ = h.setVariant(h.endpoint.Variant)
+}
 
-	return mux
+// validatesPayload reports whether the request body is checked against the payload schema.
+func (h *endpointHandler) validatesPayload() bool {
+	return (h.endpoint.Payload != nil || h.payloadSchema != nil) && (h.method == http.MethodPost || h.method == http.MethodPut || h.method == http.MethodPatch || h.method == http.MethodDelete)
 }
 
-func makeHandlerFunc(response any, status int, payload any, cache *cache.Cache, method string) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
+// needsBody reports whether the request body has to be read at all.
+func (h *endpointHandler) needsBody() bool {
+	if h.validatesPayload() {
+		return true
+	}
+	for _, m := range h.cases {
+		if m.needsBody() {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *endpointHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	markEndpoint(w, h)
+	w.Header().Set("Content-Type", "application/json")
+
+	if reply, ok := h.injectedFault(); ok {
+		h.respond(w, r, reply, faultReply)
+		return
+	}
+	if !h.validateParams(w, r) {
+		return
+	}
+	if h.endpoint.Socket != nil && websocket.IsWebSocketUpgrade(r) {
+		h.serveSocket(w, r)
+		return
+	}
 
-		if status == 0 {
-			status = http.StatusOK
+	var body any
+	var bodyBytes []byte
+	var files map[string][]uploadedFile
+	form := isForm(r)
+	if h.needsBody() || form || len(h.endpoint.Files) > 0 {
+		var content io.Reader = r.Body
+		streamed := isMultipart(r) && h.proxy == nil
+		if limit := maxFormSize(h.endpoint.Files); isMultipart(r) && limit > 0 {
+			r.Body = http.MaxBytesReader(w, r.Body, limit)
+			content = r.Body
+		}
+		// Multipart files are hashed as they arrive instead of being held in
+		// memory, unless the body is passed through to the upstream
+		if !streamed {
+			var err error
+			if bodyBytes, err = io.ReadAll(r.Body); err != nil {
+				h.failBody(w, r, "Error reading request body", err)
+				return
+			}
+			content = bytes.NewReader(bodyBytes)
 		}
 
-		// Validate payload when schema is provided and method commonly carries a body
-		if payload != nil && (method == http.MethodPost || method == http.MethodPut || method == http.MethodPatch || method == http.MethodDelete) {
-			bodyBytes, err := io.ReadAll(r.Body)
+		if form {
+			fields, uploaded, err := parseForm(r.Header.Get("Content-Type"), content, h.endpoint.Files)
 			if err != nil {
-				http.Error(w, fmt.Sprintf("Error reading request body: %v", err), http.StatusBadRequest)
+				h.failBody(w, r, "Invalid form body", err)
 				return
 			}
+			body = fieldValues(fields, h.payloadSchema)
+			files = uploaded
+		}
+		if violations := h.checkFiles(files); len(violations) > 0 {
+			h.writeProblem(w, r, problem{Status: http.StatusUnprocessableEntity, Detail: "Files do not match limits", Violations: violations})
+			return
+		}
+
+		// Validate payload when schema is provided and method commonly carries a body
+		if h.validatesPayload() {
 			// Empty body is invalid when a payload schema is specified
-			if len(bodyBytes) == 0 {
-				http.Error(w, "Empty body", http.StatusBadRequest)
+			if !form && len(bodyBytes) == 0 {
+				h.fail(w, r, http.StatusBadRequest, "Empty body")
 				return
 			}
-			var body any
-			if err := json.Unmarshal(bodyBytes, &body); err != nil {
-				http.Error(w, "Invalid JSON body", http.StatusBadRequest)
+			if !form && json.Unmarshal(bodyBytes, &body) != nil {
+				h.fail(w, r, http.StatusBadRequest, "Invalid JSON body")
 				return
 			}
-			if !validatePayloadStructure(payload, body) {
-				http.Error(w, "Payload does not match schema", http.StatusBadRequest)
+			if h.payloadSchema != nil {
+				if violations := h.payloadSchema.Validate(body); len(violations) > 0 {
+					h.writeProblem(w, r, problem{Status: http.StatusUnprocessableEntity, Detail: "Payload does not match schema", Violations: violations})
+					return
+				}
+			} else if !validatePayloadStructure(h.endpoint.Payload, body) {
+				h.fail(w, r, http.StatusBadRequest, "Payload does not match schema")
 				return
 			}
+		} else if !form {
+			// Cases only match on JSON and form bodies, anything else counts as no body
+			_ = json.Unmarshal(bodyBytes, &body)
 		}
+	}
+	r = withFiles(r, files)
 
-		// Only use cache for GET requests and if cache is configured
-		var cacheKey string
-		if r.Method == http.MethodGet && cache != nil {
-			cacheKey = r.Method + ":" + r.URL.Path + r.URL.RawQuery
-			cacheValue, cacheHit := cache.Get(cacheKey)
-			if cacheHit {
-				w.WriteHeader(status)
-				if status != http.StatusNoContent {
-					w.Write([]byte(cacheValue.(string)))
-				}
-				return
+	reply, replyKey, served := h.selectReply(r, body)
+	if replyKey == defaultReply && h.proxy != nil {
+		h.passthrough(w, r, bodyBytes)
+		return
+	}
+	if h.respond(w, r, reply, replyKey) && served != nil {
+		served()
+	}
+}
+
+// failBody answers a request whose body could not be read or parsed, with
+// 413 when it exceeds the size limit.
+func (h *endpointHandler) failBody(w http.ResponseWriter, r *http.Request, detail string, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		h.fail(w, r, http.StatusRequestEntityTooLarge, fmt.Sprintf("Request body is larger than %d bytes", tooLarge.Limit))
+		return
+	}
+	h.fail(w, r, http.StatusBadRequest, fmt.Sprintf("%s: %v", detail, err))
+}
+
+// selectReply picks the reply for a request: a variant chosen by the request,
+// then the runtime default variant, then the reply for the current scenario
+// state, then the first matching case and finally the next reply of the
+// sequence or the endpoint's own reply.
+// replyKey identifies the choice in the cache. served, if not nil, is called
+// once the reply is served and moves the scenario to the reply's next state
+// or the sequence to its next reply.
+func (h *endpointHandler) selectReply(r *http.Request, body any) (reply config.Reply, replyKey string, served func()) {
+	requested := requestVariant(r)
+	if requested != defaultVariant {
+		for _, name := range []string{requested, h.currentVariant()} {
+			if variant, ok := h.endpoint.Variants[name]; ok {
+				return variant, "variant:" + name, nil
 			}
 		}
+	}
 
-		var data interface{}
-		switch v := response.(type) {
-		case []interface{}:
-			// Treat top-level array as a list: use the first element as template
-			var template any
-			if len(v) > 0 {
-				template = v[0]
-			} else {
-				template = map[string]any{}
-			}
-			page, perPage := getPaginationParams(r)
-			data = generateDataList(template, page, perPage)
-		default:
-			// Treat maps and primitives as a single object response
-			data = generateData(response)
+	if name := h.endpoint.Scenario; name != "" {
+		key := h.scenarios.key(name, r)
+		if state, current, ok := h.scenarios.lookup(name, key, h.endpoint.States); ok {
+			return state.Reply, "state:" + current, func() { h.scenarios.move(name, key, current, state.Next) }
 		}
+	}
 
-		jsonData, err := JSONMarshal(data)
-		if err != nil {
-			http.Error(w, fmt.Sprintf("Error generating JSON: %v", err), http.StatusInternalServerError)
-			return
+	for i, m := range h.cases {
+		if m.matches(r, body) {
+			return h.endpoint.Cases[i].Reply, "case:" + strconv.Itoa(i), nil
 		}
+	}
 
-		// Write status first
-		w.WriteHeader(status)
-		if status == http.StatusNoContent {
-			return
+	if h.sequence != nil {
+		step, i := h.sequence.step()
+		return step, "sequence:" + strconv.Itoa(i), func() { h.sequence.advance(i) }
+	}
+
+	return h.endpoint.Reply(), defaultReply, nil
+}
+
+// respond writes reply and reports whether it was served rather than failed
+// or abandoned. replyKey identifies the reply in the endpoint cache.
+func (h *endpointHandler) respond(w http.ResponseWriter, r *http.Request, reply config.Reply, replyKey string) bool {
+	// Nothing is written to a client that gave up while waiting
+	if !sleep(r.Context(), reply.Delay) {
+		return false
+	}
+
+	status := reply.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	var format string
+	switch {
+	case hasBody(reply):
+		// Detected from the body unless a header sets it
+		w.Header().Del("Content-Type")
+	case h.streams(reply, status):
+		w.Header().Set("Content-Type", "text/event-stream")
+	default:
+		_, list := reply.Response.([]interface{})
+		var ok bool
+		if format, ok = negotiate(h.endpoint, r.Header.Get("Accept"), list); !ok {
+			h.fail(w, r, http.StatusNotAcceptable, "None of the accepted media types can be served")
+			return false
 		}
-		// Cache only GET responses and if cache is configured
-		if cacheKey != "" && cache != nil {
-			cache.Set(cacheKey, string(jsonData))
+		w.Header().Set("Content-Type", encoders[format].contentType)
+		if h.endpoint.Format == "" {
+			w.Header().Add("Vary", "Accept")
 		}
-		w.Write(jsonData)
 	}
+	for name, value := range reply.Headers {
+		w.Header().Set(name, value)
+	}
+
+	// A seed requested by the client makes this response reproducible, so it
+	// bypasses the cache and does not advance the endpoint's seeds
+	seed, seedOverride, err := requestSeed(r)
+	if err != nil {
+		h.fail(w, r, http.StatusBadRequest, fmt.Sprintf("Invalid seed: %v", err))
+		return false
+	}
+
+	// Only use cache for generated GET responses and if cache is configured.
+	// A hit is answered before a seed is drawn, so it leaves the seeds alone.
+	var cacheKey string
+	if r.Method == http.MethodGet && h.cache != nil && !seedOverride && !hasBody(reply) && reply.Asset == nil && !h.streams(reply, status) {
+		cacheKey = replyKey + ":" + format + ":" + r.Method + ":" + r.URL.Path + r.URL.RawQuery
+		cacheValue, cacheHit := h.cache.Get(cacheKey)
+		if cacheHit {
+			w.WriteHeader(status)
+			if status != http.StatusNoContent {
+				w.Write(cacheValue.(cachedReply).body)
+			}
+			return true
+		}
+	}
+
+	if !seedOverride {
+		// Fault replies draw from the fault rolls, so they don't shift the data
+		purpose := seedData
+		if replyKey == faultReply {
+			purpose = seedFault
+		}
+		seed = h.seed(purpose)
+	}
+	gen := h.gen.withFaker(newFaker(seed))
+	gen.files = requestFiles(r)
+	if h.streams(reply, status) {
+		return h.serveStream(w, r, reply, gen)
+	}
+	if reply.Asset != nil {
+		return h.serveAsset(w, r, reply, status, gen)
+	}
+	if hasBody(reply) {
+		return h.serveBody(w, r, reply, status, gen)
+	}
+
+	var data interface{}
+	switch v := reply.Response.(type) {
+	case []interface{}:
+		// Treat top-level array as a list: use the first element as template
+		var template any
+		if len(v) > 0 {
+			template = v[0]
+		} else {
+			template = map[string]any{}
+		}
+		page, perPage := getPaginationParams(r)
+		data = gen.generateDataList(template, page, perPage)
+	default:
+		// Treat maps and primitives as a single object response
+		data = gen.generateData(reply.Response)
+	}
+
+	encoded, err := encoders[format].encode(data, h.endpoint)
+	if err != nil {
+		h.fail(w, r, http.StatusInternalServerError, fmt.Sprintf("Error generating %s: %v", strings.ToUpper(format), err))
+		return false
+	}
+
+	// Write status first
+	w.WriteHeader(status)
+	if status == http.StatusNoContent {
+		return true
+	}
+	// Cache only GET responses and if cache is configured
+	if cacheKey != "" {
+		h.cache.Set(cacheKey, cachedReply{format: format, body: encoded})
+	}
+	w.Write(encoded)
+	return true
+}
+
+// cachedReply is an encoded response kept in an endpoint cache.
+type cachedReply struct {
+	format string
+	body   []byte
 }
 
 // validatePayloadStructure ensures that the given body matches the shape of the schema.
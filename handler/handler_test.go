@@ -10,6 +10,7 @@ import (
 	"testing"
 
 	"github.com/paqstd-team/fake-cli/config"
+	"github.com/paqstd-team/fake-cli/jsonschema"
 )
 
 func TestHandler_HTTPMethods(t *testing.T) {
@@ -279,6 +280,86 @@ func TestHandler_PayloadValidation(t *testing.T) {
 	}
 }
 
+func TestHandler_PayloadSchemaValidation(t *testing.T) {
+	payload := map[string]any{"name": "", "age": "int", "email": "email"}
+	payloadSchema := &jsonschema.Schema{
+		Type:     jsonschema.Types{"object"},
+		Required: []string{"role"},
+		Properties: map[string]*jsonschema.Schema{
+			"role": {Enum: []any{"admin", "user"}},
+		},
+	}
+
+	tests := []struct {
+		name           string
+		validation     string
+		endpoint       config.Endpoint
+		payload        string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "inferred_valid",
+			validation:     config.ValidationSchema,
+			endpoint:       config.Endpoint{Payload: payload},
+			payload:        `{"name": 1, "age": 30, "email": "ada@example.com"}`,
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "inferred_invalid",
+			validation:     config.ValidationSchema,
+			endpoint:       config.Endpoint{Payload: payload},
+			payload:        `{"name": "Ada", "age": "abc", "email": "nope"}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"type":"about:blank","title":"Unprocessable Entity","status":422,"detail":"Payload does not match schema","instance":"/api/users","violations":[{"pointer":"/age","message":"must be of type integer, got string"},{"pointer":"/email","message":"must be a valid email"}]}`,
+		},
+		{
+			name:           "structure_ignores_types",
+			endpoint:       config.Endpoint{Payload: payload},
+			payload:        `{"name": "Ada", "age": "abc", "email": "nope"}`,
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "explicit_schema",
+			endpoint:       config.Endpoint{Payload: payload, PayloadSchema: payloadSchema},
+			payload:        `{"role": "root"}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"type":"about:blank","title":"Unprocessable Entity","status":422,"detail":"Payload does not match schema","instance":"/api/users","violations":[{"pointer":"/role","message":"must be one of \"admin\", \"user\""}]}`,
+		},
+		{
+			name:           "explicit_schema_without_payload",
+			endpoint:       config.Endpoint{PayloadSchema: payloadSchema},
+			payload:        `{}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"type":"about:blank","title":"Unprocessable Entity","status":422,"detail":"Payload does not match schema","instance":"/api/users","violations":[{"pointer":"/role","message":"is required"}]}`,
+		},
+		{
+			name:           "explicit_schema_invalid_json",
+			endpoint:       config.Endpoint{PayloadSchema: payloadSchema},
+			payload:        `{"role"`,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			endpoint := tt.endpoint
+			endpoint.URL = "/api/users"
+			endpoint.Type = http.MethodPost
+			endpoint.Status = http.StatusCreated
+			handler := MakeHandler(config.Config{Validation: tt.validation, Endpoints: []config.Endpoint{endpoint}})
+
+			w := adminRequest(handler, http.MethodPost, "/api/users", tt.payload)
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedBody != "" && strings.TrimSpace(w.Body.String()) != tt.expectedBody {
+				t.Errorf("Expected body %s, got %s", tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
 func TestHandler_CacheBehavior(t *testing.T) {
 	cacheSize := 2
 	cfg := config.Config{
@@ -331,6 +412,20 @@ func TestHandler_JSONMarshalError(t *testing.T) {
 	}
 }
 
+func TestHandler_ResponseHeaders(t *testing.T) {
+	handler := MakeHandler(config.Config{Endpoints: []config.Endpoint{
+		{URL: "/api/items", Headers: map[string]string{"X-Total-Count": "42", "Cache-Control": "no-store"}, Response: map[string]any{"id": "uuid"}},
+	}})
+
+	w := serve(handler, "/api/items", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if w.Header().Get("X-Total-Count") != "42" || w.Header().Get("Cache-Control") != "no-store" {
+		t.Errorf("Expected the endpoint's headers, got %v", w.Header())
+	}
+}
+
 func TestHandler_ReadBodyError(t *testing.T) {
 	cfg := config.Config{
 		Endpoints: []config.Endpoint{
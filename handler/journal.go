@@ -0,0 +1,187 @@
+package handler
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/paqstd-team/fake-cli/config"
+	"github.com/paqstd-team/fake-cli/journal"
+)
+
+// newJournal creates the request journal. A file that cannot be opened is
+// reported and the journal is kept in memory only.
+func newJournal(cfg config.Journal) *journal.Journal {
+	size := cfg.Size
+	if size == 0 {
+		size = config.DefaultJournalSize
+	}
+	if cfg.File == "" {
+		return journal.New(size, nil)
+	}
+
+	file, err := os.OpenFile(cfg.File, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		log.Printf("journal: %v, requests are only kept in memory", err)
+		return journal.New(size, nil)
+	}
+	return journal.New(size, file)
+}
+
+// recorder captures the status of a response and the endpoint that wrote it.
+type recorder struct {
+	http.ResponseWriter
+	status   int
+	endpoint string
+}
+
+func (r *recorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *recorder) Write(data []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.ResponseWriter.Write(data)
+}
+
+// Hijack lets WebSocket endpoints take over the connection, which the
+// journal records as switching protocols.
+func (r *recorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(r.ResponseWriter).Hijack()
+	if err == nil {
+		r.status = http.StatusSwitchingProtocols
+	}
+	return conn, rw, err
+}
+
+// Unwrap gives http.ResponseController access to the underlying writer.
+func (r *recorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// markEndpoint tells the journal which endpoint serves the request.
+func markEndpoint(w http.ResponseWriter, h *endpointHandler) {
+	if rec, ok := w.(*recorder); ok {
+		rec.endpoint = h.endpoint.URL
+	}
+}
+
+// failedReader returns err once the body read before it is used up.
+type failedReader struct {
+	err error
+}
+
+func (r failedReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
+
+// record serves the request with next and adds it to the journal.
+func (s *server) record(w http.ResponseWriter, r *http.Request, next http.Handler) {
+	start := time.Now()
+
+	// The start of the body is read up front so that it is recorded whether
+	// or not the endpoint reads it, and the endpoint gets it followed by the
+	// rest of the body. A read error is passed on to the endpoint. Multipart
+	// bodies carry files, which are left for the endpoint to stream rather
+	// than kept in memory and in the journal.
+	var body []byte
+	var truncated bool
+	if !isMultipart(r) {
+		limit := s.config.Journal.BodySize
+		if limit == 0 {
+			limit = config.DefaultJournalBodySize
+		}
+		// One byte more tells whether there is more than limit
+		start, err := io.ReadAll(io.LimitReader(r.Body, int64(limit)+1))
+		body, truncated = start[:min(len(start), limit)], len(start) > limit
+		var rest io.Reader = r.Body
+		if err != nil {
+			rest = failedReader{err}
+		}
+		r.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(start), rest), r.Body}
+	}
+
+	rec := &recorder{ResponseWriter: w}
+	next.ServeHTTP(rec, r)
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+
+	entry := journal.Entry{
+		Time:      start,
+		Method:    r.Method,
+		Path:      r.URL.Path,
+		Query:     r.URL.Query(),
+		Headers:   r.Header.Clone(),
+		Body:      string(body),
+		Truncated: truncated,
+		Endpoint:  rec.endpoint,
+		Status:    rec.status,
+		LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err := s.journal.Record(entry); err != nil {
+		log.Printf("journal: %v", err)
+	}
+}
+
+// listRequests returns the journal entries that match the query parameters
+// method, path, endpoint, status and limit.
+func (s *server) listRequests(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := journal.Filter{
+		Method:   query.Get("method"),
+		Path:     query.Get("path"),
+		Endpoint: query.Get("endpoint"),
+	}
+	for name, field := range map[string]*int{"status": &filter.Status, "limit": &filter.Limit} {
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+		number, err := strconv.Atoi(value)
+		if err != nil {
+			s.fail(w, r, http.StatusBadRequest, "Invalid "+name+" parameter")
+			return
+		}
+		*field = number
+	}
+
+	writeJSON(w, http.StatusOK, s.journal.Entries(filter))
+}
+
+// verifyRequests checks the journal against the matcher in the request body.
+func (s *server) verifyRequests(w http.ResponseWriter, r *http.Request) {
+	var matcher journal.Matcher
+	if err := json.NewDecoder(r.Body).Decode(&matcher); err != nil {
+		s.fail(w, r, http.StatusBadRequest, "Invalid JSON body")
+		return
+	}
+
+	result, err := s.journal.Verify(matcher)
+	if err != nil {
+		s.fail(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// clearRequests empties the journal.
+func (s *server) clearRequests(w http.ResponseWriter, r *http.Request) {
+	s.journal.Clear()
+	w.WriteHeader(http.StatusNoContent)
+}
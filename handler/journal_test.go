@@ -0,0 +1,308 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/paqstd-team/fake-cli/config"
+	"github.com/paqstd-team/fake-cli/journal"
+)
+
+func journalTestConfig() config.Config {
+	return config.Config{Endpoints: []config.Endpoint{
+		{URL: "/orders", Type: http.MethodPost, Status: http.StatusCreated, Response: map[string]any{"id": "uuid"}},
+		{URL: "/orders/{id}", Response: map[string]any{"id": "uuid"}},
+	}}
+}
+
+func listRequests(t *testing.T, handler http.Handler, query string) []journal.Entry {
+	t.Helper()
+	var entries []journal.Entry
+	w := serve(handler, "/__admin/requests"+query, nil)
+	if err := json.Unmarshal(w.Body.Bytes(), &entries); err != nil {
+		t.Fatalf("Failed to unmarshal response %s: %v", w.Body.String(), err)
+	}
+	return entries
+}
+
+func TestJournal_RecordsRequests(t *testing.T) {
+	handler := MakeHandler(journalTestConfig())
+
+	req := httptest.NewRequest(http.MethodPost, "/orders?source=test", strings.NewReader(`{"total": 10}`))
+	req.Header.Set("X-Client", "checkout")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	serve(handler, "/orders/42", nil)
+	serve(handler, "/missing", nil)
+	// Admin requests are not recorded
+	serve(handler, "/__admin/endpoints", nil)
+
+	entries := listRequests(t, handler, "")
+	if len(entries) != 3 {
+		t.Fatalf("Expected 3 entries, got %d", len(entries))
+	}
+
+	post := entries[0]
+	if post.Method != http.MethodPost || post.Path != "/orders" || post.Endpoint != "/orders" || post.Status != http.StatusCreated {
+		t.Errorf("Unexpected entry %+v", post)
+	}
+	if post.Body != `{"total": 10}` || post.Query["source"][0] != "test" || post.Headers["X-Client"][0] != "checkout" {
+		t.Errorf("Expected body, query and headers to be recorded, got %+v", post)
+	}
+	if post.Time.IsZero() || post.LatencyMS < 0 {
+		t.Errorf("Expected time and latency, got %+v", post)
+	}
+
+	if entries[1].Endpoint != "/orders/{id}" || entries[1].Status != http.StatusOK {
+		t.Errorf("Expected endpoint template, got %+v", entries[1])
+	}
+	if entries[2].Endpoint != "" || entries[2].Status != http.StatusNotFound {
+		t.Errorf("Expected unmatched request, got %+v", entries[2])
+	}
+}
+
+func TestJournal_AdminPrefixBoundary(t *testing.T) {
+	handler := MakeHandler(config.Config{Endpoints: []config.Endpoint{{URL: "/__administrator", Response: map[string]any{"id": "uuid"}}}})
+	if w := serve(handler, "/__administrator", nil); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	serve(handler, "/__admin", nil)
+
+	entries := listRequests(t, handler, "")
+	if len(entries) != 1 || entries[0].Endpoint != "/__administrator" {
+		t.Errorf("Expected only the endpoint next to the admin prefix to be recorded, got %+v", entries)
+	}
+}
+
+func TestJournal_Query(t *testing.T) {
+	handler := MakeHandler(journalTestConfig())
+	adminRequest(handler, http.MethodPost, "/orders", `{}`)
+	serve(handler, "/orders/1", nil)
+	serve(handler, "/orders/2", nil)
+
+	tests := []struct {
+		name           string
+		query          string
+		expected       int
+		expectedStatus int
+	}{
+		{name: "by_method", query: "?method=POST", expected: 1},
+		{name: "by_path", query: "?path=/orders/2", expected: 1},
+		{name: "by_endpoint", query: "?endpoint=/orders/{id}", expected: 2},
+		{name: "by_status", query: "?status=201", expected: 1},
+		{name: "limit", query: "?limit=1", expected: 1},
+		{name: "invalid_status", query: "?status=abc", expectedStatus: http.StatusBadRequest},
+		{name: "invalid_limit", query: "?limit=abc", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.expectedStatus != 0 {
+				if w := serve(handler, "/__admin/requests"+tt.query, nil); w.Code != tt.expectedStatus {
+					t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+				}
+				return
+			}
+			if entries := listRequests(t, handler, tt.query); len(entries) != tt.expected {
+				t.Errorf("Expected %d entries, got %d", tt.expected, len(entries))
+			}
+		})
+	}
+}
+
+func TestJournal_Clear(t *testing.T) {
+	handler := MakeHandler(journalTestConfig())
+	serve(handler, "/orders/1", nil)
+
+	if w := adminRequest(handler, http.MethodDelete, "/__admin/requests", ""); w.Code != http.StatusNoContent {
+		t.Fatalf("Expected status 204, got %d", w.Code)
+	}
+	if entries := listRequests(t, handler, ""); len(entries) != 0 {
+		t.Errorf("Expected empty journal, got %d entries", len(entries))
+	}
+}
+
+func TestJournal_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "requests.jsonl")
+	cfg := journalTestConfig()
+	cfg.Journal = config.Journal{Size: 1, File: path}
+	handler := MakeHandler(cfg)
+
+	serve(handler, "/orders/1", nil)
+	serve(handler, "/orders/2", nil)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read journal file: %v", err)
+	}
+	if lines := strings.Split(strings.TrimSpace(string(data)), "\n"); len(lines) != 2 {
+		t.Errorf("Expected 2 lines in file, got %d", len(lines))
+	}
+	// Memory only keeps the configured number of entries
+	if entries := listRequests(t, handler, ""); len(entries) != 1 || entries[0].Path != "/orders/2" {
+		t.Errorf("Expected only the latest entry in memory, got %+v", entries)
+	}
+}
+
+func TestJournal_Close(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "requests.jsonl")
+	cfg := journalTestConfig()
+	cfg.Journal.File = path
+	s := MakeHandler(cfg).(*server)
+
+	serve(s, "/orders/1", nil)
+	if err := s.Close(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	serve(s, "/orders/2", nil)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read journal file: %v", err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines != 1 {
+		t.Errorf("Expected 1 line in file, got %d", lines)
+	}
+	if entries := listRequests(t, s, ""); len(entries) != 2 {
+		t.Errorf("Expected 2 entries in memory, got %d", len(entries))
+	}
+
+	cfg.Journal.Disabled = true
+	if err := MakeHandler(cfg).(*server).Close(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestJournal_FileErrors(t *testing.T) {
+	// A file that cannot be opened keeps the journal in memory
+	cfg := journalTestConfig()
+	cfg.Journal = config.Journal{File: filepath.Join(t.TempDir(), "missing", "requests.jsonl")}
+	handler := MakeHandler(cfg)
+	serve(handler, "/orders/1", nil)
+	if entries := listRequests(t, handler, ""); len(entries) != 1 {
+		t.Errorf("Expected entry in memory, got %d", len(entries))
+	}
+
+	// A failing write is logged and the entry kept
+	s := MakeHandler(journalTestConfig()).(*server)
+	s.journal = journal.New(5, failingWriter{})
+	serve(s, "/orders/1", nil)
+	if entries := s.journal.Entries(journal.Filter{}); len(entries) != 1 {
+		t.Errorf("Expected entry in memory, got %d", len(entries))
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, os.ErrClosed
+}
+
+func TestJournal_Disabled(t *testing.T) {
+	cfg := journalTestConfig()
+	cfg.Journal.Disabled = true
+	handler := MakeHandler(cfg)
+
+	if w := serve(handler, "/orders/1", nil); w.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", w.Code)
+	}
+	if w := serve(handler, "/__admin/requests", nil); w.Code != http.StatusNotFound {
+		t.Errorf("Expected journal API to be off, got %d", w.Code)
+	}
+}
+
+func TestJournal_BodyReadError(t *testing.T) {
+	handler := MakeHandler(config.Config{Endpoints: []config.Endpoint{
+		{URL: "/orders", Type: http.MethodPost, Payload: map[string]any{"total": "number"}},
+	}})
+
+	req := httptest.NewRequest(http.MethodPost, "/orders", &errorReader{})
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected read error to reach the endpoint, got %d", w.Code)
+	}
+}
+
+func TestJournal_TruncatedBody(t *testing.T) {
+	handler := MakeHandler(config.Config{
+		Journal: config.Journal{BodySize: 8},
+		Endpoints: []config.Endpoint{
+			{URL: "/orders", Type: http.MethodPost, Status: http.StatusCreated, Payload: map[string]any{"total": "number", "note": "word"}},
+		},
+	})
+
+	// The endpoint still gets the whole body
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(`{"total": 10, "note": "leave at the door"}`)))
+	if w.Code != http.StatusCreated {
+		t.Errorf("Expected status 201, got %d %s", w.Code, w.Body.String())
+	}
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(`{"a": 1}`)))
+
+	entries := listRequests(t, handler, "")
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(entries))
+	}
+	if entries[0].Body != `{"total"` || !entries[0].Truncated {
+		t.Errorf("Expected the first 8 bytes marked truncated, got %q %v", entries[0].Body, entries[0].Truncated)
+	}
+	if entries[1].Body != `{"a": 1}` || entries[1].Truncated {
+		t.Errorf("Expected a body of exactly 8 bytes to be kept whole, got %q %v", entries[1].Body, entries[1].Truncated)
+	}
+}
+
+func TestJournal_Recorder(t *testing.T) {
+	w := httptest.NewRecorder()
+	rec := &recorder{ResponseWriter: w}
+	if rec.Unwrap() != w {
+		t.Error("Expected Unwrap to return the underlying writer")
+	}
+
+	// Writing without a status sends 200
+	rec.Write([]byte("ok"))
+	if rec.status != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", rec.status)
+	}
+}
+
+func TestJournal_Verify(t *testing.T) {
+	handler := MakeHandler(journalTestConfig())
+	adminRequest(handler, http.MethodPost, "/orders", `{"total": 10}`)
+
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+		expected       bool
+	}{
+		{name: "matched", body: `{"method": "POST", "path": "/orders", "body": {"total": 10}, "count": {"max": 1}}`, expectedStatus: http.StatusOK, expected: true},
+		{name: "not_matched", body: `{"method": "POST", "path": "/orders", "body": {"total": 11}}`, expectedStatus: http.StatusOK},
+		{name: "invalid_json", body: `{`, expectedStatus: http.StatusBadRequest},
+		{name: "invalid_matcher", body: `{"path_regex": "("}`, expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := adminRequest(handler, http.MethodPost, "/__admin/requests/verify", tt.body)
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+			var result journal.Result
+			if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+			if result.Matched != tt.expected {
+				t.Errorf("Expected matched %v, got %+v", tt.expected, result)
+			}
+		})
+	}
+}
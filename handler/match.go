@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+
+	"github.com/gorilla/mux"
+	"github.com/paqstd-team/fake-cli/config"
+	"github.com/paqstd-team/fake-cli/jsonpath"
+)
+
+// valueMatcher checks a single request value. exists is false when the value is
+// missing from the request.
+type valueMatcher func(value any, exists bool) bool
+
+type bodyMatcher struct {
+	path  jsonpath.Path
+	match valueMatcher
+}
+
+// requestMatcher is the compiled form of config.Match.
+type requestMatcher struct {
+	query   map[string]valueMatcher
+	headers map[string]valueMatcher
+	path    map[string]valueMatcher
+	body    []bodyMatcher
+}
+
+// compileMatch prepares the conditions of a case. The config is validated when
+// it is loaded, so conditions that fail to compile simply never match.
+func compileMatch(match config.Match) *requestMatcher {
+	m := &requestMatcher{
+		query:   compileConditions(match.Query),
+		headers: compileConditions(match.Headers),
+		path:    compileConditions(match.Path),
+	}
+	for expr, condition := range match.Body {
+		path, err := jsonpath.Parse(expr)
+		if err != nil {
+			m.body = append(m.body, bodyMatcher{match: neverMatch})
+			continue
+		}
+		m.body = append(m.body, bodyMatcher{path: path, match: compileCondition(condition)})
+	}
+	return m
+}
+
+func compileConditions(conditions map[string]config.Condition) map[string]valueMatcher {
+	matchers := make(map[string]valueMatcher, len(conditions))
+	for key, condition := range conditions {
+		matchers[key] = compileCondition(condition)
+	}
+	return matchers
+}
+
+func neverMatch(any, bool) bool {
+	return false
+}
+
+func compileCondition(c config.Condition) valueMatcher {
+	var re *regexp.Regexp
+	if c.Regex != "" {
+		var err error
+		if re, err = regexp.Compile(c.Regex); err != nil {
+			return neverMatch
+		}
+	}
+
+	return func(value any, exists bool) bool {
+		if c.Exists != nil && *c.Exists != exists {
+			return false
+		}
+		if !exists {
+			// Only an explicit {"exists": false} accepts a missing value
+			return c.Exists != nil
+		}
+		if re != nil && !re.MatchString(fmt.Sprint(value)) {
+			return false
+		}
+		if c.Equals != nil && !valuesEqual(c.Equals, value) {
+			return false
+		}
+		return true
+	}
+}
+
+// valuesEqual compares a configured value with a request value. Scalars are also
+// compared by their text so that {"equals": 2} matches the query string "2".
+func valuesEqual(expected, actual any) bool {
+	if reflect.DeepEqual(expected, actual) {
+		return true
+	}
+	return isScalar(expected) && isScalar(actual) && fmt.Sprint(expected) == fmt.Sprint(actual)
+}
+
+func isScalar(value any) bool {
+	switch value.(type) {
+	case map[string]any, []any:
+		return false
+	default:
+		return true
+	}
+}
+
+// needsBody reports whether the matcher inspects the request body.
+func (m *requestMatcher) needsBody() bool {
+	return len(m.body) > 0
+}
+
+// matches reports whether r satisfies every condition. body is the decoded JSON
+// request body, or nil when there is none.
+func (m *requestMatcher) matches(r *http.Request, body any) bool {
+	query := r.URL.Query()
+	for key, match := range m.query {
+		if !match(query.Get(key), query.Has(key)) {
+			return false
+		}
+	}
+	for key, match := range m.headers {
+		_, exists := r.Header[http.CanonicalHeaderKey(key)]
+		if !match(r.Header.Get(key), exists) {
+			return false
+		}
+	}
+	vars := mux.Vars(r)
+	for key, match := range m.path {
+		value, exists := vars[key]
+		if !match(value, exists) {
+			return false
+		}
+	}
+	for _, bm := range m.body {
+		if !bm.matchesAny(body) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesAny checks the condition against every value the path selects.
+func (bm bodyMatcher) matchesAny(body any) bool {
+	if body == nil {
+		return bm.match(nil, false)
+	}
+	values := bm.path.Lookup(body)
+	if len(values) == 0 {
+		return bm.match(nil, false)
+	}
+	for _, value := range values {
+		if bm.match(value, true) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,411 @@
+package handler
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/paqstd-team/fake-cli/config"
+)
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func TestMatch_LoginCases(t *testing.T) {
+	cfg := config.Config{
+		Endpoints: []config.Endpoint{
+			{
+				URL:      "/login",
+				Type:     http.MethodPost,
+				Payload:  map[string]any{"username": "", "password": ""},
+				Status:   401,
+				Response: map[string]any{"error": "invalid credentials"},
+				Cases: []config.Case{
+					{
+						When: config.Match{
+							Body: map[string]config.Condition{
+								"$.username": {Equals: "admin"},
+								"password":   {Equals: "secret"},
+							},
+						},
+						Reply: config.Reply{
+							Status:   200,
+							Headers:  map[string]string{"X-Session": "abc"},
+							Response: map[string]any{"token": "uuid"},
+						},
+					},
+				},
+			},
+		},
+	}
+	handler := MakeHandler(cfg)
+
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+		expectedKey    string
+		expectedHeader string
+	}{
+		{name: "valid_credentials", body: `{"username":"admin","password":"secret"}`, expectedStatus: 200, expectedKey: "token", expectedHeader: "abc"},
+		{name: "wrong_password", body: `{"username":"admin","password":"nope"}`, expectedStatus: 401, expectedKey: "error"},
+		{name: "wrong_user", body: `{"username":"guest","password":"secret"}`, expectedStatus: 401, expectedKey: "error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if got := w.Header().Get("X-Session"); got != tt.expectedHeader {
+				t.Errorf("Expected X-Session %q, got %q", tt.expectedHeader, got)
+			}
+			var response map[string]any
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+			if _, ok := response[tt.expectedKey]; !ok {
+				t.Errorf("Expected key %q in %v", tt.expectedKey, response)
+			}
+		})
+	}
+}
+
+func TestMatch_Conditions(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		when    config.Match
+		method  string
+		target  string
+		header  http.Header
+		body    string
+		matched bool
+	}{
+		{
+			name:    "query_equals",
+			when:    config.Match{Query: map[string]config.Condition{"status": {Equals: "active"}}},
+			target:  "/items?status=active",
+			matched: true,
+		},
+		{
+			name:    "query_equals_number",
+			when:    config.Match{Query: map[string]config.Condition{"page": {Equals: float64(2)}}},
+			target:  "/items?page=2",
+			matched: true,
+		},
+		{
+			name:    "query_mismatch",
+			when:    config.Match{Query: map[string]config.Condition{"status": {Equals: "active"}}},
+			target:  "/items?status=archived",
+			matched: false,
+		},
+		{
+			name:    "query_missing",
+			when:    config.Match{Query: map[string]config.Condition{"status": {Equals: "active"}}},
+			target:  "/items",
+			matched: false,
+		},
+		{
+			name:    "query_exists",
+			when:    config.Match{Query: map[string]config.Condition{"debug": {Exists: boolPtr(true)}}},
+			target:  "/items?debug",
+			matched: true,
+		},
+		{
+			name:    "query_not_exists",
+			when:    config.Match{Query: map[string]config.Condition{"debug": {Exists: boolPtr(false)}}},
+			target:  "/items",
+			matched: true,
+		},
+		{
+			name:    "query_not_exists_but_present",
+			when:    config.Match{Query: map[string]config.Condition{"debug": {Exists: boolPtr(false)}}},
+			target:  "/items?debug=1",
+			matched: false,
+		},
+		{
+			name:    "header_regex",
+			when:    config.Match{Headers: map[string]config.Condition{"authorization": {Regex: "^Bearer .+"}}},
+			target:  "/items",
+			header:  http.Header{"Authorization": {"Bearer token"}},
+			matched: true,
+		},
+		{
+			name:    "header_regex_mismatch",
+			when:    config.Match{Headers: map[string]config.Condition{"Authorization": {Regex: "^Bearer .+"}}},
+			target:  "/items",
+			header:  http.Header{"Authorization": {"Basic abc"}},
+			matched: false,
+		},
+		{
+			name:    "header_missing",
+			when:    config.Match{Headers: map[string]config.Condition{"X-Tenant": {Regex: ".*"}}},
+			target:  "/items",
+			matched: false,
+		},
+		{
+			name:    "path_var",
+			url:     "/items/{id}",
+			when:    config.Match{Path: map[string]config.Condition{"id": {Equals: "42"}}},
+			target:  "/items/42",
+			matched: true,
+		},
+		{
+			name:    "path_var_regex_mismatch",
+			url:     "/items/{id}",
+			when:    config.Match{Path: map[string]config.Condition{"id": {Regex: `^\d+$`}}},
+			target:  "/items/abc",
+			matched: false,
+		},
+		{
+			name:    "path_var_unknown",
+			url:     "/items/{id}",
+			when:    config.Match{Path: map[string]config.Condition{"slug": {Exists: boolPtr(true)}}},
+			target:  "/items/abc",
+			matched: false,
+		},
+		{
+			name:    "body_wildcard_any_item",
+			method:  http.MethodPost,
+			when:    config.Match{Body: map[string]config.Condition{"$.items[*].sku": {Equals: "B-2"}}},
+			target:  "/items",
+			body:    `{"items":[{"sku":"A-1"},{"sku":"B-2"}]}`,
+			matched: true,
+		},
+		{
+			name:    "body_wildcard_no_item",
+			method:  http.MethodPost,
+			when:    config.Match{Body: map[string]config.Condition{"$.items[*].sku": {Equals: "C-3"}}},
+			target:  "/items",
+			body:    `{"items":[{"sku":"A-1"},{"sku":"B-2"}]}`,
+			matched: false,
+		},
+		{
+			name:    "body_number_equals_string",
+			method:  http.MethodPost,
+			when:    config.Match{Body: map[string]config.Condition{"$.age": {Equals: "30"}}},
+			target:  "/items",
+			body:    `{"age":30}`,
+			matched: true,
+		},
+		{
+			name:    "body_object_equals",
+			method:  http.MethodPost,
+			when:    config.Match{Body: map[string]config.Condition{"$.user": {Equals: map[string]any{"id": float64(1)}}}},
+			target:  "/items",
+			body:    `{"user":{"id":1}}`,
+			matched: true,
+		},
+		{
+			name:    "body_object_not_equal",
+			method:  http.MethodPost,
+			when:    config.Match{Body: map[string]config.Condition{"$.user": {Equals: map[string]any{"id": float64(2)}}}},
+			target:  "/items",
+			body:    `{"user":{"id":1}}`,
+			matched: false,
+		},
+		{
+			name:    "body_field_missing",
+			method:  http.MethodPost,
+			when:    config.Match{Body: map[string]config.Condition{"$.coupon": {Exists: boolPtr(false)}}},
+			target:  "/items",
+			body:    `{"user":{"id":1}}`,
+			matched: true,
+		},
+		{
+			name:    "body_empty",
+			method:  http.MethodPost,
+			when:    config.Match{Body: map[string]config.Condition{"$.coupon": {Exists: boolPtr(false)}}},
+			target:  "/items",
+			matched: true,
+		},
+		{
+			name:    "body_not_json",
+			method:  http.MethodPost,
+			when:    config.Match{Body: map[string]config.Condition{"$.coupon": {Exists: boolPtr(true)}}},
+			target:  "/items",
+			body:    `coupon=1`,
+			matched: false,
+		},
+		{
+			name:    "invalid_regex_never_matches",
+			when:    config.Match{Query: map[string]config.Condition{"q": {Regex: "[a-"}}},
+			target:  "/items?q=a",
+			matched: false,
+		},
+		{
+			name:    "invalid_jsonpath_never_matches",
+			method:  http.MethodPost,
+			when:    config.Match{Body: map[string]config.Condition{"$[": {Exists: boolPtr(false)}}},
+			target:  "/items",
+			body:    `{}`,
+			matched: false,
+		},
+		{
+			name: "all_conditions_required",
+			when: config.Match{
+				Query:   map[string]config.Condition{"status": {Equals: "active"}},
+				Headers: map[string]config.Condition{"X-Tenant": {Equals: "acme"}},
+			},
+			target:  "/items?status=active",
+			matched: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			url := tt.url
+			if url == "" {
+				url = "/items"
+			}
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			cfg := config.Config{
+				Endpoints: []config.Endpoint{
+					{
+						URL:      url,
+						Type:     method,
+						Response: map[string]any{"matched": false},
+						Cases: []config.Case{
+							{When: tt.when, Reply: config.Reply{Response: map[string]any{"matched": true}}},
+						},
+					},
+				},
+			}
+			handler := MakeHandler(cfg)
+
+			var body io.Reader
+			if tt.body != "" {
+				body = strings.NewReader(tt.body)
+			}
+			req := httptest.NewRequest(method, tt.target, body)
+			for key, values := range tt.header {
+				req.Header[key] = values
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			var response map[string]any
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to unmarshal response %q: %v", w.Body.String(), err)
+			}
+			if response["matched"] != tt.matched {
+				t.Errorf("Expected matched=%v, got %v", tt.matched, response["matched"])
+			}
+		})
+	}
+}
+
+func TestMatch_FirstCaseWins(t *testing.T) {
+	cfg := config.Config{
+		Endpoints: []config.Endpoint{
+			{
+				URL:      "/items",
+				Response: map[string]any{"case": "default"},
+				Cases: []config.Case{
+					{When: config.Match{Query: map[string]config.Condition{"a": {Exists: boolPtr(true)}}}, Reply: config.Reply{Response: map[string]any{"case": "first"}}},
+					{When: config.Match{Query: map[string]config.Condition{"b": {Exists: boolPtr(true)}}}, Reply: config.Reply{Response: map[string]any{"case": map[string]any{"$literal": "second"}}}},
+				},
+			},
+		},
+	}
+	handler := MakeHandler(cfg)
+
+	tests := map[string]string{
+		"/items?a&b": "first",
+		"/items?b":   "second",
+		"/items":     "default",
+	}
+	for target, expected := range tests {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+
+		var response map[string]any
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		if response["case"] != expected {
+			t.Errorf("%s: expected case %q, got %v", target, expected, response["case"])
+		}
+	}
+}
+
+func TestMatch_CasesAreCachedSeparately(t *testing.T) {
+	cacheSize := 5
+	cfg := config.Config{
+		Endpoints: []config.Endpoint{
+			{
+				URL:      "/items",
+				Response: map[string]any{"id": "uuid"},
+				Cache:    &cacheSize,
+				Cases: []config.Case{
+					{
+						When:  config.Match{Headers: map[string]config.Condition{"X-Empty": {Exists: boolPtr(true)}}},
+						Reply: config.Reply{Status: http.StatusNotFound, Response: map[string]any{"error": "not found"}},
+					},
+				},
+			},
+		},
+	}
+	handler := MakeHandler(cfg)
+
+	serveItems := func(empty bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/items", nil)
+		if empty {
+			req.Header.Set("X-Empty", "1")
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	first := serveItems(false)
+	notFound := serveItems(true)
+	second := serveItems(false)
+	notFoundAgain := serveItems(true)
+
+	if first.Body.String() != second.Body.String() {
+		t.Error("Expected default reply to be cached")
+	}
+	if notFound.Code != http.StatusNotFound || notFoundAgain.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for the case, got %d and %d", notFound.Code, notFoundAgain.Code)
+	}
+	if notFound.Body.String() == first.Body.String() {
+		t.Error("Expected case reply to be cached separately from the default reply")
+	}
+}
+
+func TestMatch_ReadBodyError(t *testing.T) {
+	cfg := config.Config{
+		Endpoints: []config.Endpoint{
+			{
+				URL:      "/items",
+				Type:     http.MethodPost,
+				Response: map[string]any{"ok": true},
+				Cases: []config.Case{
+					{When: config.Match{Body: map[string]config.Condition{"$.id": {Exists: boolPtr(true)}}}},
+				},
+			},
+		},
+	}
+	handler := MakeHandler(cfg)
+
+	req := httptest.NewRequest(http.MethodPost, "/items", &errorReader{})
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"encoding/binary"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+)
+
+// encodeMsgPack encodes normalized data, see normalized, as MessagePack.
+// Map keys are sorted so that equal data gives equal bytes.
+func encodeMsgPack(value any) ([]byte, error) {
+	return appendMsgPack(nil, value)
+}
+
+func appendMsgPack(b []byte, value any) ([]byte, error) {
+	switch v := value.(type) {
+	case nil:
+		return append(b, 0xc0), nil
+	case bool:
+		if v {
+			return append(b, 0xc3), nil
+		}
+		return append(b, 0xc2), nil
+	case int64:
+		return appendMsgPackInt(b, v), nil
+	case float64:
+		b = append(b, 0xcb)
+		return binary.BigEndian.AppendUint64(b, math.Float64bits(v)), nil
+	case string:
+		b = appendMsgPackLength(b, len(v), 0xa0, 31, 0xd9, 0xda, 0xdb)
+		return append(b, v...), nil
+	case []any:
+		b = appendMsgPackLength(b, len(v), 0x90, 15, 0, 0xdc, 0xdd)
+		for _, item := range v {
+			var err error
+			if b, err = appendMsgPack(b, item); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	case map[string]any:
+		b = appendMsgPackLength(b, len(v), 0x80, 15, 0, 0xde, 0xdf)
+		for _, key := range slices.Sorted(maps.Keys(v)) {
+			b, _ = appendMsgPack(b, key)
+			var err error
+			if b, err = appendMsgPack(b, v[key]); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	default:
+		return nil, fmt.Errorf("msgpack: unsupported type %T", value)
+	}
+}
+
+// appendMsgPackInt writes n in the shortest integer form.
+func appendMsgPackInt(b []byte, n int64) []byte {
+	switch {
+	case n >= 0 && n <= math.MaxInt8:
+		return append(b, byte(n))
+	case n < 0 && n >= -32:
+		return append(b, byte(n))
+	case n >= math.MinInt8 && n <= math.MaxInt8:
+		return append(b, 0xd0, byte(n))
+	case n >= math.MinInt16 && n <= math.MaxInt16:
+		return binary.BigEndian.AppendUint16(append(b, 0xd1), uint16(n))
+	case n >= math.MinInt32 && n <= math.MaxInt32:
+		return binary.BigEndian.AppendUint32(append(b, 0xd2), uint32(n))
+	default:
+		return binary.BigEndian.AppendUint64(append(b, 0xd3), uint64(n))
+	}
+}
+
+// appendMsgPackLength writes the header of a string, array or map: a fix form
+// for lengths up to fixMax, then the 8-bit (if the type has one), 16-bit and
+// 32-bit forms.
+func appendMsgPackLength(b []byte, n int, fix byte, fixMax int, code8, code16, code32 byte) []byte {
+	switch {
+	case n <= fixMax:
+		return append(b, fix|byte(n))
+	case code8 != 0 && n <= math.MaxUint8:
+		return append(b, code8, byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, code16), uint16(n))
+	default:
+		return binary.BigEndian.AppendUint32(append(b, code32), uint32(n))
+	}
+}
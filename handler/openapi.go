@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/paqstd-team/fake-cli/config"
+	"github.com/paqstd-team/fake-cli/openapi"
+)
+
+// openAPIPath is where the OpenAPI document of the endpoints is served, unless
+// an endpoint uses it.
+const openAPIPath = "/openapi.json"
+
+// serveOpenAPI describes the current endpoints as an OpenAPI document.
+func (s *server) serveOpenAPI(w http.ResponseWriter, r *http.Request) {
+	var endpoints []config.Endpoint
+	for _, h := range s.handlers() {
+		endpoints = append(endpoints, h.endpoint)
+	}
+	writeJSON(w, http.StatusOK, openapi.Export(endpoints, s.gen.describeField))
+}
+
+// describeField generates a sample value for a template string and reports the
+// data type it names, if any, and whether it is a literal. It follows the rules
+// of generateField.
+func (g *generator) describeField(value string) (sample any, dataType string, literal bool) {
+	gen := g.withFaker(newFaker(0))
+	if g.strict && strings.HasPrefix(value, literalPrefix) {
+		return value[len(literalPrefix):], "", true
+	}
+	if isTemplate(value) {
+		return gen.interpolate(value), "", false
+	}
+	name := value
+	if strings.HasPrefix(value, typePrefix) {
+		name = value[len(typePrefix):]
+	} else if g.strict {
+		return value, "", true
+	}
+	if data, ok := fakeValue(gen.faker, name); ok {
+		return data, name, false
+	}
+	return value, "", true
+}
@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/paqstd-team/fake-cli/config"
+	"github.com/paqstd-team/fake-cli/openapi"
+)
+
+func TestOpenAPI_Document(t *testing.T) {
+	handler := MakeHandler(config.Config{Endpoints: []config.Endpoint{
+		{URL: "/users/{id}", Response: map[string]any{"id": "uuid", "age": "int32"}},
+	}})
+
+	w := adminRequest(handler, http.MethodPost, "/__admin/endpoints", `{"url": "/orders", "type": "POST", "payload": {"email": "email"}}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = serve(handler, "/openapi.json", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	var doc openapi.Document
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("Failed to decode document: %v", err)
+	}
+	schema := doc.Paths["/users/{id}"].Get.Responses["200"].Content["application/json"].Schema
+	if id := schema.Properties["id"]; id.Format != "uuid" {
+		t.Errorf("Expected uuid format, got %+v", id)
+	}
+	if age := schema.Properties["age"]; age.Type.Primary() != "integer" || age.Format != "int32" {
+		t.Errorf("Expected int32 integer, got %+v", age)
+	}
+	if doc.Paths["/orders"] == nil || doc.Paths["/orders"].Post.RequestBody == nil {
+		t.Error("Expected the endpoint added at runtime to be documented")
+	}
+}
+
+func TestOpenAPI_EndpointTakesPrecedence(t *testing.T) {
+	handler := MakeHandler(config.Config{Endpoints: []config.Endpoint{
+		{URL: "/openapi.json", Response: map[string]any{"custom": "yes"}},
+	}})
+
+	if w := serve(handler, "/openapi.json", nil); w.Body.String() != `{"custom":"yes"}` {
+		t.Errorf("Expected the configured endpoint, got %s", w.Body.String())
+	}
+}
+
+func TestOpenAPI_DescribeField(t *testing.T) {
+	tests := []struct {
+		name     string
+		strict   bool
+		value    string
+		dataType string
+		literal  bool
+	}{
+		{name: "literal", strict: true, value: "=uuid", literal: true},
+		{name: "equals_sign", value: "=uuid", literal: true},
+		{name: "inline_template", value: "{{first_name}} here"},
+		{name: "prefixed_type", value: "$uuid", dataType: "uuid"},
+		{name: "prefixed_type_strict", strict: true, value: "$uuid", dataType: "uuid"},
+		{name: "bare_type", value: "email", dataType: "email"},
+		{name: "bare_type_strict", strict: true, value: "email", literal: true},
+		{name: "unknown", value: "pending", literal: true},
+		{name: "unknown_prefixed", value: "$pending", literal: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen := &generator{strict: tt.strict}
+			sample, dataType, literal := gen.describeField(tt.value)
+			if dataType != tt.dataType || literal != tt.literal {
+				t.Errorf("Expected type %q and literal %v, got %q and %v", tt.dataType, tt.literal, dataType, literal)
+			}
+			if sample == nil {
+				t.Error("Expected a sample value")
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/paqstd-team/fake-cli/jsonschema"
+)
+
+// validateParams checks the query parameters and headers of a request against
+// the endpoint's schemas and sends a 400 when they don't match.
+func (h *endpointHandler) validateParams(w http.ResponseWriter, r *http.Request) bool {
+	if schema := h.endpoint.Query; schema != nil {
+		if violations := schema.Validate(fieldValues(r.URL.Query(), schema)); len(violations) > 0 {
+			h.writeProblem(w, r, problem{Status: http.StatusBadRequest, Detail: "Query parameters do not match schema", Violations: violations})
+			return false
+		}
+	}
+	if schema := h.endpoint.HeaderSchema; schema != nil {
+		if violations := schema.Validate(headerValues(r.Header, schema)); len(violations) > 0 {
+			h.writeProblem(w, r, problem{Status: http.StatusBadRequest, Detail: "Headers do not match schema", Violations: violations})
+			return false
+		}
+	}
+	return true
+}
+
+// fieldValues turns query parameters or form fields into an object for the
+// schema, which may be nil, to validate. All of them are included so that
+// additionalProperties can reject unknown ones.
+func fieldValues(values url.Values, schema *jsonschema.Schema) map[string]any {
+	var shape *jsonschema.Shape
+	if schema != nil {
+		shape = schema.Shape()
+	}
+	result := make(map[string]any, len(values))
+	for name, list := range values {
+		var property *jsonschema.Shape
+		if shape != nil {
+			property = shape.Property(name)
+		}
+		result[name] = paramValue(list, property)
+	}
+	return result
+}
+
+// headerValues only picks the headers the schema declares, since clients and
+// proxies add plenty of their own. Header names are case-insensitive.
+func headerValues(header http.Header, schema *jsonschema.Schema) map[string]any {
+	shape := schema.Shape()
+	names := shape.Properties()
+	result := make(map[string]any, len(names))
+	for _, name := range names {
+		if list := header.Values(name); len(list) > 0 {
+			result[name] = paramValue(list, shape.Property(name))
+		}
+	}
+	return result
+}
+
+// paramValue converts the values of a parameter to the type the shape, which
+// may be nil, declares. Values that don't convert stay strings and fail
+// validation. Without a shape, a parameter given several times is a list.
+func paramValue(list []string, shape *jsonschema.Shape) any {
+	if shape == nil && len(list) > 1 {
+		return paramValue(list, &jsonschema.Shape{Type: "array"})
+	}
+	if shape != nil && shape.Type == "array" {
+		items := make([]any, len(list))
+		for i, value := range list {
+			items[i] = scalarValue(value, shape.Items())
+		}
+		return items
+	}
+	return scalarValue(list[0], shape)
+}
+
+func scalarValue(value string, shape *jsonschema.Shape) any {
+	if shape == nil {
+		return value
+	}
+	switch shape.Type {
+	case "integer", "number":
+		if n, err := strconv.ParseFloat(value, 64); err == nil {
+			return n
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return value
+}
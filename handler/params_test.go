@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/paqstd-team/fake-cli/config"
+	"github.com/paqstd-team/fake-cli/jsonschema"
+)
+
+func TestParams_Validation(t *testing.T) {
+	query := &jsonschema.Schema{
+		Type:     jsonschema.Types{"object"},
+		Required: []string{"limit"},
+		Properties: map[string]*jsonschema.Schema{
+			"limit":   {Type: jsonschema.Types{"integer"}, Maximum: floatPtr(100)},
+			"ratio":   {Type: jsonschema.Types{"number"}},
+			"active":  {Type: jsonschema.Types{"boolean"}},
+			"tag":     {Type: jsonschema.Types{"array"}, Items: &jsonschema.Schema{Type: jsonschema.Types{"integer"}}},
+			"untyped": {Type: jsonschema.Types{"array"}},
+		},
+		AdditionalProperties: &jsonschema.Schema{Type: jsonschema.Types{"string"}, Pattern: "^[a-z]{1,3}$"},
+	}
+	headers := &jsonschema.Schema{
+		Required: []string{"X-Api-Version"},
+		Properties: map[string]*jsonschema.Schema{
+			"X-Api-Version": {Enum: []any{"1", "2"}},
+		},
+	}
+
+	// Types reached through references and combinators convert as well
+	var referencedQuery, referencedHeaders jsonschema.Schema
+	json.Unmarshal([]byte(`{
+		"$defs": {"n": {"type": "integer"}, "page": {"properties": {"page": {"$ref": "#/$defs/n"}}}},
+		"$ref": "#/$defs/page",
+		"properties": {"limit": {"$ref": "#/$defs/n"}, "ids": {"type": "array", "items": {"$ref": "#/$defs/n"}}},
+		"allOf": [{"properties": {"active": {"oneOf": [{"type": "boolean"}]}}}]
+	}`), &referencedQuery)
+	json.Unmarshal([]byte(`{
+		"$defs": {"count": {"required": ["X-Count"], "properties": {"X-Count": {"type": "integer"}}}},
+		"allOf": [{"$ref": "#/$defs/count"}]
+	}`), &referencedHeaders)
+
+	tests := []struct {
+		name           string
+		endpoint       config.Endpoint
+		target         string
+		header         http.Header
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "valid_query",
+			endpoint:       config.Endpoint{Query: query},
+			target:         "/items?limit=10&ratio=0.5&active=true&tag=1&tag=2&untyped=a&q=abc",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "missing_query_parameter",
+			endpoint:       config.Endpoint{Query: query},
+			target:         "/items",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"type":"about:blank","title":"Bad Request","status":400,"detail":"Query parameters do not match schema","instance":"/items","violations":[{"pointer":"/limit","message":"is required"}]}`,
+		},
+		{
+			name:           "mistyped_query_parameter",
+			endpoint:       config.Endpoint{Query: query},
+			target:         "/items?limit=ten",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"type":"about:blank","title":"Bad Request","status":400,"detail":"Query parameters do not match schema","instance":"/items","violations":[{"pointer":"/limit","message":"must be of type integer, got string"}]}`,
+		},
+		{
+			name:           "query_parameter_out_of_range",
+			endpoint:       config.Endpoint{Query: query},
+			target:         "/items?limit=500",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "mistyped_boolean_and_item",
+			endpoint:       config.Endpoint{Query: query},
+			target:         "/items?limit=1&active=maybe&tag=x",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "additional_query_parameter",
+			endpoint:       config.Endpoint{Query: query},
+			target:         "/items?limit=1&q=toolong",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "referenced_query",
+			endpoint:       config.Endpoint{Query: &referencedQuery},
+			target:         "/items?limit=5&page=2&ids=1&ids=2&active=true",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "mistyped_referenced_query",
+			endpoint:       config.Endpoint{Query: &referencedQuery},
+			target:         "/items?page=two",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"type":"about:blank","title":"Bad Request","status":400,"detail":"Query parameters do not match schema","instance":"/items","violations":[{"pointer":"/page","message":"must be of type integer, got string"}]}`,
+		},
+		{
+			name:           "referenced_header",
+			endpoint:       config.Endpoint{HeaderSchema: &referencedHeaders},
+			target:         "/items",
+			header:         http.Header{"X-Count": {"3"}},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "missing_referenced_header",
+			endpoint:       config.Endpoint{HeaderSchema: &referencedHeaders},
+			target:         "/items",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "valid_header",
+			endpoint:       config.Endpoint{HeaderSchema: headers},
+			target:         "/items",
+			header:         http.Header{"X-Api-Version": {"2"}},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "missing_header",
+			endpoint:       config.Endpoint{HeaderSchema: headers},
+			target:         "/items",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"type":"about:blank","title":"Bad Request","status":400,"detail":"Headers do not match schema","instance":"/items","violations":[{"pointer":"/X-Api-Version","message":"is required"}]}`,
+		},
+		{
+			name:           "invalid_header",
+			endpoint:       config.Endpoint{HeaderSchema: headers},
+			target:         "/items",
+			header:         http.Header{"X-Api-Version": {"3"}},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			endpoint := tt.endpoint
+			endpoint.URL = "/items"
+			endpoint.Response = map[string]any{"id": "uuid"}
+			handler := MakeHandler(config.Config{Endpoints: []config.Endpoint{endpoint}})
+
+			w := serve(handler, tt.target, tt.header)
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedBody != "" && strings.TrimSpace(w.Body.String()) != tt.expectedBody {
+				t.Errorf("Expected body %s, got %s", tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+)
+
+// patchResponse overrides fields of a JSON upstream response with generated
+// values. Other responses are left alone.
+func (h *endpointHandler) patchResponse(resp *http.Response) error {
+	data, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return err
+	}
+
+	var body any
+	if json.Unmarshal(data, &body) == nil {
+		seed, ok, _ := requestSeed(resp.Request)
+		if !ok {
+			seed = h.seed(seedData)
+		}
+		// Marshaling decoded JSON with generated values cannot fail
+		data, _ = JSONMarshal(h.gen.withFaker(newFaker(seed)).patch(body, h.endpoint.Patch))
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+	resp.ContentLength = int64(len(data))
+	resp.Header.Set("Content-Length", strconv.Itoa(len(data)))
+	return nil
+}
+
+// patch merges the generated patch template into target. Objects are merged key
+// by key, an object patch applies to every item of an array, and anything else
+// is replaced with the generated value.
+func (g *generator) patch(target any, patch any) any {
+	p, ok := patch.(map[string]any)
+	if !ok {
+		return g.generateData(patch)
+	}
+	if value, ok := g.generateDirective(p); ok {
+		return value
+	}
+
+	switch t := target.(type) {
+	case map[string]any:
+		for _, key := range slices.Sorted(maps.Keys(p)) {
+			t[key] = g.patch(t[key], p[key])
+		}
+		return t
+	case []any:
+		for i := range t {
+			t[i] = g.patch(t[i], p)
+		}
+		return t
+	default:
+		return g.generateData(p)
+	}
+}
+
+// passthrough forwards the request to the upstream. body is the request body
+// when it was already read.
+func (h *endpointHandler) passthrough(w http.ResponseWriter, r *http.Request, body []byte) {
+	if body != nil {
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	// The upstream decides the content type
+	w.Header().Del("Content-Type")
+	h.proxy.ServeHTTP(w, r)
+}
@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/paqstd-team/fake-cli/config"
+)
+
+func newPassthroughUpstream(t *testing.T) *httptest.Server {
+	t.Helper()
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/users/1":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"id": 1, "name": "Ada", "email": "ada@real.example", "items": [{"sku": "A", "price": 1}, {"sku": "B", "price": 2}]}`))
+		case "/echo":
+			body, _ := io.ReadAll(r.Body)
+			w.Header().Set("Content-Type", "application/json")
+			w.Write(body)
+		default:
+			w.Header().Set("Content-Type", "text/plain")
+			w.Write([]byte("plain"))
+		}
+	}))
+	t.Cleanup(upstream.Close)
+	return upstream
+}
+
+func TestPassthrough(t *testing.T) {
+	upstream := newPassthroughUpstream(t)
+	handler := MakeHandler(config.Config{
+		Fallback: config.Fallback{Upstream: upstream.URL},
+		Endpoints: []config.Endpoint{
+			{
+				URL:         "/users/{id}",
+				Passthrough: true,
+				Patch: map[string]any{
+					"email": "patched@example.com",
+					"items": map[string]any{"price": "9"},
+					"tags":  []any{"new"},
+				},
+				Variants: map[string]config.Reply{"error": {Status: http.StatusServiceUnavailable}},
+			},
+			{URL: "/echo", Type: http.MethodPost, Passthrough: true, Payload: map[string]any{"total": "number"}},
+			{URL: "/text", Passthrough: true, Patch: map[string]any{"a": "b"}},
+		},
+	})
+
+	w := serve(handler, "/users/1", nil)
+	var body map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to unmarshal response %s: %v", w.Body.String(), err)
+	}
+	if body["name"] != "Ada" || body["email"] != "patched@example.com" {
+		t.Errorf("Expected real name and patched email, got %v", body)
+	}
+	items := body["items"].([]any)
+	for _, item := range items {
+		if item.(map[string]any)["price"] != "9" {
+			t.Errorf("Expected every item to be patched, got %v", item)
+		}
+	}
+	if items[1].(map[string]any)["sku"] != "B" {
+		t.Errorf("Expected other item fields to be kept, got %v", items[1])
+	}
+	if tags := body["tags"].([]any); len(tags) != 1 || tags[0] != "new" {
+		t.Errorf("Expected missing field to be added, got %v", body["tags"])
+	}
+	if values := w.Header().Values("Content-Type"); len(values) != 1 || values[0] != "application/json" {
+		t.Errorf("Expected the upstream content type only, got %v", values)
+	}
+
+	// Variants still take precedence over the upstream
+	if w := serve(handler, "/users/1?_variant=error", nil); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected mocked variant, got %d", w.Code)
+	}
+
+	// The validated body is forwarded as is
+	w = adminRequest(handler, http.MethodPost, "/echo", `{"total": 10}`)
+	if w.Body.String() != `{"total": 10}` {
+		t.Errorf("Expected body to reach the upstream, got %s", w.Body.String())
+	}
+	if w := adminRequest(handler, http.MethodPost, "/echo", `{}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected payload validation before forwarding, got %d", w.Code)
+	}
+
+	// Responses that are not JSON are not patched
+	if w := serve(handler, "/text", nil); w.Body.String() != "plain" {
+		t.Errorf("Expected plain upstream body, got %s", w.Body.String())
+	}
+}
+
+func TestPassthrough_PatchDirective(t *testing.T) {
+	gen := &generator{faker: newFaker(1)}
+	target := map[string]any{"code": "real", "nested": "scalar"}
+
+	patched := gen.patch(target, map[string]any{
+		"code":   map[string]any{"$format": "###"},
+		"nested": map[string]any{"value": "x"},
+	}).(map[string]any)
+
+	if code, ok := patched["code"].(string); !ok || len(code) != 3 {
+		t.Errorf("Expected generated code, got %v", patched["code"])
+	}
+	if nested, ok := patched["nested"].(map[string]any); !ok || nested["value"] != "x" {
+		t.Errorf("Expected scalar to be replaced with object, got %v", patched["nested"])
+	}
+}
+
+func TestPassthrough_PatchReadError(t *testing.T) {
+	h := &endpointHandler{}
+	resp := &http.Response{Body: failingBody{}, Request: httptest.NewRequest(http.MethodGet, "/", nil)}
+	if err := h.patchResponse(resp); err == nil {
+		t.Error("Expected read error")
+	}
+
+	// A requested seed is used for the patch
+	h = &endpointHandler{shared: &shared{gen: &generator{}}, endpoint: config.Endpoint{Patch: map[string]any{"id": "uuid"}}}
+	patchWithSeed := func() string {
+		req := httptest.NewRequest(http.MethodGet, "/?_seed=3", nil)
+		resp := &http.Response{Header: http.Header{}, Body: io.NopCloser(strings.NewReader(`{}`)), Request: req}
+		if err := h.patchResponse(resp); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		data, _ := io.ReadAll(resp.Body)
+		return string(data)
+	}
+	if first, second := patchWithSeed(), patchWithSeed(); first != second {
+		t.Errorf("Expected the same patch for the same seed, got %s and %s", first, second)
+	}
+}
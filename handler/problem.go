@@ -0,0 +1,159 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/paqstd-team/fake-cli/config"
+	"github.com/paqstd-team/fake-cli/jsonschema"
+)
+
+// problem is an RFC 7807 problem details object.
+type problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	// Violations lists the parts of a request body that do not match its schema
+	Violations []jsonschema.Violation `json:"violations,omitempty"`
+}
+
+// fail sends a problem with status and detail.
+func (s *shared) fail(w http.ResponseWriter, r *http.Request, status int, detail string) {
+	s.writeProblem(w, r, problem{Status: status, Detail: detail})
+}
+
+// writeProblem sends p in the configured shape. Type, title and instance
+// default to "about:blank", the status text and the request path.
+func (s *shared) writeProblem(w http.ResponseWriter, r *http.Request, p problem) {
+	if p.Type == "" {
+		p.Type = "about:blank"
+	}
+	if p.Title == "" {
+		p.Title = http.StatusText(p.Status)
+	}
+	if p.Instance == "" {
+		p.Instance = r.URL.Path
+	}
+
+	var body any = p
+	contentType := config.ProblemContentType
+	if s.errors.Body != nil {
+		body = p.render(s.errors.Body)
+		contentType = "application/json"
+	}
+	if s.errors.ContentType != "" {
+		contentType = s.errors.ContentType
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// render fills a custom error body with the members of the problem. A string
+// that is exactly a placeholder takes the member's JSON type.
+func (p problem) render(shape any) any {
+	violations := p.Violations
+	if violations == nil {
+		violations = []jsonschema.Violation{}
+	}
+	members := map[string]any{
+		"{type}":       p.Type,
+		"{title}":      p.Title,
+		"{status}":     p.Status,
+		"{detail}":     p.Detail,
+		"{instance}":   p.Instance,
+		"{violations}": violations,
+	}
+
+	switch t := shape.(type) {
+	case string:
+		if member, ok := members[t]; ok {
+			return member
+		}
+		var described []string
+		for _, v := range violations {
+			described = append(described, v.String())
+		}
+		return strings.NewReplacer(
+			"{type}", p.Type,
+			"{title}", p.Title,
+			"{status}", strconv.Itoa(p.Status),
+			"{detail}", p.Detail,
+			"{instance}", p.Instance,
+			"{violations}", strings.Join(described, "; "),
+		).Replace(t)
+	case map[string]any:
+		rendered := make(map[string]any, len(t))
+		for key, value := range t {
+			rendered[key] = p.render(value)
+		}
+		return rendered
+	case []any:
+		rendered := make([]any, len(t))
+		for i, value := range t {
+			rendered[i] = p.render(value)
+		}
+		return rendered
+	default:
+		return t
+	}
+}
+
+// sendReply sends a configured error reply, with status unless it sets another.
+// It is sent as JSON whatever the request accepts, so that an error is never
+// turned into a 406.
+func (s *server) sendReply(w http.ResponseWriter, r *http.Request, reply config.Reply, status int) {
+	if reply.Status == 0 {
+		reply.Status = status
+	}
+	h := &endpointHandler{shared: s.shared, endpoint: config.Endpoint{Format: config.FormatJSON}}
+	h.respond(w, r, reply, defaultReply)
+}
+
+// notFound serves requests that no endpoint matches.
+func (s *server) notFound(w http.ResponseWriter, r *http.Request) {
+	if reply := s.config.Errors.NotFound; reply != nil {
+		s.sendReply(w, r, *reply, http.StatusNotFound)
+		return
+	}
+	s.fail(w, r, http.StatusNotFound, fmt.Sprintf("No endpoint serves %s %s", r.Method, r.URL.Path))
+}
+
+// methodNotAllowed serves requests whose path matches an endpoint but not its method.
+func (s *server) methodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	allowed := s.allowedMethods(r)
+	w.Header().Set("Allow", strings.Join(allowed, ", "))
+	if reply := s.config.Errors.MethodNotAllowed; reply != nil {
+		s.sendReply(w, r, *reply, http.StatusMethodNotAllowed)
+		return
+	}
+	s.fail(w, r, http.StatusMethodNotAllowed, fmt.Sprintf("%s %s is not allowed, use %s", r.Method, r.URL.Path, strings.Join(allowed, " or ")))
+}
+
+// allowedMethods returns the methods the routes matching the request's path accept.
+func (s *server) allowedMethods(r *http.Request) []string {
+	s.mutex.RLock()
+	router := s.router
+	s.mutex.RUnlock()
+
+	var allowed []string
+	router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		var match mux.RouteMatch
+		if route.Match(r, &match) || match.MatchErr == mux.ErrMethodMismatch {
+			// Every route has methods
+			methods, _ := route.GetMethods()
+			allowed = append(allowed, methods...)
+		}
+		return nil
+	})
+	slices.Sort(allowed)
+	return slices.Compact(allowed)
+}
@@ -0,0 +1,190 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/paqstd-team/fake-cli/config"
+	"github.com/paqstd-team/fake-cli/jsonschema"
+)
+
+func problemTestConfig(errors config.Errors) config.Config {
+	return config.Config{
+		Errors: errors,
+		Endpoints: []config.Endpoint{
+			{URL: "/items", Type: http.MethodGet, Response: map[string]any{"id": "uuid"}},
+			{URL: "/items", Type: http.MethodPost, Payload: map[string]any{"name": ""}},
+			{URL: "/typed", Type: http.MethodPost, PayloadSchema: &jsonschema.Schema{Type: jsonschema.Types{"object"}, Required: []string{"name"}}},
+		},
+	}
+}
+
+func TestProblem_Defaults(t *testing.T) {
+	handler := MakeHandler(problemTestConfig(config.Errors{}))
+
+	tests := []struct {
+		name           string
+		method         string
+		target         string
+		body           string
+		expectedStatus int
+		expectedBody   string
+		expectedAllow  string
+	}{
+		{
+			name:           "invalid_json",
+			method:         http.MethodPost,
+			target:         "/items",
+			body:           `{"name"`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"type":"about:blank","title":"Bad Request","status":400,"detail":"Invalid JSON body","instance":"/items"}`,
+		},
+		{
+			name:           "unknown_route",
+			method:         http.MethodGet,
+			target:         "/nope",
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"type":"about:blank","title":"Not Found","status":404,"detail":"No endpoint serves GET /nope","instance":"/nope"}`,
+		},
+		{
+			name:           "wrong_method",
+			method:         http.MethodDelete,
+			target:         "/items",
+			expectedStatus: http.StatusMethodNotAllowed,
+			expectedBody:   `{"type":"about:blank","title":"Method Not Allowed","status":405,"detail":"DELETE /items is not allowed, use GET or POST","instance":"/items"}`,
+			expectedAllow:  "GET, POST",
+		},
+		{
+			name:           "admin_error",
+			method:         http.MethodPost,
+			target:         "/__admin/endpoints",
+			body:           `{`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"type":"about:blank","title":"Bad Request","status":400,"detail":"Invalid JSON body","instance":"/__admin/endpoints"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := adminRequest(handler, tt.method, tt.target, tt.body)
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if got := w.Header().Get("Content-Type"); got != config.ProblemContentType {
+				t.Errorf("Expected content type %s, got %s", config.ProblemContentType, got)
+			}
+			if got := strings.TrimSpace(w.Body.String()); got != tt.expectedBody {
+				t.Errorf("Expected body %s, got %s", tt.expectedBody, got)
+			}
+			if got := w.Header().Get("Allow"); got != tt.expectedAllow {
+				t.Errorf("Expected Allow %q, got %q", tt.expectedAllow, got)
+			}
+		})
+	}
+}
+
+func TestProblem_CustomShape(t *testing.T) {
+	tests := []struct {
+		name                string
+		errors              config.Errors
+		target              string
+		body                string
+		expectedContentType string
+		expectedBody        string
+	}{
+		{
+			name: "typed_members",
+			errors: config.Errors{Body: map[string]any{
+				"error": map[string]any{"code": "{status}", "kind": "{type}", "problems": "{violations}"},
+				"path":  []any{"{instance}"},
+				"v":     float64(1),
+			}},
+			target:              "/typed",
+			body:                `{}`,
+			expectedContentType: "application/json",
+			expectedBody:        `{"error":{"code":422,"kind":"about:blank","problems":[{"pointer":"/name","message":"is required"}]},"path":["/typed"],"v":1}`,
+		},
+		{
+			name:                "embedded_members",
+			errors:              config.Errors{Body: map[string]any{"message": "{status} {title}: {detail} ({violations})"}},
+			target:              "/typed",
+			body:                `{}`,
+			expectedContentType: "application/json",
+			expectedBody:        `{"message":"422 Unprocessable Entity: Payload does not match schema (/name: is required)"}`,
+		},
+		{
+			name:                "no_violations",
+			errors:              config.Errors{Body: map[string]any{"problems": "{violations}"}, ContentType: "application/vnd.error+json"},
+			target:              "/items",
+			body:                `[]`,
+			expectedContentType: "application/vnd.error+json",
+			expectedBody:        `{"problems":[]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := MakeHandler(problemTestConfig(tt.errors))
+			w := adminRequest(handler, http.MethodPost, tt.target, tt.body)
+			if got := w.Header().Get("Content-Type"); got != tt.expectedContentType {
+				t.Errorf("Expected content type %s, got %s", tt.expectedContentType, got)
+			}
+			if got := strings.TrimSpace(w.Body.String()); got != tt.expectedBody {
+				t.Errorf("Expected body %s, got %s", tt.expectedBody, got)
+			}
+		})
+	}
+}
+
+func TestProblem_ConfiguredReplies(t *testing.T) {
+	handler := MakeHandler(problemTestConfig(config.Errors{
+		NotFound:         &config.Reply{Headers: map[string]string{"X-Mock": "missing"}, Response: map[string]any{"message": "nothing here"}},
+		MethodNotAllowed: &config.Reply{Status: http.StatusBadRequest, Response: map[string]any{"message": "wrong method"}},
+	}))
+
+	w := serve(handler, "/nope", nil)
+	if w.Code != http.StatusNotFound || w.Body.String() != `{"message":"nothing here"}` {
+		t.Errorf("Expected configured 404, got %d %s", w.Code, w.Body.String())
+	}
+	if w.Header().Get("X-Mock") != "missing" || w.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("Expected configured headers, got %v", w.Header())
+	}
+
+	w = adminRequest(handler, http.MethodPut, "/items", "")
+	if w.Code != http.StatusBadRequest || w.Body.String() != `{"message":"wrong method"}` {
+		t.Errorf("Expected configured 405 reply, got %d %s", w.Code, w.Body.String())
+	}
+	if w.Header().Get("Allow") != "GET, POST" {
+		t.Errorf("Expected Allow header, got %q", w.Header().Get("Allow"))
+	}
+
+	// Configured errors are sent as JSON whatever the client accepts
+	for _, accept := range []string{"image/*", "application/xml"} {
+		w = serve(handler, "/nope", http.Header{"Accept": {accept}})
+		if w.Code != http.StatusNotFound || w.Body.String() != `{"message":"nothing here"}` || w.Header().Get("Content-Type") != "application/json" {
+			t.Errorf("Expected configured 404 as JSON for %s, got %d %s %s", accept, w.Code, w.Header().Get("Content-Type"), w.Body.String())
+		}
+	}
+}
+
+func TestProblem_UpstreamFailure(t *testing.T) {
+	upstream := httptest.NewServer(http.NotFoundHandler())
+	upstream.Close()
+
+	handler := MakeHandler(config.Config{
+		Fallback:  config.Fallback{Upstream: upstream.URL},
+		Endpoints: []config.Endpoint{{URL: "/users", Passthrough: true}},
+	})
+
+	for _, target := range []string{"/users", "/unknown"} {
+		w := serve(handler, target, nil)
+		if w.Code != http.StatusBadGateway || w.Header().Get("Content-Type") != config.ProblemContentType {
+			t.Errorf("Expected 502 problem for %s, got %d %s", target, w.Code, w.Header().Get("Content-Type"))
+		}
+		if !strings.Contains(w.Body.String(), `"detail":"Upstream request failed: `) {
+			t.Errorf("Expected upstream error detail for %s, got %s", target, w.Body.String())
+		}
+	}
+}
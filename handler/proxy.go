@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+
+	"github.com/paqstd-team/fake-cli/record"
+)
+
+// inboundPathKey holds the path a proxied request arrived with, before the
+// upstream's base path was joined to it.
+type inboundPathKey struct{}
+
+// newProxy forwards requests to upstream. modify, unless nil, can read and
+// change every response before it is sent to the client.
+func newProxy(upstream *url.URL, modify func(*http.Response) error) *httputil.ReverseProxy {
+	return &httputil.ReverseProxy{
+		Rewrite: func(r *httputil.ProxyRequest) {
+			r.Out = r.Out.WithContext(context.WithValue(r.Out.Context(), inboundPathKey{}, r.In.URL.Path))
+			r.SetURL(upstream)
+			r.SetXForwarded()
+			if modify != nil {
+				// Without the client's Accept-Encoding the transport asks for
+				// gzip itself and decompresses, so modify sees plain bodies
+				r.Out.Header.Del("Accept-Encoding")
+			}
+		},
+		ModifyResponse: modify,
+	}
+}
+
+// newFallbackProxy forwards unmatched requests to upstream. Responses with a
+// JSON or empty body are added to recorder unless it is nil.
+func newFallbackProxy(upstream *url.URL, recorder *record.Recorder) *httputil.ReverseProxy {
+	if recorder == nil {
+		return newProxy(upstream, nil)
+	}
+	return newProxy(upstream, func(resp *http.Response) error {
+		return capture(resp, recorder)
+	})
+}
+
+// capture records resp and leaves its body intact for the client.
+func capture(resp *http.Response, recorder *record.Recorder) error {
+	data, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+
+	var body any
+	if len(data) > 0 && json.Unmarshal(data, &body) != nil {
+		return nil
+	}
+	recorder.Add(record.Recording{
+		Method: resp.Request.Method,
+		Path:   inboundPath(resp.Request),
+		Status: resp.StatusCode,
+		Body:   body,
+	})
+	return nil
+}
+
+// inboundPath returns the path the client requested, which the upstream's
+// base path has not been joined to.
+func inboundPath(r *http.Request) string {
+	if path, ok := r.Context().Value(inboundPathKey{}).(string); ok {
+		return path
+	}
+	return r.URL.Path
+}
+
+// proxyError reports a request that could not be forwarded to the upstream.
+func (s *shared) proxyError(w http.ResponseWriter, r *http.Request, err error) {
+	s.fail(w, r, http.StatusBadGateway, fmt.Sprintf("Upstream request failed: %v", err))
+}
+
+// fallback serves requests that no endpoint matches.
+func (s *server) fallback(w http.ResponseWriter, r *http.Request) {
+	// Unknown admin routes are not forwarded
+	if s.isAdmin(r) {
+		s.notFound(w, r)
+		return
+	}
+	s.proxy.ServeHTTP(w, r)
+}
+
+// listRecordings returns the captured upstream responses.
+func (s *server) listRecordings(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.recorder.Recordings())
+}
+
+// clearRecordings drops the captured upstream responses.
+func (s *server) clearRecordings(w http.ResponseWriter, r *http.Request) {
+	s.recorder.Clear()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// recordedConfig returns a config with an endpoint for every recorded route.
+// With ?infer=true values are replaced with data types where possible.
+func (s *server) recordedConfig(w http.ResponseWriter, r *http.Request) {
+	infer := r.URL.Query().Get("infer") == "true"
+	endpoints := record.Endpoints(s.recorder.Recordings(), infer)
+
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(map[string]any{"endpoints": endpoints})
+}
@@ -0,0 +1,206 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/paqstd-team/fake-cli/config"
+	"github.com/paqstd-team/fake-cli/record"
+)
+
+func newUpstream(t *testing.T) *httptest.Server {
+	t.Helper()
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/users/42":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"id": 42, "email": "ada@example.com"}`))
+		case "/users":
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"created": true}`))
+		case "/teams":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`[{"name":"email","size":3},{"name":"Grace","tags":["word"]}]`))
+		case "/health":
+			w.Write([]byte("ok"))
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	t.Cleanup(upstream.Close)
+	return upstream
+}
+
+func fallbackTestConfig(upstream string, record bool) config.Config {
+	return config.Config{
+		Fallback: config.Fallback{Upstream: upstream, Record: record},
+		Endpoints: []config.Endpoint{
+			{URL: "/orders", Response: map[string]any{"mocked": true}},
+		},
+	}
+}
+
+func TestProxy_Fallback(t *testing.T) {
+	upstream := newUpstream(t)
+	handler := MakeHandler(fallbackTestConfig(upstream.URL, false))
+
+	tests := []struct {
+		name           string
+		method         string
+		target         string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{name: "mocked_route", method: http.MethodGet, target: "/orders", expectedStatus: http.StatusOK, expectedBody: `{"mocked":true}`},
+		{name: "unknown_route", method: http.MethodGet, target: "/users/42", expectedStatus: http.StatusOK, expectedBody: `{"id": 42, "email": "ada@example.com"}`},
+		{name: "other_method", method: http.MethodPost, target: "/orders", expectedStatus: http.StatusNoContent},
+		{name: "unknown_admin_route", method: http.MethodGet, target: "/__admin/nope", expectedStatus: http.StatusNotFound, expectedBody: `{"type":"about:blank","title":"Not Found","status":404,"detail":"No endpoint serves GET /__admin/nope","instance":"/__admin/nope"}` + "\n"},
+		{name: "recordings_off", method: http.MethodGet, target: "/__admin/recordings", expectedStatus: http.StatusNotFound, expectedBody: `{"type":"about:blank","title":"Not Found","status":404,"detail":"No endpoint serves GET /__admin/recordings","instance":"/__admin/recordings"}` + "\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := adminRequest(handler, tt.method, tt.target, "")
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if w.Body.String() != tt.expectedBody {
+				t.Errorf("Expected body %q, got %q", tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestProxy_Record(t *testing.T) {
+	upstream := newUpstream(t)
+	handler := MakeHandler(fallbackTestConfig(upstream.URL, true))
+
+	req := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Body.String() != `{"id": 42, "email": "ada@example.com"}` {
+		t.Fatalf("Expected upstream body to reach the client, got %s", w.Body.String())
+	}
+	adminRequest(handler, http.MethodPost, "/users", `{}`)
+	// Non-JSON bodies are forwarded but not recorded
+	if w := serve(handler, "/health", nil); w.Body.String() != "ok" {
+		t.Errorf("Expected upstream body, got %s", w.Body.String())
+	}
+	// Mocked routes are not recorded
+	serve(handler, "/orders", nil)
+
+	var recordings []record.Recording
+	if err := json.Unmarshal(serve(handler, "/__admin/recordings", nil).Body.Bytes(), &recordings); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if len(recordings) != 2 || recordings[0].Path != "/users/42" || recordings[1].Status != http.StatusCreated {
+		t.Fatalf("Unexpected recordings %+v", recordings)
+	}
+
+	var generated config.Config
+	if err := json.Unmarshal(serve(handler, "/__admin/recordings/config?infer=true", nil).Body.Bytes(), &generated); err != nil {
+		t.Fatalf("Failed to unmarshal config: %v", err)
+	}
+	if len(generated.Endpoints) != 2 || generated.Endpoints[0].URL != "/users/{id}" || generated.Endpoints[1].Status != http.StatusCreated {
+		t.Fatalf("Unexpected endpoints %+v", generated.Endpoints)
+	}
+	if response := generated.Endpoints[0].Response.(map[string]any); response["email"] != "email" {
+		t.Errorf("Expected inferred email type, got %v", response)
+	}
+	if err := config.Validate(generated); err != nil {
+		t.Errorf("Expected generated config to be valid, got %v", err)
+	}
+
+	if w := adminRequest(handler, http.MethodDelete, "/__admin/recordings", ""); w.Code != http.StatusNoContent {
+		t.Fatalf("Expected status 204, got %d", w.Code)
+	}
+	if w := serve(handler, "/__admin/recordings", nil); w.Body.String() != "[]\n" {
+		t.Errorf("Expected no recordings, got %s", w.Body.String())
+	}
+}
+
+func TestProxy_RecordReplaysExactly(t *testing.T) {
+	upstream := newUpstream(t)
+	handler := MakeHandler(fallbackTestConfig(upstream.URL, true))
+	expected := serve(handler, "/teams", nil).Body.String()
+	serve(handler, "/users/42", nil)
+
+	var generated config.Config
+	if err := json.Unmarshal(serve(handler, "/__admin/recordings/config", nil).Body.Bytes(), &generated); err != nil {
+		t.Fatalf("Failed to unmarshal config: %v", err)
+	}
+	if err := config.Validate(generated); err != nil {
+		t.Fatalf("Expected generated config to be valid, got %v", err)
+	}
+
+	mock := MakeHandler(generated)
+	// A top-level array is answered as recorded, not as a paginated list
+	if got := serve(mock, "/teams", nil).Body.String(); got != expected {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+	if got := serve(mock, "/users/42", nil).Body.String(); got != `{"email":"ada@example.com","id":42}` {
+		t.Errorf("Expected recorded object, got %s", got)
+	}
+}
+
+func TestProxy_RecordInboundPath(t *testing.T) {
+	var forwarded string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		forwarded = r.URL.Path
+		w.Write([]byte(`{"id": 42}`))
+	}))
+	t.Cleanup(upstream.Close)
+	handler := MakeHandler(fallbackTestConfig(upstream.URL+"/api", true))
+
+	serve(handler, "/users/42", nil)
+	if forwarded != "/api/users/42" {
+		t.Fatalf("Expected upstream base path to be joined, got %s", forwarded)
+	}
+
+	var generated config.Config
+	if err := json.Unmarshal(serve(handler, "/__admin/recordings/config", nil).Body.Bytes(), &generated); err != nil {
+		t.Fatalf("Failed to unmarshal config: %v", err)
+	}
+	if len(generated.Endpoints) != 1 || generated.Endpoints[0].URL != "/users/42" {
+		t.Fatalf("Expected endpoint at the requested path, got %+v", generated.Endpoints)
+	}
+	if got := serve(MakeHandler(generated), "/users/42", nil).Body.String(); got != `{"id":42}` {
+		t.Errorf("Expected recorded object, got %s", got)
+	}
+}
+
+type failingBody struct{}
+
+func (failingBody) Read([]byte) (int, error) {
+	return 0, errors.New("connection reset")
+}
+
+func (failingBody) Close() error {
+	return nil
+}
+
+func TestProxy_CaptureReadError(t *testing.T) {
+	resp := &http.Response{
+		Body:    failingBody{},
+		Request: httptest.NewRequest(http.MethodGet, "/users", nil),
+	}
+	if err := capture(resp, record.NewRecorder()); err == nil {
+		t.Error("Expected read error")
+	}
+
+	resp = &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(strings.NewReader("")),
+		Request:    httptest.NewRequest(http.MethodGet, "/empty", nil),
+	}
+	recorder := record.NewRecorder()
+	if err := capture(resp, recorder); err != nil || len(recorder.Recordings()) != 1 {
+		t.Errorf("Expected empty body to be recorded, got %v", err)
+	}
+}
@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"sync"
+
+	"github.com/gorilla/mux"
+	"github.com/paqstd-team/fake-cli/config"
+)
+
+// scenarioStore keeps the current state of every scenario, per key.
+type scenarioStore struct {
+	mutex     sync.Mutex
+	scenarios map[string]config.Scenario
+	// states maps scenario name to key to state; missing entries are in the initial state
+	states map[string]map[string]string
+}
+
+func newScenarioStore(scenarios []config.Scenario) *scenarioStore {
+	s := &scenarioStore{
+		scenarios: make(map[string]config.Scenario, len(scenarios)),
+		states:    make(map[string]map[string]string, len(scenarios)),
+	}
+	for _, scenario := range scenarios {
+		s.scenarios[scenario.Name] = scenario
+		s.states[scenario.Name] = make(map[string]string)
+	}
+	return s
+}
+
+// key returns the state key of a request for the named scenario.
+func (s *scenarioStore) key(name string, r *http.Request) string {
+	keyName := s.scenarios[name].Key
+	if keyName == "" {
+		return ""
+	}
+	if value, ok := mux.Vars(r)[keyName]; ok {
+		return value
+	}
+	return r.URL.Query().Get(keyName)
+}
+
+// lookup returns the reply for the current state of the scenario and the
+// state. It reports false when the endpoint has no reply for the state.
+func (s *scenarioStore) lookup(name, key string, states map[string]config.State) (config.State, string, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	current := s.current(name, key)
+	state, ok := states[current]
+	if !ok {
+		state, ok = states[config.AnyState]
+	}
+	return state, current, ok
+}
+
+// move moves the scenario from state to next for key, once the reply for
+// state is served. Requests served concurrently in the same state move the
+// scenario only once.
+func (s *scenarioStore) move(name, key, state, next string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if next != "" && s.current(name, key) == state {
+		s.states[name][key] = next
+	}
+}
+
+// current returns the state of the scenario for key. The caller holds the lock.
+func (s *scenarioStore) current(name, key string) string {
+	if state, ok := s.states[name][key]; ok {
+		return state
+	}
+	return s.scenarios[name].Initial
+}
+
+// set moves the scenario to state for key.
+func (s *scenarioStore) set(name, key, state string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, ok := s.scenarios[name]; !ok {
+		return fmt.Errorf("unknown scenario %q", name)
+	}
+	s.states[name][key] = state
+	return nil
+}
+
+// reset puts the scenario back into its initial state, for a single key or,
+// when key is nil, for every key. An empty name resets every scenario.
+func (s *scenarioStore) reset(name string, key *string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if name == "" {
+		for name := range s.states {
+			s.states[name] = make(map[string]string)
+		}
+		return nil
+	}
+	if _, ok := s.scenarios[name]; !ok {
+		return fmt.Errorf("unknown scenario %q", name)
+	}
+	if key == nil {
+		s.states[name] = make(map[string]string)
+	} else {
+		delete(s.states[name], *key)
+	}
+	return nil
+}
+
+type scenarioState struct {
+	Name    string            `json:"name"`
+	Initial string            `json:"initial"`
+	States  map[string]string `json:"states"`
+}
+
+// snapshot returns the states of every scenario, keyed by state key.
+func (s *scenarioStore) snapshot() []scenarioState {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	snapshot := make([]scenarioState, 0, len(s.scenarios))
+	for _, name := range slices.Sorted(maps.Keys(s.scenarios)) {
+		snapshot = append(snapshot, scenarioState{
+			Name:    name,
+			Initial: s.scenarios[name].Initial,
+			States:  maps.Clone(s.states[name]),
+		})
+	}
+	return snapshot
+}
@@ -0,0 +1,205 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/paqstd-team/fake-cli/config"
+)
+
+func scenarioTestConfig() config.Config {
+	return config.Config{
+		Scenarios: []config.Scenario{
+			{Name: "job", Initial: "submitted", Key: "id"},
+			{Name: "flaky", Initial: "failing", States: []string{"healthy"}},
+		},
+		Endpoints: []config.Endpoint{
+			{
+				URL:      "/jobs/{id}",
+				Scenario: "job",
+				States: map[string]config.State{
+					"submitted": {Reply: config.Reply{Response: map[string]any{"status": "submitted"}}, Next: "running"},
+					"running":   {Reply: config.Reply{Response: map[string]any{"status": "running"}}, Next: "completed"},
+					"completed": {Reply: config.Reply{Response: map[string]any{"status": "completed"}}},
+				},
+			},
+			{
+				URL:      "/jobs",
+				Type:     http.MethodPost,
+				Scenario: "job",
+				Response: map[string]any{"accepted": true},
+				States: map[string]config.State{
+					config.AnyState: {Reply: config.Reply{Status: http.StatusAccepted, Response: map[string]any{"accepted": true}}, Next: "submitted"},
+				},
+			},
+			{
+				URL:      "/health",
+				Scenario: "flaky",
+				Response: map[string]any{"ok": true},
+				States: map[string]config.State{
+					"failing": {Reply: config.Reply{Status: http.StatusInternalServerError, Response: map[string]any{"ok": false}}, Next: "healthy"},
+				},
+			},
+		},
+	}
+}
+
+func jobStatus(t *testing.T, handler http.Handler, target string) string {
+	t.Helper()
+	var response map[string]any
+	if err := json.Unmarshal(serve(handler, target, nil).Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	status, _ := response["status"].(string)
+	return status
+}
+
+func TestScenario_JobFlow(t *testing.T) {
+	handler := MakeHandler(scenarioTestConfig())
+
+	for _, expected := range []string{"submitted", "running", "completed", "completed"} {
+		if status := jobStatus(t, handler, "/jobs/1"); status != expected {
+			t.Fatalf("Expected status %q, got %q", expected, status)
+		}
+	}
+
+	// Every job id has its own state
+	if status := jobStatus(t, handler, "/jobs/2"); status != "submitted" {
+		t.Errorf("Expected a new job to start submitted, got %q", status)
+	}
+}
+
+func TestScenario_RetryFlow(t *testing.T) {
+	handler := MakeHandler(scenarioTestConfig())
+
+	if w := serve(handler, "/health", nil); w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected first call to fail, got %d", w.Code)
+	}
+	// Without a reply for "healthy" the endpoint's own reply is used
+	for i := 0; i < 2; i++ {
+		if w := serve(handler, "/health", nil); w.Code != http.StatusOK || w.Body.String() != `{"ok":true}` {
+			t.Errorf("Expected later calls to succeed, got %d %s", w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestScenario_TransitionOnlyWhenServed(t *testing.T) {
+	handler := MakeHandler(scenarioTestConfig())
+
+	// A request that fails leaves the state alone
+	if w := serve(handler, "/jobs/1?_seed=abc", nil); w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400, got %d", w.Code)
+	}
+	if status := jobStatus(t, handler, "/jobs/1"); status != "submitted" {
+		t.Errorf("Expected job to be submitted, got %q", status)
+	}
+	if status := jobStatus(t, handler, "/jobs/1"); status != "running" {
+		t.Errorf("Expected job to be running, got %q", status)
+	}
+
+	// A reply served for a state the scenario has left since does not move it
+	store := newScenarioStore(scenarioTestConfig().Scenarios)
+	store.move("flaky", "", "healthy", "failing")
+	if _, current, _ := store.lookup("flaky", "", nil); current != "failing" {
+		t.Errorf("Expected scenario to stay failing, got %q", current)
+	}
+}
+
+func TestScenario_QueryKeyAndAnyState(t *testing.T) {
+	handler := MakeHandler(scenarioTestConfig())
+
+	jobStatus(t, handler, "/jobs/1")
+	if status := jobStatus(t, handler, "/jobs/1"); status != "running" {
+		t.Fatalf("Expected job to be running, got %q", status)
+	}
+
+	// The POST endpoint has no {id} path variable, so the key comes from the query
+	w := adminRequest(handler, http.MethodPost, "/jobs?id=1", "")
+	if w.Code != http.StatusAccepted {
+		t.Errorf("Expected status 202, got %d", w.Code)
+	}
+	if status := jobStatus(t, handler, "/jobs/1"); status != "submitted" {
+		t.Errorf("Expected job to be submitted again, got %q", status)
+	}
+}
+
+func TestScenario_Admin(t *testing.T) {
+	handler := MakeHandler(scenarioTestConfig())
+
+	jobStatus(t, handler, "/jobs/1")
+	jobStatus(t, handler, "/jobs/2")
+	jobStatus(t, handler, "/jobs/2")
+
+	var states []scenarioState
+	w := adminRequest(handler, http.MethodGet, "/__admin/scenarios", "")
+	if err := json.Unmarshal(w.Body.Bytes(), &states); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if len(states) != 2 || states[1].Name != "job" || states[1].States["1"] != "running" || states[1].States["2"] != "completed" {
+		t.Fatalf("Unexpected scenario states %+v", states)
+	}
+
+	// Reset a single key
+	w = adminRequest(handler, http.MethodPost, "/__admin/scenarios/reset", `{"name": "job", "key": "2"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if status := jobStatus(t, handler, "/jobs/2"); status != "submitted" {
+		t.Errorf("Expected reset job to be submitted, got %q", status)
+	}
+	if status := jobStatus(t, handler, "/jobs/1"); status != "running" {
+		t.Errorf("Expected other job to keep its state, got %q", status)
+	}
+
+	// Jump to a state
+	w = adminRequest(handler, http.MethodPut, "/__admin/scenarios", `{"name": "job", "key": "3", "state": "completed"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if status := jobStatus(t, handler, "/jobs/3"); status != "completed" {
+		t.Errorf("Expected job to be completed, got %q", status)
+	}
+
+	// Reset every key of a scenario
+	adminRequest(handler, http.MethodPost, "/__admin/scenarios/reset", `{"name": "job"}`)
+	if status := jobStatus(t, handler, "/jobs/3"); status != "submitted" {
+		t.Errorf("Expected scenario reset, got %q", status)
+	}
+
+	// Reset everything
+	serve(handler, "/health", nil)
+	adminRequest(handler, http.MethodPost, "/__admin/scenarios/reset", "")
+	if w := serve(handler, "/health", nil); w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected flaky scenario reset, got %d", w.Code)
+	}
+}
+
+func TestScenario_AdminErrors(t *testing.T) {
+	handler := MakeHandler(scenarioTestConfig())
+
+	tests := []struct {
+		name           string
+		method         string
+		target         string
+		body           string
+		expectedStatus int
+	}{
+		{name: "set_invalid_json", method: http.MethodPut, target: "/__admin/scenarios", body: `{`, expectedStatus: http.StatusBadRequest},
+		{name: "set_unknown", method: http.MethodPut, target: "/__admin/scenarios", body: `{"name": "nope", "state": "x"}`, expectedStatus: http.StatusNotFound},
+		{name: "set_unknown_state", method: http.MethodPut, target: "/__admin/scenarios", body: `{"name": "job", "state": "done"}`, expectedStatus: http.StatusBadRequest},
+		{name: "add_unknown_next_state", method: http.MethodPost, target: "/__admin/endpoints", body: `{"url": "/jobs/{id}/cancel", "type": "POST", "scenario": "job", "states": {"*": {"next": "cancelled"}}}`, expectedStatus: http.StatusBadRequest},
+		{name: "add_next_state_of_other_endpoint", method: http.MethodPost, target: "/__admin/endpoints", body: `{"url": "/jobs/{id}/retry", "type": "POST", "scenario": "job", "states": {"*": {"next": "running"}}}`, expectedStatus: http.StatusCreated},
+		{name: "reset_invalid_json", method: http.MethodPost, target: "/__admin/scenarios/reset", body: `[`, expectedStatus: http.StatusBadRequest},
+		{name: "reset_unknown", method: http.MethodPost, target: "/__admin/scenarios/reset", body: `{"name": "nope"}`, expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := adminRequest(handler, tt.method, tt.target, tt.body)
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+		})
+	}
+}
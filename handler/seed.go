@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"hash/fnv"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/brianvoe/gofakeit/v7"
+)
+
+// Per-request seed overrides, e.g. "X-Fake-Seed: 42" or "?_seed=42".
+const (
+	seedHeader     = "X-Fake-Seed"
+	seedQueryParam = "_seed"
+)
+
+// Purposes an endpoint draws seeds for, each from a stream of its own.
+const (
+	seedData     = "data"
+	seedSequence = "sequence"
+	seedFault    = "fault"
+)
+
+// seedSource hands out seeds derived from the configured seed, so each request
+// gets its own generator instead of sharing a locked global one. Every stream,
+// named after an endpoint and a purpose, advances on its own, so traffic to one
+// endpoint or a fault roll does not change the data of another.
+type seedSource struct {
+	mutex   sync.Mutex
+	seed    uint64
+	streams map[string]*rand.Rand
+}
+
+func newSeedSource(seed uint64) *seedSource {
+	return &seedSource{seed: seed, streams: map[string]*rand.Rand{}}
+}
+
+// next returns the next seed of a stream.
+func (s *seedSource) next(stream string) uint64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	rng, ok := s.streams[stream]
+	if !ok {
+		hash := fnv.New64a()
+		hash.Write([]byte(stream))
+		rng = rand.New(rand.NewPCG(s.seed, hash.Sum64()))
+		s.streams[stream] = rng
+	}
+	return rng.Uint64()
+}
+
+// seed returns the next seed the endpoint draws for purpose.
+func (h *endpointHandler) seed(purpose string) uint64 {
+	return h.seeds.next(purpose + " " + h.method + " " + h.endpoint.URL)
+}
+
+// random returns a generator for a single draw of the endpoint for purpose.
+func (h *endpointHandler) random(purpose string) *rand.Rand {
+	seed := h.seed(purpose)
+	return rand.New(rand.NewPCG(seed, seed))
+}
+
+// newFaker returns an unlocked generator for a single goroutine. Unlike gofakeit.New,
+// a zero seed is a regular seed rather than a request for a random one.
+func newFaker(seed uint64) *gofakeit.Faker {
+	return gofakeit.NewFaker(rand.NewPCG(seed, seed), false)
+}
+
+// requestSeed returns the seed the client asked for, if any.
+func requestSeed(r *http.Request) (seed uint64, ok bool, err error) {
+	value := r.Header.Get(seedHeader)
+	if value == "" {
+		value = r.URL.Query().Get(seedQueryParam)
+	}
+	if value == "" {
+		return 0, false, nil
+	}
+
+	seed, err = strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, false, err
+	}
+	return seed, true, nil
+}
+
+// reset starts every stream over from seed.
+func (s *seedSource) reset(seed uint64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.seed = seed
+	clear(s.streams)
+}
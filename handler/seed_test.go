@@ -0,0 +1,195 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/paqstd-team/fake-cli/config"
+)
+
+func seedTestConfig(seed config.Seed) config.Config {
+	cacheSize := 5
+	return config.Config{
+		Seed: seed,
+		Endpoints: []config.Endpoint{
+			{
+				URL:      "/api/users",
+				Response: []any{map[string]any{"id": "uuid", "name": "name"}},
+			},
+			{
+				URL:      "/api/cached",
+				Response: map[string]any{"id": "uuid"},
+				Cache:    &cacheSize,
+			},
+		},
+	}
+}
+
+func serve(handler http.Handler, target string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w
+}
+
+func TestSeed_DeterministicAcrossRuns(t *testing.T) {
+	tests := []struct {
+		name       string
+		seedA      config.Seed
+		seedB      config.Seed
+		expectSame bool
+	}{
+		{name: "default_seed", seedA: "", seedB: "", expectSame: true},
+		{name: "same_seed", seedA: "42", seedB: "42", expectSame: true},
+		{name: "different_seeds", seedA: "1", seedB: "2", expectSame: false},
+		{name: "random_seed", seedA: config.SeedRandom, seedB: config.SeedRandom, expectSame: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handlerA := MakeHandler(seedTestConfig(tt.seedA))
+			handlerB := MakeHandler(seedTestConfig(tt.seedB))
+
+			for i := 0; i < 3; i++ {
+				a := serve(handlerA, "/api/users", nil).Body.String()
+				b := serve(handlerB, "/api/users", nil).Body.String()
+				if (a == b) != tt.expectSame {
+					t.Fatalf("Request %d: expected same=%v, got %s and %s", i, tt.expectSame, a, b)
+				}
+			}
+		})
+	}
+}
+
+func TestSeed_SuccessiveRequestsDiffer(t *testing.T) {
+	handler := MakeHandler(seedTestConfig("42"))
+
+	first := serve(handler, "/api/users", nil).Body.String()
+	second := serve(handler, "/api/users", nil).Body.String()
+	if first == second {
+		t.Error("Expected successive requests to produce different data")
+	}
+}
+
+func TestSeed_RequestOverride(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		header http.Header
+	}{
+		{name: "header", target: "/api/users", header: http.Header{"X-Fake-Seed": {"7"}}},
+		{name: "query", target: "/api/users?_seed=7"},
+		{name: "header_bypasses_cache", target: "/api/cached", header: http.Header{"X-Fake-Seed": {"7"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handlerA := MakeHandler(seedTestConfig("1"))
+			handlerB := MakeHandler(seedTestConfig("2"))
+
+			// Unrelated traffic must not change a seeded response
+			serve(handlerB, tt.target[:len("/api/users")], nil)
+
+			a := serve(handlerA, tt.target, tt.header)
+			b := serve(handlerB, tt.target, tt.header)
+			if a.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d", a.Code)
+			}
+			if a.Body.String() != b.Body.String() {
+				t.Errorf("Expected identical responses for the same seed, got %s and %s", a.Body.String(), b.Body.String())
+			}
+		})
+	}
+}
+
+func TestSeed_OverrideDoesNotPolluteCache(t *testing.T) {
+	handler := MakeHandler(seedTestConfig("1"))
+
+	cached := serve(handler, "/api/cached", nil).Body.String()
+	seeded := serve(handler, "/api/cached", http.Header{"X-Fake-Seed": {"99"}}).Body.String()
+	again := serve(handler, "/api/cached", nil).Body.String()
+
+	if seeded == cached {
+		t.Error("Expected seeded response to bypass the cache")
+	}
+	if again != cached {
+		t.Error("Expected cached response to be unchanged by a seeded request")
+	}
+}
+
+func TestSeed_InvalidOverride(t *testing.T) {
+	handler := MakeHandler(seedTestConfig(""))
+
+	tests := []struct {
+		name   string
+		target string
+		header http.Header
+	}{
+		{name: "header", target: "/api/users", header: http.Header{"X-Fake-Seed": {"abc"}}},
+		{name: "query", target: "/api/users?_seed=-1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(handler, tt.target, tt.header)
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status 400, got %d", w.Code)
+			}
+		})
+	}
+}
+
+func TestSeed_EndpointsIndependent(t *testing.T) {
+	handlerA := MakeHandler(seedTestConfig("42"))
+	handlerB := MakeHandler(seedTestConfig("42"))
+
+	// Traffic to another endpoint and cache hits don't change the data
+	serve(handlerB, "/api/cached", nil)
+	serve(handlerA, "/api/cached?page=1", nil)
+	serve(handlerA, "/api/cached?page=1", nil)
+
+	for _, target := range []string{"/api/users", "/api/cached?page=2"} {
+		a := serve(handlerA, target, nil).Body.String()
+		b := serve(handlerB, target, nil).Body.String()
+		if a != b {
+			t.Errorf("Expected identical responses for %s, got %s and %s", target, a, b)
+		}
+	}
+}
+
+func TestSeed_Faults(t *testing.T) {
+	cfg := seedTestConfig("42")
+	faulty := seedTestConfig("42")
+	faulty.Endpoints[0].Fault = &config.Fault{Rate: floatPtr(0.5)}
+
+	// Fault rolls repeat with the seed and don't shift the generated data
+	var rolls [2][]int
+	var bodies []string
+	for i, handler := range []http.Handler{MakeHandler(faulty), MakeHandler(faulty)} {
+		for range 20 {
+			w := serve(handler, "/api/users", nil)
+			rolls[i] = append(rolls[i], w.Code)
+			if i == 0 && w.Code == http.StatusOK {
+				bodies = append(bodies, w.Body.String())
+			}
+		}
+	}
+	if !slices.Equal(rolls[0], rolls[1]) {
+		t.Errorf("Expected the same faults for the same seed, got %v and %v", rolls[0], rolls[1])
+	}
+	if len(bodies) == 0 || len(bodies) == len(rolls[0]) {
+		t.Fatalf("Expected some faults and some responses, got %v", rolls[0])
+	}
+
+	handler := MakeHandler(cfg)
+	for i, body := range bodies {
+		if expected := serve(handler, "/api/users", nil).Body.String(); body != expected {
+			t.Errorf("Response %d: expected %s, got %s", i, expected, body)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/paqstd-team/fake-cli/config"
+)
+
+// sequence hands out the replies of an endpoint's sequence in turn.
+type sequence struct {
+	mutex   sync.Mutex
+	replies []config.Reply
+	mode    string
+	next    int
+	// pick returns a random position below n, only used in random mode
+	pick func(n int) int
+}
+
+func newSequence(replies []config.Reply, mode string, pick func(n int) int) *sequence {
+	return &sequence{
+		replies: replies,
+		mode:    mode,
+		pick:    pick,
+	}
+}
+
+// step returns the reply to send and its position in the sequence. The
+// sequence stays on it until advance is called.
+func (s *sequence) step() (config.Reply, int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	i := s.next
+	if s.mode == config.SequenceRandom {
+		i = s.pick(len(s.replies))
+	}
+	return s.replies[i], i
+}
+
+// advance moves the sequence past the reply at position i once it has been
+// served. It does nothing when another request has moved the sequence on
+// since, so concurrent requests for the same step advance it only once.
+func (s *sequence) advance(i int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.next != i {
+		return
+	}
+	switch s.mode {
+	case config.SequenceRandom:
+		// Every reply is picked anew
+	case config.SequenceLoop:
+		s.next = (i + 1) % len(s.replies)
+	default:
+		s.next = min(i+1, len(s.replies)-1)
+	}
+}
+
+// reset starts the sequence over with its first reply.
+func (s *sequence) reset() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.next = 0
+}
+
+// sleep waits for the delay of a reply. It returns false when the request is
+// cancelled first.
+func sleep(ctx context.Context, delay config.Duration) bool {
+	if delay <= 0 {
+		return true
+	}
+
+	timer := time.NewTimer(time.Duration(delay))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
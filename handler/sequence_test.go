@@ -0,0 +1,189 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/paqstd-team/fake-cli/config"
+)
+
+func sequenceTestEndpoint(mode string) config.Endpoint {
+	return config.Endpoint{
+		URL:      "/status",
+		Response: map[string]any{"step": "default"},
+		Sequence: []config.Reply{
+			{Status: http.StatusServiceUnavailable, Headers: map[string]string{"Retry-After": "1"}, Response: map[string]any{"step": "first"}},
+			{Status: http.StatusAccepted, Response: map[string]any{"step": map[string]any{"$literal": "second"}}},
+			{Response: map[string]any{"step": "third"}},
+		},
+		SequenceMode: mode,
+	}
+}
+
+func TestSequence_Modes(t *testing.T) {
+	tests := []struct {
+		name     string
+		mode     string
+		expected []string
+	}{
+		{
+			name:     "stick_by_default",
+			mode:     "",
+			expected: []string{`{"step":"first"}`, `{"step":"second"}`, `{"step":"third"}`, `{"step":"third"}`},
+		},
+		{
+			name:     "stick",
+			mode:     config.SequenceStick,
+			expected: []string{`{"step":"first"}`, `{"step":"second"}`, `{"step":"third"}`, `{"step":"third"}`},
+		},
+		{
+			name:     "loop",
+			mode:     config.SequenceLoop,
+			expected: []string{`{"step":"first"}`, `{"step":"second"}`, `{"step":"third"}`, `{"step":"first"}`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := MakeHandler(config.Config{Endpoints: []config.Endpoint{sequenceTestEndpoint(tt.mode)}})
+
+			for i, expected := range tt.expected {
+				w := serve(handler, "/status", nil)
+				if w.Body.String() != expected {
+					t.Errorf("Request %d: expected %s, got %s", i, expected, w.Body.String())
+				}
+			}
+		})
+	}
+}
+
+func TestSequence_StatusAndHeaders(t *testing.T) {
+	handler := MakeHandler(config.Config{Endpoints: []config.Endpoint{sequenceTestEndpoint("")}})
+
+	w := serve(handler, "/status", nil)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503, got %d", w.Code)
+	}
+	if w.Header().Get("Retry-After") != "1" {
+		t.Errorf("Expected Retry-After header, got %q", w.Header().Get("Retry-After"))
+	}
+
+	if w := serve(handler, "/status", nil); w.Code != http.StatusAccepted {
+		t.Errorf("Expected status 202, got %d", w.Code)
+	}
+	if w := serve(handler, "/status", nil); w.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", w.Code)
+	}
+}
+
+func TestSequence_Random(t *testing.T) {
+	responses := func() []string {
+		handler := MakeHandler(config.Config{Endpoints: []config.Endpoint{sequenceTestEndpoint(config.SequenceRandom)}})
+		var bodies []string
+		for i := 0; i < 20; i++ {
+			bodies = append(bodies, serve(handler, "/status", nil).Body.String())
+		}
+		return bodies
+	}
+
+	first, second := responses(), responses()
+	seen := map[string]bool{}
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("Expected the same order for the same seed, got %s and %s", first[i], second[i])
+		}
+		seen[first[i]] = true
+	}
+	if len(seen) < 2 {
+		t.Errorf("Expected random mode to pick different replies, got %v", seen)
+	}
+}
+
+func TestSequence_CasesTakePrecedence(t *testing.T) {
+	endpoint := sequenceTestEndpoint("")
+	endpoint.Cases = []config.Case{
+		{When: config.Match{Query: map[string]config.Condition{"id": {Equals: "0"}}}, Reply: config.Reply{Status: http.StatusNotFound}},
+	}
+	handler := MakeHandler(config.Config{Endpoints: []config.Endpoint{endpoint}})
+
+	if w := serve(handler, "/status?id=0", nil); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", w.Code)
+	}
+	// The case did not use up the first reply of the sequence
+	if w := serve(handler, "/status", nil); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503, got %d", w.Code)
+	}
+}
+
+func TestSequence_Delay(t *testing.T) {
+	delay := 50 * time.Millisecond
+	handler := MakeHandler(config.Config{Endpoints: []config.Endpoint{{
+		URL:      "/slow",
+		Sequence: []config.Reply{{Delay: config.Duration(delay), Response: map[string]any{"ok": true}}},
+	}}})
+
+	start := time.Now()
+	w := serve(handler, "/slow", nil)
+	if elapsed := time.Since(start); elapsed < delay {
+		t.Errorf("Expected response after %v, got %v", delay, elapsed)
+	}
+	if w.Body.String() != `{"ok":true}` {
+		t.Errorf("Expected body, got %s", w.Body.String())
+	}
+}
+
+func TestSequence_DelayCancelled(t *testing.T) {
+	handler := MakeHandler(config.Config{Endpoints: []config.Endpoint{{
+		URL:      "/slow",
+		Sequence: []config.Reply{{Delay: config.Duration(time.Hour), Response: map[string]any{"ok": true}}},
+	}}})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req := httptest.NewRequest(http.MethodGet, "/slow", nil).WithContext(ctx)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Body.Len() != 0 {
+		t.Errorf("Expected no body for a cancelled request, got %s", w.Body.String())
+	}
+}
+
+func TestSequence_AdvancesWhenServed(t *testing.T) {
+	s := MakeHandler(config.Config{Endpoints: []config.Endpoint{
+		{URL: "/slow", Sequence: []config.Reply{{Delay: config.Duration(time.Hour)}, {}}},
+		{URL: "/status", Sequence: []config.Reply{{Status: http.StatusServiceUnavailable}, {Status: http.StatusOK}}},
+	}}).(*server)
+
+	// A client that gives up during the delay leaves the sequence where it is
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/slow", nil).WithContext(ctx))
+	if next := s.find(http.MethodGet, "/slow").sequence.next; next != 0 {
+		t.Errorf("Expected the sequence to stay on its first reply, got %d", next)
+	}
+
+	// So does a request that can't be answered in an acceptable format
+	if w := serve(s, "/status", http.Header{"Accept": {"image/png"}}); w.Code != http.StatusNotAcceptable {
+		t.Fatalf("Expected status 406, got %d", w.Code)
+	}
+	if w := serve(s, "/status", nil); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected the first reply, got %d", w.Code)
+	}
+	if w := serve(s, "/status", nil); w.Code != http.StatusOK {
+		t.Errorf("Expected the second reply, got %d", w.Code)
+	}
+
+	// Requests for a step that was served meanwhile advance it only once
+	sequence := newSequence([]config.Reply{{}, {}, {}}, config.SequenceStick, nil)
+	_, first := sequence.step()
+	_, second := sequence.step()
+	sequence.advance(first)
+	sequence.advance(second)
+	if sequence.next != 1 {
+		t.Errorf("Expected the sequence to advance once, got %d", sequence.next)
+	}
+}
@@ -0,0 +1,193 @@
+package handler
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"github.com/gorilla/mux"
+	"github.com/paqstd-team/fake-cli/config"
+	"github.com/paqstd-team/fake-cli/journal"
+	"github.com/paqstd-team/fake-cli/record"
+)
+
+// shared is the state every endpoint of a server uses.
+type shared struct {
+	gen       *generator
+	seeds     *seedSource
+	scenarios *scenarioStore
+	// globalFault applies to endpoints without a fault of their own
+	globalFault *faultSwitch
+	// upstream is the fallback backend, nil without one
+	upstream *url.URL
+	// validation is the config's validation mode for payload templates
+	validation string
+	// errors configures the responses sent when a request cannot be served
+	errors config.Errors
+	// dir is where relative body files are, see config.Config.Dir
+	dir string
+}
+
+// bodyPath returns the path of a body file.
+func (s *shared) bodyPath(file string) string {
+	if filepath.IsAbs(file) {
+		return file
+	}
+	return filepath.Join(s.dir, file)
+}
+
+// server routes requests to its endpoints, which can be added and removed at
+// runtime through the admin API.
+type server struct {
+	*shared
+	config config.Config
+	// journal is nil when disabled
+	journal *journal.Journal
+	// proxy forwards unmatched requests, nil without a fallback upstream
+	proxy *httputil.ReverseProxy
+	// recorder is nil unless the fallback records responses
+	recorder *record.Recorder
+
+	mutex     sync.RWMutex
+	router    *mux.Router
+	endpoints []*endpointHandler
+}
+
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mutex.RLock()
+	router := s.router
+	s.mutex.RUnlock()
+
+	if s.journal == nil || s.isAdmin(r) {
+		router.ServeHTTP(w, r)
+		return
+	}
+	s.record(w, r, router)
+}
+
+// Close closes the journal file. The server keeps serving, with requests only
+// recorded in memory.
+func (s *server) Close() error {
+	if s.journal == nil {
+		return nil
+	}
+	return s.journal.Close()
+}
+
+// isAdmin reports whether the request goes to the admin API.
+func (s *server) isAdmin(r *http.Request) bool {
+	return s.config.Admin.Covers(r.URL.Path)
+}
+
+// route rebuilds the router from the current endpoints. The caller holds the
+// write lock unless the server is not serving yet.
+func (s *server) route() {
+	router := mux.NewRouter()
+	// Admin routes go first so that no endpoint can shadow them
+	if !s.config.Admin.Disabled {
+		s.registerAdmin(router)
+	}
+	for _, h := range s.endpoints {
+		router.Handle(h.endpoint.URL, h).Methods(h.method)
+	}
+	// Registered last so that an endpoint can serve the path instead
+	router.HandleFunc(openAPIPath, s.serveOpenAPI).Methods(http.MethodGet)
+	router.NotFoundHandler = http.HandlerFunc(s.notFound)
+	router.MethodNotAllowedHandler = http.HandlerFunc(s.methodNotAllowed)
+	if s.proxy != nil {
+		router.NotFoundHandler = http.HandlerFunc(s.fallback)
+		router.MethodNotAllowedHandler = http.HandlerFunc(s.fallback)
+	}
+	s.router = router
+}
+
+// handlers returns the current endpoints.
+func (s *server) handlers() []*endpointHandler {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return slices.Clone(s.endpoints)
+}
+
+// find returns the endpoint registered for method and url template, or nil.
+func (s *server) find(method, url string) *endpointHandler {
+	if method == "" {
+		method = http.MethodGet
+	}
+	for _, h := range s.handlers() {
+		if h.method == method && h.endpoint.URL == url {
+			return h
+		}
+	}
+	return nil
+}
+
+// scenarioStates returns the states every scenario can be in with handlers,
+// see config.ScenarioStates.
+func (s *server) scenarioStates(handlers []*endpointHandler) map[string]map[string]bool {
+	endpoints := make([]config.Endpoint, len(handlers))
+	for i, h := range handlers {
+		endpoints[i] = h.endpoint
+	}
+	return config.ScenarioStates(s.config.Scenarios, endpoints)
+}
+
+// addEndpoint validates endpoint and serves it, replacing an endpoint with the
+// same method and url. The endpoint is validated under the write lock, so the
+// states it is checked against can't change before it is served.
+func (s *server) addEndpoint(endpoint config.Endpoint) (*endpointHandler, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// The states of the other endpoints are valid targets as well
+	states := s.scenarioStates(s.endpoints)
+	scenarios := slices.Clone(s.config.Scenarios)
+	for i, scenario := range scenarios {
+		scenarios[i].States = slices.Sorted(maps.Keys(states[scenario.Name]))
+	}
+	if err := config.Validate(config.Config{Admin: s.config.Admin, Scenarios: scenarios, Fallback: s.config.Fallback, Validation: s.config.Validation, Endpoints: []config.Endpoint{endpoint}}); err != nil {
+		return nil, err
+	}
+	h := newEndpointHandler(endpoint, s.shared)
+	s.endpoints = slices.DeleteFunc(s.endpoints, func(old *endpointHandler) bool {
+		return old.method == h.method && old.endpoint.URL == h.endpoint.URL
+	})
+	s.endpoints = append(s.endpoints, h)
+	s.route()
+	return h, nil
+}
+
+// removeEndpoint stops serving the endpoint registered for method and url.
+func (s *server) removeEndpoint(method, url string) error {
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	count := len(s.endpoints)
+	s.endpoints = slices.DeleteFunc(s.endpoints, func(h *endpointHandler) bool {
+		return h.method == method && h.endpoint.URL == url
+	})
+	if len(s.endpoints) == count {
+		return fmt.Errorf("unknown endpoint %s %s", method, url)
+	}
+	s.route()
+	return nil
+}
+
+// reset clears the runtime state of every endpoint: caches, sequences,
+// scenario states and variants chosen through the admin API.
+func (s *server) reset() {
+	for _, h := range s.handlers() {
+		h.reset()
+	}
+	// Resetting every scenario cannot fail
+	_ = s.scenarios.reset("", nil)
+}
@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/paqstd-team/fake-cli/config"
+)
+
+func TestServer_AdminPrefix(t *testing.T) {
+	tests := []struct {
+		name           string
+		admin          config.Admin
+		target         string
+		expectedStatus int
+	}{
+		{name: "default_prefix", target: "/__admin/endpoints", expectedStatus: http.StatusOK},
+		{name: "custom_prefix", admin: config.Admin{Prefix: "/_mock"}, target: "/_mock/endpoints", expectedStatus: http.StatusOK},
+		{name: "custom_prefix_frees_default", admin: config.Admin{Prefix: "/_mock"}, target: "/__admin/endpoints", expectedStatus: http.StatusNotFound},
+		{name: "disabled", admin: config.Admin{Disabled: true}, target: "/__admin/endpoints", expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := MakeHandler(config.Config{Admin: tt.admin})
+
+			if w := serve(handler, tt.target, nil); w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+		})
+	}
+}
+
+func TestServer_AdminShadowsEndpoints(t *testing.T) {
+	handler := MakeHandler(config.Config{Endpoints: []config.Endpoint{
+		{URL: "/__admin/endpoints", Response: map[string]any{"shadowed": true}},
+	}})
+
+	if w := serve(handler, "/__admin/endpoints", nil); w.Body.String() == `{"shadowed":true}` {
+		t.Error("Expected admin route to take precedence over endpoint")
+	}
+}
+
+func TestServer_ConcurrentChanges(t *testing.T) {
+	handler := MakeHandler(config.Config{Endpoints: []config.Endpoint{
+		{URL: "/stable", Response: map[string]any{"ok": true}},
+	}})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			adminRequest(handler, http.MethodPost, "/__admin/endpoints", `{"url": "/dynamic", "response": {"ok": true}}`)
+		}()
+		go func() {
+			defer wg.Done()
+			if w := serve(handler, "/stable", nil); w.Code != http.StatusOK {
+				t.Errorf("Expected status 200, got %d", w.Code)
+			}
+		}()
+	}
+	wg.Wait()
+}
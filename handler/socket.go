@@ -0,0 +1,166 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/paqstd-team/fake-cli/config"
+)
+
+// closeTimeout bounds the time spent writing a close frame.
+const closeTimeout = time.Second
+
+// socket is an open WebSocket connection of an endpoint. Pushes, replies and
+// closes write from their own goroutines, so writes and the generator, which
+// is not safe for concurrent use, are guarded by mutex.
+type socket struct {
+	conn  *websocket.Conn
+	mutex sync.Mutex
+	gen   *generator
+	// cancel stops the pushes, closes and reply delays still waiting
+	cancel context.CancelFunc
+}
+
+// serveSocket upgrades the request to a WebSocket and serves it until either
+// side closes the connection.
+func (h *endpointHandler) serveSocket(w http.ResponseWriter, r *http.Request) {
+	seed, seedOverride, err := requestSeed(r)
+	if err != nil {
+		h.fail(w, r, http.StatusBadRequest, fmt.Sprintf("Invalid seed: %v", err))
+		return
+	}
+	if !seedOverride {
+		seed = h.seed(seedData)
+	}
+	upgrader := websocket.Upgrader{
+		// Mocks are called from any origin
+		CheckOrigin: func(*http.Request) bool { return true },
+		Error: func(w http.ResponseWriter, r *http.Request, status int, reason error) {
+			h.fail(w, r, status, reason.Error())
+		},
+	}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// The upgrader has answered with the problem
+		return
+	}
+	defer conn.Close()
+
+	// Pushes and closes are cancelled, then stop before the handler returns.
+	// The connection outlives the request, so it has its own context.
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s := &socket{conn: conn, gen: h.gen.withFaker(newFaker(seed)), cancel: cancel}
+	for _, push := range h.endpoint.Socket.Push {
+		wg.Go(func() { s.push(ctx, push) })
+	}
+	if c := h.endpoint.Socket.Close; c != nil {
+		wg.Go(func() { s.closeAfter(ctx, *c) })
+	}
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		if !h.answer(ctx, &wg, s, r, message) {
+			return
+		}
+	}
+}
+
+// answer sends the first socket reply that matches the message and reports
+// whether the connection is still usable. Messages that aren't JSON are
+// matched as a string.
+func (h *endpointHandler) answer(ctx context.Context, wg *sync.WaitGroup, s *socket, r *http.Request, message []byte) bool {
+	var body any
+	if json.Unmarshal(message, &body) != nil {
+		body = string(message)
+	}
+	for i, reply := range h.endpoint.Socket.Replies {
+		if !h.socketReplies[i].matches(r, body) {
+			continue
+		}
+		if reply.PayloadSchema != nil && len(reply.PayloadSchema.Validate(body)) > 0 {
+			continue
+		}
+		// Delays hold back reading, so replies keep the order of the messages.
+		// A close while waiting ends the connection.
+		if !sleep(ctx, reply.Delay) {
+			return false
+		}
+		if reply.Message != nil && s.send(reply.Message) != nil {
+			return false
+		}
+		if reply.Close != nil {
+			wg.Go(func() { s.closeAfter(ctx, *reply.Close) })
+		}
+		return true
+	}
+	return true
+}
+
+// push sends the messages of a push until its count is reached or the
+// connection closes.
+func (s *socket) push(ctx context.Context, push config.Push) {
+	limit := push.Count
+	if limit == 0 && push.Interval == 0 {
+		limit = 1
+	}
+	if !sleep(ctx, push.Delay) {
+		return
+	}
+	for sent := 0; limit == 0 || sent < limit; sent++ {
+		if sent > 0 && !sleep(ctx, push.Interval) {
+			return
+		}
+		if s.send(push.Message) != nil {
+			return
+		}
+	}
+}
+
+// send writes a message generated from template: strings as they are, any
+// other data as JSON. Data that can't be encoded closes the connection with
+// an internal error.
+func (s *socket) send(template any) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	data := s.gen.generateData(template)
+	if text, ok := data.(string); ok {
+		return s.conn.WriteMessage(websocket.TextMessage, []byte(text))
+	}
+	encoded, err := JSONMarshal(data)
+	if err != nil {
+		s.writeClose(config.SocketClose{Code: websocket.CloseInternalServerErr, Reason: "Error generating JSON"})
+		return err
+	}
+	return s.conn.WriteMessage(websocket.TextMessage, encoded)
+}
+
+// closeAfter closes the connection once the delay of c has passed, unless
+// the connection closes first.
+func (s *socket) closeAfter(ctx context.Context, c config.SocketClose) {
+	if !sleep(ctx, c.After) {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.writeClose(c)
+}
+
+// writeClose sends a close frame and closes the connection without waiting
+// for the client to answer, then cancels what is still waiting to write. The
+// caller holds the mutex.
+func (s *socket) writeClose(c config.SocketClose) {
+	s.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(c.Code, c.Reason), time.Now().Add(closeTimeout))
+	s.conn.Close()
+	s.cancel()
+}
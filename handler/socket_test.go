@@ -0,0 +1,309 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/paqstd-team/fake-cli/config"
+	"github.com/paqstd-team/fake-cli/jsonschema"
+)
+
+// socketServer serves the endpoint at /ws. done receives a value whenever a
+// request has been served, so tests can wait for connections to wind down.
+func socketServer(t *testing.T, socket config.Socket) (url string, done chan struct{}) {
+	t.Helper()
+	handler := MakeHandler(config.Config{Endpoints: []config.Endpoint{{
+		URL:      "/ws",
+		Response: map[string]any{"transport": "http"},
+		Socket:   &socket,
+	}}})
+	done = make(chan struct{}, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler.ServeHTTP(w, r)
+		done <- struct{}{}
+	}))
+	t.Cleanup(server.Close)
+	return "ws" + strings.TrimPrefix(server.URL, "http") + "/ws", done
+}
+
+func dial(t *testing.T, url string) *websocket.Conn {
+	t.Helper()
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func readMessage(t *testing.T, conn *websocket.Conn) string {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, message, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("Expected a message, got %v", err)
+	}
+	return string(message)
+}
+
+func expectClose(t *testing.T, conn *websocket.Conn, code int, reason string) {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, message, err := conn.ReadMessage()
+	var closeErr *websocket.CloseError
+	if !errors.As(err, &closeErr) {
+		t.Fatalf("Expected a close, got %q, %v", message, err)
+	}
+	if closeErr.Code != code || closeErr.Text != reason {
+		t.Errorf("Expected close %d %q, got %d %q", code, reason, closeErr.Code, closeErr.Text)
+	}
+}
+
+func wait(t *testing.T, done chan struct{}) {
+	t.Helper()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the connection to be served to the end")
+	}
+}
+
+func TestSocket_Push(t *testing.T) {
+	t.Run("count", func(t *testing.T) {
+		url, _ := socketServer(t, config.Socket{Push: []config.Push{{Message: map[string]any{"id": "uuid"}, Interval: config.Duration(time.Millisecond), Count: 2}}})
+		conn := dial(t, url)
+
+		ids := map[string]bool{}
+		for range 2 {
+			var message map[string]string
+			if err := json.Unmarshal([]byte(readMessage(t, conn)), &message); err != nil || len(message["id"]) != 36 {
+				t.Fatalf("Expected a generated id, got %v", message)
+			}
+			ids[message["id"]] = true
+		}
+		if len(ids) != 2 {
+			t.Errorf("Expected a new message every time, got %v", ids)
+		}
+	})
+
+	t.Run("once_without_interval", func(t *testing.T) {
+		url, _ := socketServer(t, config.Socket{
+			Push:    []config.Push{{Message: "welcome"}},
+			Replies: []config.SocketReply{{Message: "pong"}},
+		})
+		conn := dial(t, url)
+
+		if got := readMessage(t, conn); got != "welcome" {
+			t.Errorf("Expected welcome, got %q", got)
+		}
+		// A reply comes next, not a second push
+		conn.WriteMessage(websocket.TextMessage, []byte("ping"))
+		if got := readMessage(t, conn); got != "pong" {
+			t.Errorf("Expected pong, got %q", got)
+		}
+	})
+
+	t.Run("forever_with_interval", func(t *testing.T) {
+		url, done := socketServer(t, config.Socket{Push: []config.Push{{Message: "tick", Interval: config.Duration(time.Millisecond)}}})
+		conn := dial(t, url)
+		for range 3 {
+			if got := readMessage(t, conn); got != "tick" {
+				t.Errorf("Expected tick, got %q", got)
+			}
+		}
+		conn.Close()
+		wait(t, done)
+	})
+
+	t.Run("stops_on_disconnect", func(t *testing.T) {
+		url, done := socketServer(t, config.Socket{
+			Push: []config.Push{
+				{Message: "first", Interval: config.Duration(time.Hour), Count: 2},
+				{Message: "later", Delay: config.Duration(time.Hour)},
+			},
+			Close: &config.SocketClose{Code: websocket.CloseNormalClosure, After: config.Duration(time.Hour)},
+		})
+		conn := dial(t, url)
+		if got := readMessage(t, conn); got != "first" {
+			t.Errorf("Expected first, got %q", got)
+		}
+		conn.Close()
+		wait(t, done)
+	})
+}
+
+func TestSocket_Replies(t *testing.T) {
+	url, _ := socketServer(t, config.Socket{Replies: []config.SocketReply{
+		{
+			When:    config.Match{Body: map[string]config.Condition{"$.type": {Equals: "ping"}}},
+			Message: map[string]any{"type": "pong", "id": "uuid"},
+			Delay:   config.Duration(time.Millisecond),
+		},
+		{
+			When:    config.Match{Query: map[string]config.Condition{"room": {Equals: "a"}}, Body: map[string]config.Condition{"$": {Regex: "^hello"}}},
+			Message: "hello room a",
+		},
+		{
+			When:    config.Match{Body: map[string]config.Condition{"$": {Regex: "^hello"}}},
+			Message: "hello",
+		},
+		{
+			PayloadSchema: &jsonschema.Schema{Type: jsonschema.Types{"object"}, Required: []string{"bye"}},
+			Close:         &config.SocketClose{Code: 4001, Reason: "bye"},
+		},
+	}})
+
+	conn := dial(t, url)
+	conn.WriteMessage(websocket.TextMessage, []byte(`{"type": "ping"}`))
+	var pong map[string]string
+	if err := json.Unmarshal([]byte(readMessage(t, conn)), &pong); err != nil || pong["type"] != "pong" || len(pong["id"]) != 36 {
+		t.Errorf("Expected a generated pong, got %v", pong)
+	}
+
+	// Unmatched messages are not answered
+	conn.WriteMessage(websocket.TextMessage, []byte(`{"type": "other"}`))
+	conn.WriteMessage(websocket.TextMessage, []byte("hello there"))
+	if got := readMessage(t, conn); got != "hello" {
+		t.Errorf("Expected hello, got %q", got)
+	}
+
+	conn.WriteMessage(websocket.TextMessage, []byte(`{"bye": true}`))
+	expectClose(t, conn, 4001, "bye")
+
+	// Conditions on the handshake request
+	room := dial(t, url+"?room=a")
+	room.WriteMessage(websocket.TextMessage, []byte("hello"))
+	if got := readMessage(t, room); got != "hello room a" {
+		t.Errorf("Expected hello room a, got %q", got)
+	}
+}
+
+func TestSocket_Close(t *testing.T) {
+	url, done := socketServer(t, config.Socket{Close: &config.SocketClose{Code: 4000, Reason: "maintenance", After: config.Duration(time.Millisecond)}})
+	conn := dial(t, url)
+	expectClose(t, conn, 4000, "maintenance")
+	wait(t, done)
+}
+
+func TestSocket_CloseDuringReplyDelay(t *testing.T) {
+	url, done := socketServer(t, config.Socket{
+		Replies: []config.SocketReply{{Message: "late", Delay: config.Duration(time.Minute)}},
+		Close:   &config.SocketClose{Code: 4000, Reason: "maintenance", After: config.Duration(50 * time.Millisecond)},
+	})
+	conn := dial(t, url)
+	conn.WriteMessage(websocket.TextMessage, []byte("hi"))
+	expectClose(t, conn, 4000, "maintenance")
+	// The delayed reply is abandoned instead of holding the connection open
+	wait(t, done)
+}
+
+func TestSocket_MarshalError(t *testing.T) {
+	original := JSONMarshal
+	JSONMarshal = func(v any) ([]byte, error) { return nil, errors.New("marshal error") }
+	defer func() { JSONMarshal = original }()
+
+	t.Run("push", func(t *testing.T) {
+		url, done := socketServer(t, config.Socket{Push: []config.Push{{Message: map[string]any{"id": "uuid"}}}})
+		conn := dial(t, url)
+		expectClose(t, conn, websocket.CloseInternalServerErr, "Error generating JSON")
+		wait(t, done)
+	})
+
+	t.Run("reply", func(t *testing.T) {
+		url, done := socketServer(t, config.Socket{Replies: []config.SocketReply{{Message: []any{"uuid"}}}})
+		conn := dial(t, url)
+		conn.WriteMessage(websocket.TextMessage, []byte("hi"))
+		expectClose(t, conn, websocket.CloseInternalServerErr, "Error generating JSON")
+		wait(t, done)
+	})
+}
+
+func TestSocket_Handshake(t *testing.T) {
+	upgrade := http.Header{"Connection": {"Upgrade"}, "Upgrade": {"websocket"}}
+	handshake := upgrade.Clone()
+	handshake.Set("Sec-WebSocket-Version", "13")
+	handshake.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+
+	tests := []struct {
+		name           string
+		target         string
+		header         http.Header
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "plain_request",
+			target:         "/ws",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"transport":"http"}`,
+		},
+		{
+			name:           "invalid_handshake",
+			target:         "/ws",
+			header:         upgrade,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "websocket: unsupported version",
+		},
+		{
+			name:           "invalid_seed",
+			target:         "/ws?_seed=abc",
+			header:         handshake,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "Invalid seed",
+		},
+		{
+			name:           "connection_cannot_be_hijacked",
+			target:         "/ws",
+			header:         handshake,
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   "feature not supported",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := MakeHandler(config.Config{Endpoints: []config.Endpoint{{
+				URL:      "/ws",
+				Response: map[string]any{"transport": "http"},
+				Socket:   &config.Socket{},
+			}}})
+
+			w := serve(handler, tt.target, tt.header)
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if !strings.Contains(w.Body.String(), tt.expectedBody) {
+				t.Errorf("Expected body containing %q, got %q", tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestSocket_Journal(t *testing.T) {
+	handler := MakeHandler(config.Config{Endpoints: []config.Endpoint{{URL: "/ws", Socket: &config.Socket{}}}})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws", nil)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	conn.Close()
+
+	// The request is recorded once the connection is closed
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		w := adminRequest(handler, http.MethodGet, "/__admin/requests?path=/ws", "")
+		if strings.Contains(w.Body.String(), `"status":101`) {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Error("Expected the WebSocket request in the journal with status 101")
+}
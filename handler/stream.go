@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/paqstd-team/fake-cli/config"
+)
+
+// streams reports whether the reply is sent as Server-Sent Events: the
+// endpoint streams and the reply is a successful generated response.
+func (h *endpointHandler) streams(reply config.Reply, status int) bool {
+	return h.endpoint.Stream != nil && status == http.StatusOK && !hasBody(reply)
+}
+
+// serveStream writes an event generated from the reply's response every
+// interval until the count is reached or the client disconnects. Event ids
+// count up from 1, or from the Last-Event-ID header of a reconnecting client,
+// which gets 204 No Content once it has seen every event. It reports whether
+// the stream was served, see respond.
+func (h *endpointHandler) serveStream(w http.ResponseWriter, r *http.Request, reply config.Reply, gen *generator) bool {
+	stream := h.endpoint.Stream
+	id := 0
+	if last := r.Header.Get("Last-Event-ID"); last != "" {
+		var err error
+		if id, err = strconv.Atoi(last); err != nil || id < 0 {
+			h.fail(w, r, http.StatusBadRequest, "Invalid Last-Event-ID header")
+			return false
+		}
+	}
+	// 204 tells a reconnecting EventSource that the stream has ended
+	if stream.Count > 0 && id >= stream.Count {
+		w.Header().Del("Content-Type")
+		w.WriteHeader(http.StatusNoContent)
+		return true
+	}
+	interval := stream.Interval
+	if interval == 0 {
+		interval = config.DefaultStreamInterval
+	}
+
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	controller := http.NewResponseController(w)
+	if stream.Retry > 0 {
+		fmt.Fprintf(w, "retry: %d\n\n", time.Duration(stream.Retry).Milliseconds())
+	}
+	for sent := 0; stream.Count == 0 || id < stream.Count; sent++ {
+		if sent > 0 && !sleep(r.Context(), interval) {
+			return true
+		}
+		data, err := JSONMarshal(gen.generateData(reply.Response))
+		if err != nil {
+			fmt.Fprintf(w, ": error generating JSON: %v\n\n", err)
+			return true
+		}
+		id++
+		var event bytes.Buffer
+		fmt.Fprintf(&event, "id: %d\n", id)
+		if stream.Event != "" {
+			fmt.Fprintf(&event, "event: %s\n", stream.Event)
+		}
+		fmt.Fprintf(&event, "data: %s\n\n", data)
+		w.Write(event.Bytes())
+		controller.Flush()
+	}
+	return true
+}
@@ -0,0 +1,192 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/paqstd-team/fake-cli/config"
+)
+
+// event is a parsed Server-Sent Event.
+type event struct {
+	id, name, data string
+}
+
+func parseEvents(body string) (retry string, events []event) {
+	for _, block := range strings.Split(strings.TrimSpace(body), "\n\n") {
+		var e event
+		for _, line := range strings.Split(block, "\n") {
+			field, value, _ := strings.Cut(line, ": ")
+			switch field {
+			case "retry":
+				retry = value
+			case "id":
+				e.id = value
+			case "event":
+				e.name = value
+			case "data":
+				e.data = value
+			}
+		}
+		if e.id != "" {
+			events = append(events, e)
+		}
+	}
+	return retry, events
+}
+
+func TestStream_Serve(t *testing.T) {
+	tests := []struct {
+		name           string
+		endpoint       config.Endpoint
+		target         string
+		header         http.Header
+		expectedStatus int
+		expectedRetry  string
+		expectedIDs    []string
+		expectedEvent  string
+	}{
+		{
+			name:           "count",
+			endpoint:       config.Endpoint{Response: map[string]any{"id": "uuid"}, Stream: &config.Stream{Count: 3, Interval: config.Duration(time.Millisecond)}},
+			expectedStatus: http.StatusOK,
+			expectedIDs:    []string{"1", "2", "3"},
+		},
+		{
+			name:           "event_and_retry",
+			endpoint:       config.Endpoint{Response: map[string]any{"id": "uuid"}, Stream: &config.Stream{Count: 1, Event: "tick", Retry: config.Duration(3 * time.Second)}},
+			expectedStatus: http.StatusOK,
+			expectedRetry:  "3000",
+			expectedIDs:    []string{"1"},
+			expectedEvent:  "tick",
+		},
+		{
+			name:           "resume",
+			endpoint:       config.Endpoint{Response: map[string]any{"id": "uuid"}, Stream: &config.Stream{Count: 7, Interval: config.Duration(time.Millisecond)}},
+			header:         http.Header{"Last-Event-Id": {"5"}},
+			expectedStatus: http.StatusOK,
+			expectedIDs:    []string{"6", "7"},
+		},
+		{
+			name:           "resume_after_last",
+			endpoint:       config.Endpoint{Response: map[string]any{"id": "uuid"}, Stream: &config.Stream{Count: 2}},
+			header:         http.Header{"Last-Event-Id": {"2"}},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:           "resume_past_last",
+			endpoint:       config.Endpoint{Response: map[string]any{"id": "uuid"}, Stream: &config.Stream{Count: 2}},
+			header:         http.Header{"Last-Event-Id": {"9"}},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:           "invalid_last_event_id",
+			endpoint:       config.Endpoint{Response: map[string]any{"id": "uuid"}, Stream: &config.Stream{Count: 2}},
+			header:         http.Header{"Last-Event-Id": {"-1"}},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "other_status_is_not_streamed",
+			endpoint: config.Endpoint{
+				Response: map[string]any{"id": "uuid"},
+				Stream:   &config.Stream{Count: 2},
+				Cases:    []config.Case{{When: config.Match{Query: map[string]config.Condition{"fail": {Exists: boolPtr(true)}}}, Reply: config.Reply{Status: http.StatusServiceUnavailable, Response: map[string]any{"error": "word"}}}},
+			},
+			target:         "/events?fail=1",
+			expectedStatus: http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			endpoint := tt.endpoint
+			endpoint.URL = "/events"
+			handler := MakeHandler(config.Config{Endpoints: []config.Endpoint{endpoint}})
+
+			target := "/events"
+			if tt.target != "" {
+				target = tt.target
+			}
+			w := serve(handler, target, tt.header)
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+			if got := w.Header().Get("Content-Type"); got != "text/event-stream" {
+				t.Errorf("Expected content type text/event-stream, got %s", got)
+			}
+			retry, events := parseEvents(w.Body.String())
+			if retry != tt.expectedRetry {
+				t.Errorf("Expected retry %q, got %q", tt.expectedRetry, retry)
+			}
+			if len(events) != len(tt.expectedIDs) {
+				t.Fatalf("Expected %d events, got %d: %q", len(tt.expectedIDs), len(events), w.Body.String())
+			}
+			for i, e := range events {
+				if e.id != tt.expectedIDs[i] {
+					t.Errorf("Expected id %s, got %s", tt.expectedIDs[i], e.id)
+				}
+				if e.name != tt.expectedEvent {
+					t.Errorf("Expected event %q, got %q", tt.expectedEvent, e.name)
+				}
+				var data map[string]any
+				if err := json.Unmarshal([]byte(e.data), &data); err != nil || data["id"] == "uuid" {
+					t.Errorf("Expected generated JSON data, got %q", e.data)
+				}
+			}
+		})
+	}
+}
+
+func TestStream_UntilDisconnect(t *testing.T) {
+	handler := MakeHandler(config.Config{Endpoints: []config.Endpoint{{
+		URL:      "/events",
+		Response: map[string]any{"name": "first_name"},
+		Stream:   &config.Stream{Interval: config.Duration(time.Millisecond)},
+	}}})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/events", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer resp.Body.Close()
+
+	// Events arrive while the stream is open, so they are flushed
+	scanner := bufio.NewScanner(resp.Body)
+	var ids []string
+	for len(ids) < 3 && scanner.Scan() {
+		if id, ok := strings.CutPrefix(scanner.Text(), "id: "); ok {
+			ids = append(ids, id)
+		}
+	}
+	if strings.Join(ids, ",") != "1,2,3" {
+		t.Errorf("Expected ids 1,2,3, got %v", ids)
+	}
+	cancel()
+}
+
+func TestStream_MarshalError(t *testing.T) {
+	original := JSONMarshal
+	JSONMarshal = func(v any) ([]byte, error) { return nil, errors.New("marshal error") }
+	defer func() { JSONMarshal = original }()
+
+	handler := MakeHandler(config.Config{Endpoints: []config.Endpoint{{URL: "/events", Response: "word", Stream: &config.Stream{Count: 2}}}})
+	w := serve(handler, "/events", nil)
+	if w.Body.String() != ": error generating JSON: marshal error\n\n" {
+		t.Errorf("Expected an error comment, got %q", w.Body.String())
+	}
+}
@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/paqstd-team/fake-cli/config"
+)
+
+// strictModeNotes lists bare strings in response templates that equal a type name.
+// Outside strict mode they used to be substituted, in strict mode they are returned
+// as is, so each one is reported to help migrating configs.
+func strictModeNotes(cfg config.Config) []string {
+	var notes []string
+	for _, template := range configTemplates(cfg) {
+		for _, path := range ambiguousStrings(template.value, template.location) {
+			notes = append(notes, fmt.Sprintf("strict mode: %s %q is returned literally, use \"$%s\" to generate it or \"=%s\" to silence this note",
+				path.location, path.value, path.value, path.value))
+		}
+	}
+	return notes
+}
+
+// locatedTemplate is a response or message template and where it is in the config.
+type locatedTemplate struct {
+	location string
+	value    any
+}
+
+// configTemplates lists every template data is generated from: the replies of
+// the config and of its endpoints, and the messages of their sockets.
+func configTemplates(cfg config.Config) []locatedTemplate {
+	var templates []locatedTemplate
+	reply := func(location string, reply config.Reply) {
+		templates = append(templates, locatedTemplate{location: location + "response", value: reply.Response})
+	}
+	if cfg.Errors.NotFound != nil {
+		reply("errors.not_found.", *cfg.Errors.NotFound)
+	}
+	if cfg.Errors.MethodNotAllowed != nil {
+		reply("errors.method_not_allowed.", *cfg.Errors.MethodNotAllowed)
+	}
+	if cfg.Fault != nil {
+		reply("fault.", cfg.Fault.Reply)
+	}
+	for _, endpoint := range cfg.Endpoints {
+		method := endpoint.Type
+		if method == "" {
+			method = "GET"
+		}
+		prefix := method + " " + endpoint.URL + " "
+		reply(prefix, endpoint.Reply())
+		for i, c := range endpoint.Cases {
+			reply(fmt.Sprintf("%scases[%d].", prefix, i), c.Reply)
+		}
+		for _, name := range slices.Sorted(maps.Keys(endpoint.Variants)) {
+			reply(prefix+"variants."+name+".", endpoint.Variants[name])
+		}
+		for _, name := range slices.Sorted(maps.Keys(endpoint.States)) {
+			reply(prefix+"states."+name+".", endpoint.States[name].Reply)
+		}
+		for i, step := range endpoint.Sequence {
+			reply(fmt.Sprintf("%ssequence[%d].", prefix, i), step)
+		}
+		if endpoint.Fault != nil {
+			reply(prefix+"fault.", endpoint.Fault.Reply)
+		}
+		if socket := endpoint.Socket; socket != nil {
+			for i, push := range socket.Push {
+				templates = append(templates, locatedTemplate{location: fmt.Sprintf("%ssocket.push[%d].message", prefix, i), value: push.Message})
+			}
+			for i, r := range socket.Replies {
+				templates = append(templates, locatedTemplate{location: fmt.Sprintf("%ssocket.replies[%d].message", prefix, i), value: r.Message})
+			}
+		}
+	}
+	return templates
+}
+
+type ambiguousString struct {
+	location string
+	value    string
+}
+
+func ambiguousStrings(template any, location string) []ambiguousString {
+	var found []ambiguousString
+	switch t := template.(type) {
+	case string:
+		if !strings.HasPrefix(t, typePrefix) && !strings.HasPrefix(t, literalPrefix) && isFakeType(t) {
+			found = append(found, ambiguousString{location: location, value: t})
+		}
+	case map[string]any:
+		// The arguments of directives are patterns, masks, bounds and
+		// literals, never data types
+		if isDirective(t) {
+			return nil
+		}
+		for _, key := range slices.Sorted(maps.Keys(t)) {
+			found = append(found, ambiguousStrings(t[key], location+"."+key)...)
+		}
+	case []any:
+		for i, item := range t {
+			found = append(found, ambiguousStrings(item, fmt.Sprintf("%s[%d]", location, i))...)
+		}
+	}
+	return found
+}
+
+// isDirective reports whether fields is a single-key directive object such as
+// {"$regex": "..."}.
+func isDirective(fields map[string]any) bool {
+	if len(fields) != 1 {
+		return false
+	}
+	for key := range fields {
+		switch key {
+		case regexDirective, formatDirective, rangeDirective, literalDirective:
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,205 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/paqstd-team/fake-cli/config"
+)
+
+func TestStrict_LiteralsAndPrefixes(t *testing.T) {
+	uuidPattern := regexp.MustCompile(`^[0-9a-f-]{36}$`)
+
+	tests := []struct {
+		name   string
+		strict bool
+		value  any
+		check  func(v any) bool
+	}{
+		{
+			name:  "bare_type_name_is_generated",
+			value: "uuid",
+			check: func(v any) bool { s, _ := v.(string); return uuidPattern.MatchString(s) },
+		},
+		{
+			name:  "prefixed_type_name_is_generated",
+			value: "$uuid",
+			check: func(v any) bool { s, _ := v.(string); return uuidPattern.MatchString(s) },
+		},
+		{
+			name:  "prefixed_unknown_name_is_literal",
+			value: "$5.00",
+			check: func(v any) bool { return v == "$5.00" },
+		},
+		{
+			name:  "equals_sign_is_kept",
+			value: "=word",
+			check: func(v any) bool { return v == "=word" },
+		},
+		{
+			name:   "strict_literal_marker",
+			strict: true,
+			value:  "=word",
+			check:  func(v any) bool { return v == "word" },
+		},
+		{
+			name:   "strict_literal_marker_wins_over_tokens",
+			strict: true,
+			value:  "={{uuid}}",
+			check:  func(v any) bool { return v == "{{uuid}}" },
+		},
+		{
+			name:  "literal_directive_string",
+			value: map[string]any{"$literal": "name"},
+			check: func(v any) bool { return v == "name" },
+		},
+		{
+			name:  "literal_directive_object",
+			value: map[string]any{"$literal": map[string]any{"role": "name"}},
+			check: func(v any) bool {
+				m, _ := v.(map[string]any)
+				return m["role"] == "name"
+			},
+		},
+		{
+			name:   "strict_bare_type_name_is_literal",
+			strict: true,
+			value:  "word",
+			check:  func(v any) bool { return v == "word" },
+		},
+		{
+			name:   "strict_prefixed_type_name_is_generated",
+			strict: true,
+			value:  "$uuid",
+			check:  func(v any) bool { s, _ := v.(string); return uuidPattern.MatchString(s) },
+		},
+		{
+			name:   "strict_tokens_are_generated",
+			strict: true,
+			value:  "id-{{uuid}}",
+			check:  func(v any) bool { s, _ := v.(string); return uuidPattern.MatchString(strings.TrimPrefix(s, "id-")) },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen := &generator{strict: tt.strict, faker: newFaker(1)}
+			if v := gen.generateData(tt.value); !tt.check(v) {
+				t.Errorf("Unexpected value %v for %v", v, tt.value)
+			}
+		})
+	}
+}
+
+func TestStrict_Handler(t *testing.T) {
+	cfg := config.Config{
+		Strict: true,
+		Endpoints: []config.Endpoint{
+			{
+				URL: "/api/users/{id}",
+				Response: map[string]any{
+					"id":     "$uuid",
+					"status": "word",
+					"role":   "name",
+				},
+			},
+		},
+	}
+	handler := MakeHandler(cfg)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/users/1", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	var response map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if response["status"] != "word" || response["role"] != "name" {
+		t.Errorf("Expected literal values in strict mode, got %v", response)
+	}
+	if response["id"] == "$uuid" {
+		t.Error("Expected $uuid to be generated in strict mode")
+	}
+}
+
+func TestStrict_ModeNotes(t *testing.T) {
+	cfg := config.Config{
+		Strict: true,
+		Endpoints: []config.Endpoint{
+			{
+				URL: "/api/users",
+				Response: []any{
+					map[string]any{
+						"id":      "$uuid",
+						"status":  "word",
+						"label":   "=name",
+						"custom":  "not-a-type",
+						"literal": map[string]any{"$literal": "city"},
+						"pattern": map[string]any{"$regex": "name"},
+						"mask":    map[string]any{"$format": "word"},
+						"range":   map[string]any{"$range": []any{1, 5}},
+						"nested":  map[string]any{"$regex": "name", "city": "city"},
+						"tags":    []any{"color"},
+					},
+				},
+			},
+			{
+				URL:      "/api/users",
+				Type:     http.MethodPost,
+				Response: "email",
+				Cases:    []config.Case{{Reply: config.Reply{Response: map[string]any{"a": "city"}}}},
+				Variants: map[string]config.Reply{"slow": {Response: map[string]any{"b": "city"}}},
+				States:   map[string]config.State{"done": {Reply: config.Reply{Response: map[string]any{"c": "city"}}}},
+				Sequence: []config.Reply{{Response: map[string]any{"d": "city"}}},
+				Fault:    &config.Fault{Reply: config.Reply{Response: map[string]any{"e": "city"}}},
+				Socket: &config.Socket{
+					Push:    []config.Push{{Message: map[string]any{"f": "city"}}},
+					Replies: []config.SocketReply{{Message: "city"}},
+				},
+			},
+		},
+		Errors: config.Errors{
+			NotFound:         &config.Reply{Response: map[string]any{"message": "word"}},
+			MethodNotAllowed: &config.Reply{Response: map[string]any{"message": "word"}},
+		},
+		Fault: &config.Fault{Reply: config.Reply{Response: map[string]any{"error": "word"}}},
+	}
+
+	notes := strictModeNotes(cfg)
+	expected := []string{
+		`errors.not_found.response.message "word"`,
+		`errors.method_not_allowed.response.message "word"`,
+		`fault.response.error "word"`,
+		`GET /api/users response[0].nested.$regex "name"`,
+		`GET /api/users response[0].nested.city "city"`,
+		`GET /api/users response[0].status "word"`,
+		`GET /api/users response[0].tags[0] "color"`,
+		`POST /api/users response "email"`,
+		`POST /api/users cases[0].response.a "city"`,
+		`POST /api/users variants.slow.response.b "city"`,
+		`POST /api/users states.done.response.c "city"`,
+		`POST /api/users sequence[0].response.d "city"`,
+		`POST /api/users fault.response.e "city"`,
+		`POST /api/users socket.push[0].message.f "city"`,
+		`POST /api/users socket.replies[0].message "city"`,
+	}
+	if len(notes) != len(expected) {
+		t.Fatalf("Expected %d notes, got %d: %v", len(expected), len(notes), notes)
+	}
+	for _, want := range expected {
+		found := false
+		for _, note := range notes {
+			if strings.Contains(note, want) {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("Expected a note containing %q in %v", want, notes)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Inline templates mix literal text with type tokens, e.g. "{{first_name}} from {{city}}".
+// A backslash before the opening braces ("\{{") keeps them as literal text.
+const (
+	tokenOpen   = "{{"
+	tokenClose  = "}}"
+	tokenEscape = `\{{`
+)
+
+func isTemplate(value string) bool {
+	return strings.Contains(value, tokenOpen)
+}
+
+// interpolate replaces every {{type}} token in value with generated data. Unknown
+// tokens are kept verbatim. When the whole value is a single token the generated
+// value is returned with its own type, so "{{int}}" yields a number.
+func (g *generator) interpolate(value string) interface{} {
+	if strings.HasPrefix(value, tokenOpen) && strings.HasSuffix(value, tokenClose) &&
+		strings.Count(value, tokenOpen) == 1 && strings.Count(value, tokenClose) == 1 {
+		if data, ok := g.tokenValue(tokenName(value)); ok {
+			return data
+		}
+	}
+
+	var b strings.Builder
+	for len(value) > 0 {
+		if strings.HasPrefix(value, tokenEscape) {
+			b.WriteString(tokenOpen)
+			value = value[len(tokenEscape):]
+			continue
+		}
+		if !strings.HasPrefix(value, tokenOpen) {
+			next := nextTemplateMark(value[1:]) + 1
+			b.WriteString(value[:next])
+			value = value[next:]
+			continue
+		}
+		end := strings.Index(value, tokenClose)
+		if end < 0 {
+			b.WriteString(value)
+			break
+		}
+		token := value[:end+len(tokenClose)]
+		if data, ok := g.tokenValue(tokenName(token)); ok {
+			b.WriteString(formatTemplateValue(data))
+		} else {
+			b.WriteString(token)
+		}
+		value = value[len(token):]
+	}
+	return b.String()
+}
+
+// tokenValue generates the value of a token: request data such as uploaded
+// files, or fake data of the named type.
+func (g *generator) tokenValue(name string) (interface{}, bool) {
+	if data, ok := g.fileValue(name); ok {
+		return data, true
+	}
+	return fakeValue(g.faker, name)
+}
+
+// tokenName extracts the type name from a "{{ type }}" or "{{$type}}" token.
+func tokenName(token string) string {
+	name := strings.TrimSpace(token[len(tokenOpen) : len(token)-len(tokenClose)])
+	return strings.TrimPrefix(name, typePrefix)
+}
+
+// nextTemplateMark returns the offset of the next token or escape in s, or len(s).
+func nextTemplateMark(s string) int {
+	open := strings.Index(s, tokenOpen)
+	escape := strings.Index(s, tokenEscape)
+	switch {
+	case open < 0 && escape < 0:
+		return len(s)
+	case open < 0 || (escape >= 0 && escape < open):
+		return escape
+	default:
+		return open
+	}
+}
+
+func formatTemplateValue(data interface{}) string {
+	if t, ok := data.(time.Time); ok {
+		return t.Format(time.RFC3339)
+	}
+	return fmt.Sprint(data)
+}
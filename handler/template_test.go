@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/paqstd-team/fake-cli/config"
+)
+
+func TestTemplate_Interpolate(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		pattern string
+	}{
+		{
+			name:    "tokens_with_literals",
+			value:   "{{first_name}} from {{city}}",
+			pattern: `^\S+ from .+$`,
+		},
+		{
+			name:    "url_with_uuid",
+			value:   "https://cdn.example.com/{{uuid}}.png",
+			pattern: `^https://cdn\.example\.com/[0-9a-f-]{36}\.png$`,
+		},
+		{
+			name:    "whitespace_inside_token",
+			value:   "id-{{ uuid }}",
+			pattern: `^id-[0-9a-f-]{36}$`,
+		},
+		{
+			name:    "unknown_token_kept",
+			value:   "Hello, {{nobody}}!",
+			pattern: `^Hello, \{\{nobody\}\}!$`,
+		},
+		{
+			name:    "unknown_single_token_kept",
+			value:   "{{nobody}}",
+			pattern: `^\{\{nobody\}\}$`,
+		},
+		{
+			name:    "escaped_braces",
+			value:   `\{{uuid}} is {{uuid}}`,
+			pattern: `^\{\{uuid\}\} is [0-9a-f-]{36}$`,
+		},
+		{
+			name:    "escape_at_end",
+			value:   `literal \{{`,
+			pattern: `^literal \{\{$`,
+		},
+		{
+			name:    "unclosed_token",
+			value:   "open {{uuid",
+			pattern: `^open \{\{uuid$`,
+		},
+		{
+			name:    "date_token_uses_rfc3339",
+			value:   "at {{date}}",
+			pattern: `^at \d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}Z$`,
+		},
+		{
+			name:    "number_token_with_literal",
+			value:   "#{{number}}",
+			pattern: `^#\d+$`,
+		},
+	}
+
+	gen := &generator{faker: newFaker(1)}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := gen.generateField(tt.value)
+			value, ok := data.(string)
+			if !ok {
+				t.Fatalf("Expected string, got %T", data)
+			}
+			if !regexp.MustCompile(tt.pattern).MatchString(value) {
+				t.Errorf("Expected %q to match %s", value, tt.pattern)
+			}
+		})
+	}
+}
+
+func TestTemplate_SingleTokenKeepsType(t *testing.T) {
+	gen := &generator{faker: newFaker(1)}
+	if _, ok := gen.generateField("{{int}}").(int32); !ok {
+		t.Error("Expected {{int}} to keep its int32 type")
+	}
+	if _, ok := gen.generateField("{{ $bool }}").(bool); !ok {
+		t.Error("Expected {{ $bool }} to keep its bool type")
+	}
+}
+
+func TestTemplate_AnyPosition(t *testing.T) {
+	tests := []struct {
+		name     string
+		response any
+		check    func(t *testing.T, body []byte)
+	}{
+		{
+			name:     "top_level_string",
+			response: "Hello, {{first_name}}",
+			check: func(t *testing.T, body []byte) {
+				var s string
+				if err := json.Unmarshal(body, &s); err != nil {
+					t.Fatalf("Failed to unmarshal response: %v", err)
+				}
+				if !regexp.MustCompile(`^Hello, \S+$`).MatchString(s) {
+					t.Errorf("Unexpected greeting %q", s)
+				}
+			},
+		},
+		{
+			name: "nested_object_and_array",
+			response: map[string]any{
+				"avatar": "https://cdn.example.com/{{uuid}}.png",
+				"labels": []any{"tag-{{digit}}", "plain"},
+			},
+			check: func(t *testing.T, body []byte) {
+				var m map[string]any
+				if err := json.Unmarshal(body, &m); err != nil {
+					t.Fatalf("Failed to unmarshal response: %v", err)
+				}
+				if !regexp.MustCompile(`^https://cdn\.example\.com/[0-9a-f-]{36}\.png$`).MatchString(m["avatar"].(string)) {
+					t.Errorf("Unexpected avatar %v", m["avatar"])
+				}
+				labels := m["labels"].([]any)
+				if !regexp.MustCompile(`^tag-\d$`).MatchString(labels[0].(string)) || labels[1] != "plain" {
+					t.Errorf("Unexpected labels %v", labels)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.Config{
+				Endpoints: []config.Endpoint{{URL: "/api/template", Response: tt.response}},
+			}
+			handler := MakeHandler(cfg)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/template", nil)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d", w.Code)
+			}
+			tt.check(t, w.Body.Bytes())
+		})
+	}
+}
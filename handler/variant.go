@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+)
+
+// Per-request variant selection, e.g. "X-Fake-Variant: empty" or "?_variant=empty".
+const (
+	variantHeader     = "X-Fake-Variant"
+	variantQueryParam = "_variant"
+	// defaultVariant selects the endpoint's own reply, ignoring the runtime default.
+	defaultVariant = "default"
+)
+
+// requestVariant returns the variant the client asked for, if any.
+func requestVariant(r *http.Request) string {
+	if name := r.Header.Get(variantHeader); name != "" {
+		return name
+	}
+	return r.URL.Query().Get(variantQueryParam)
+}
+
+// currentVariant returns the variant served when the request does not pick one.
+func (h *endpointHandler) currentVariant() string {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	return h.variant
+}
+
+// setVariant changes the runtime default variant. An empty name or "default"
+// restores the endpoint's own reply.
+func (h *endpointHandler) setVariant(name string) error {
+	if name == defaultVariant {
+		name = ""
+	}
+	if _, ok := h.endpoint.Variants[name]; name != "" && !ok {
+		return fmt.Errorf("unknown variant %q, available: %v", name, h.variantNames())
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.variant = name
+	return nil
+}
+
+func (h *endpointHandler) variantNames() []string {
+	return slices.Sorted(maps.Keys(h.endpoint.Variants))
+}
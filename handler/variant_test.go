@@ -0,0 +1,184 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/paqstd-team/fake-cli/config"
+)
+
+func variantTestConfig() config.Config {
+	return config.Config{
+		Endpoints: []config.Endpoint{
+			{
+				URL:      "/items",
+				Response: []any{map[string]any{"id": "uuid"}},
+				Variants: map[string]config.Reply{
+					"empty": {Response: map[string]any{"items": []any{}}},
+					"error": {Status: http.StatusInternalServerError, Headers: map[string]string{"Retry-After": "5"}, Response: map[string]any{"error": "boom"}},
+				},
+				Cases: []config.Case{
+					{
+						When:  config.Match{Query: map[string]config.Condition{"missing": {Exists: boolPtr(true)}}},
+						Reply: config.Reply{Status: http.StatusNotFound, Response: map[string]any{"error": "not found"}},
+					},
+				},
+			},
+			{
+				URL:      "/profile",
+				Response: map[string]any{"name": "name"},
+			},
+		},
+	}
+}
+
+func TestVariant_RequestSelection(t *testing.T) {
+	handler := MakeHandler(variantTestConfig())
+
+	tests := []struct {
+		name           string
+		target         string
+		header         http.Header
+		expectedStatus int
+		expectedBody   string
+	}{
+		{name: "header", target: "/items", header: http.Header{"X-Fake-Variant": {"empty"}}, expectedStatus: 200, expectedBody: `{"items":[]}`},
+		{name: "query", target: "/items?_variant=error", expectedStatus: 500, expectedBody: `{"error":"boom"}`},
+		{name: "variant_wins_over_case", target: "/items?missing&_variant=empty", expectedStatus: 200, expectedBody: `{"items":[]}`},
+		{name: "unknown_variant_falls_back", target: "/items?missing&_variant=huge", expectedStatus: 404, expectedBody: `{"error":"not found"}`},
+		{name: "endpoint_without_variants", target: "/profile", header: http.Header{"X-Fake-Variant": {"empty"}}, expectedStatus: 200},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(handler, tt.target, tt.header)
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if tt.expectedBody != "" && w.Body.String() != tt.expectedBody {
+				t.Errorf("Expected body %s, got %s", tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+
+	if w := serve(handler, "/items?_variant=error", nil); w.Header().Get("Retry-After") != "5" {
+		t.Errorf("Expected variant headers, got %v", w.Header())
+	}
+}
+
+func TestVariant_ConfiguredDefault(t *testing.T) {
+	cfg := variantTestConfig()
+	cfg.Endpoints[0].Variant = "empty"
+	handler := MakeHandler(cfg)
+
+	if w := serve(handler, "/items", nil); w.Body.String() != `{"items":[]}` {
+		t.Errorf("Expected configured variant, got %s", w.Body.String())
+	}
+	if w := serve(handler, "/items?_variant=error", nil); w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected requested variant to win over the default, got %d", w.Code)
+	}
+
+	// "default" selects the endpoint's own reply for a single request
+	w := serve(handler, "/items?per_page=2", http.Header{"X-Fake-Variant": {"default"}})
+	var items []map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &items); err != nil || len(items) != 2 {
+		t.Errorf("Expected the endpoint's own list, got %s", w.Body.String())
+	}
+}
+
+func adminRequest(handler http.Handler, method, target, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w
+}
+
+func TestVariant_AdminSwitch(t *testing.T) {
+	handler := MakeHandler(variantTestConfig())
+
+	w := adminRequest(handler, http.MethodPut, "/__admin/variants", `{"url": "/items", "variant": "error"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var state variantState
+	if err := json.Unmarshal(w.Body.Bytes(), &state); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if state.Method != http.MethodGet || state.Variant != "error" || len(state.Variants) != 2 {
+		t.Errorf("Unexpected variant state %+v", state)
+	}
+
+	if w := serve(handler, "/items", nil); w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected the switched variant, got %d", w.Code)
+	}
+
+	w = adminRequest(handler, http.MethodGet, "/__admin/variants", "")
+	var states []variantState
+	if err := json.Unmarshal(w.Body.Bytes(), &states); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if len(states) != 1 || states[0].URL != "/items" || states[0].Variant != "error" {
+		t.Errorf("Unexpected variant list %+v", states)
+	}
+
+	w = adminRequest(handler, http.MethodPut, "/__admin/variants", `{"method": "GET", "url": "/items", "variant": "default"}`)
+	if err := json.Unmarshal(w.Body.Bytes(), &state); err != nil || state.Variant != "default" {
+		t.Errorf("Expected variant to be reset, got %s", w.Body.String())
+	}
+	if w := serve(handler, "/items", nil); w.Code != http.StatusOK {
+		t.Errorf("Expected the endpoint's own reply after reset, got %d", w.Code)
+	}
+}
+
+func TestVariant_AdminErrors(t *testing.T) {
+	handler := MakeHandler(variantTestConfig())
+
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+	}{
+		{name: "invalid_json", body: `{"url":`, expectedStatus: http.StatusBadRequest},
+		{name: "unknown_endpoint", body: `{"url": "/nope", "variant": "empty"}`, expectedStatus: http.StatusNotFound},
+		{name: "wrong_method", body: `{"method": "POST", "url": "/items", "variant": "empty"}`, expectedStatus: http.StatusNotFound},
+		{name: "unknown_variant", body: `{"url": "/items", "variant": "huge"}`, expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := adminRequest(handler, http.MethodPut, "/__admin/variants", tt.body)
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+		})
+	}
+}
+
+func TestVariant_CachedPerVariant(t *testing.T) {
+	cacheSize := 5
+	cfg := config.Config{
+		Endpoints: []config.Endpoint{
+			{
+				URL:      "/profile",
+				Response: map[string]any{"id": "uuid"},
+				Cache:    &cacheSize,
+				Variants: map[string]config.Reply{"other": {Response: map[string]any{"other": "uuid"}}},
+			},
+		},
+	}
+	handler := MakeHandler(cfg)
+
+	own := serve(handler, "/profile", nil).Body.String()
+	other := serve(handler, "/profile", http.Header{"X-Fake-Variant": {"other"}}).Body.String()
+	otherAgain := serve(handler, "/profile", http.Header{"X-Fake-Variant": {"other"}}).Body.String()
+
+	if own == other {
+		t.Error("Expected variants to be cached separately")
+	}
+	if other != otherAgain {
+		t.Error("Expected variant reply to be cached")
+	}
+}
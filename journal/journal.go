@@ -0,0 +1,128 @@
+// Package journal records the requests a server receives in a ring buffer and,
+// optionally, as JSON lines in a writer.
+package journal
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// Entry is a single recorded request.
+type Entry struct {
+	Time    time.Time           `json:"time"`
+	Method  string              `json:"method"`
+	Path    string              `json:"path"`
+	Query   map[string][]string `json:"query"`
+	Headers map[string][]string `json:"headers"`
+	Body    string              `json:"body"`
+	// Truncated is set when Body holds only the first bytes of the body.
+	Truncated bool `json:"truncated,omitempty"`
+	// Endpoint is the URL template of the endpoint that served the request,
+	// empty when no endpoint matched.
+	Endpoint  string  `json:"endpoint"`
+	Status    int     `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+}
+
+// Filter selects entries. Empty fields match every entry.
+type Filter struct {
+	Method   string
+	Path     string
+	Endpoint string
+	Status   int
+	// Limit keeps only the most recent entries when positive.
+	Limit int
+}
+
+func (f Filter) matches(e Entry) bool {
+	return (f.Method == "" || f.Method == e.Method) &&
+		(f.Path == "" || f.Path == e.Path) &&
+		(f.Endpoint == "" || f.Endpoint == e.Endpoint) &&
+		(f.Status == 0 || f.Status == e.Status)
+}
+
+// Journal keeps the most recent entries.
+type Journal struct {
+	mutex   sync.Mutex
+	entries []Entry
+	// next is the slot the next entry is written to once the buffer is full
+	next   int
+	size   int
+	output *json.Encoder
+	closer io.Closer
+}
+
+// New returns a journal that keeps size entries and appends every entry to
+// output as a JSON line, unless output is nil. Close closes output when it is
+// an io.Closer.
+func New(size int, output io.Writer) *Journal {
+	j := &Journal{size: size}
+	if output != nil {
+		j.output = json.NewEncoder(output)
+		j.closer, _ = output.(io.Closer)
+	}
+	return j
+}
+
+// Close stops writing entries to the output and closes it. Entries are still
+// kept in memory afterwards.
+func (j *Journal) Close() error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	j.output = nil
+	if j.closer == nil {
+		return nil
+	}
+	closer := j.closer
+	j.closer = nil
+	return closer.Close()
+}
+
+// Record adds an entry, dropping the oldest one when the journal is full. The
+// entry is kept even if writing it to the output fails.
+func (j *Journal) Record(e Entry) error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	if len(j.entries) < j.size {
+		j.entries = append(j.entries, e)
+	} else if j.size > 0 {
+		j.entries[j.next] = e
+		j.next = (j.next + 1) % j.size
+	}
+
+	if j.output == nil {
+		return nil
+	}
+	return j.output.Encode(e)
+}
+
+// Entries returns the entries that match filter, oldest first.
+func (j *Journal) Entries(filter Filter) []Entry {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	entries := []Entry{}
+	for i := range j.entries {
+		e := j.entries[(j.next+i)%len(j.entries)]
+		if filter.matches(e) {
+			entries = append(entries, e)
+		}
+	}
+	if filter.Limit > 0 && len(entries) > filter.Limit {
+		entries = entries[len(entries)-filter.Limit:]
+	}
+	return entries
+}
+
+// Clear removes every entry. Entries already written to the output are kept there.
+func (j *Journal) Clear() {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	j.entries = nil
+	j.next = 0
+}
@@ -0,0 +1,174 @@
+package journal
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func entry(method, path string, status int) Entry {
+	return Entry{Method: method, Path: path, Endpoint: path, Status: status}
+}
+
+func paths(entries []Entry) string {
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Path)
+	}
+	return strings.Join(names, ",")
+}
+
+func TestJournal_RingBuffer(t *testing.T) {
+	tests := []struct {
+		name     string
+		size     int
+		records  []string
+		expected string
+	}{
+		{name: "not_full", size: 3, records: []string{"/a", "/b"}, expected: "/a,/b"},
+		{name: "exactly_full", size: 2, records: []string{"/a", "/b"}, expected: "/a,/b"},
+		{name: "wraps_around", size: 3, records: []string{"/a", "/b", "/c", "/d", "/e"}, expected: "/c,/d,/e"},
+		{name: "zero_size", size: 0, records: []string{"/a"}, expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			j := New(tt.size, nil)
+			for _, path := range tt.records {
+				if err := j.Record(entry("GET", path, 200)); err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+			}
+
+			if got := paths(j.Entries(Filter{})); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestJournal_Filter(t *testing.T) {
+	j := New(10, nil)
+	j.Record(entry("GET", "/orders", 200))
+	j.Record(entry("POST", "/orders", 201))
+	j.Record(entry("GET", "/users", 200))
+	j.Record(entry("POST", "/orders", 400))
+
+	tests := []struct {
+		name     string
+		filter   Filter
+		expected int
+	}{
+		{name: "all", filter: Filter{}, expected: 4},
+		{name: "by_method", filter: Filter{Method: "POST"}, expected: 2},
+		{name: "by_path", filter: Filter{Path: "/orders"}, expected: 3},
+		{name: "by_endpoint", filter: Filter{Endpoint: "/users"}, expected: 1},
+		{name: "by_status", filter: Filter{Status: 200}, expected: 2},
+		{name: "combined", filter: Filter{Method: "POST", Status: 201}, expected: 1},
+		{name: "limit", filter: Filter{Path: "/orders", Limit: 2}, expected: 2},
+		{name: "limit_above_count", filter: Filter{Limit: 10}, expected: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := j.Entries(tt.filter); len(got) != tt.expected {
+				t.Errorf("Expected %d entries, got %d", tt.expected, len(got))
+			}
+		})
+	}
+
+	// Limit keeps the most recent entries
+	if got := j.Entries(Filter{Path: "/orders", Limit: 1}); got[0].Status != 400 {
+		t.Errorf("Expected most recent entry, got %+v", got[0])
+	}
+}
+
+func TestJournal_Output(t *testing.T) {
+	var output bytes.Buffer
+	j := New(1, &output)
+	j.Record(entry("GET", "/a", 200))
+	j.Record(entry("POST", "/b", 201))
+
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %d", len(lines))
+	}
+	var e Entry
+	if err := json.Unmarshal([]byte(lines[1]), &e); err != nil {
+		t.Fatalf("Failed to unmarshal line: %v", err)
+	}
+	if e.Method != "POST" || e.Path != "/b" || e.Status != 201 {
+		t.Errorf("Unexpected entry %+v", e)
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestJournal_OutputError(t *testing.T) {
+	j := New(5, failingWriter{})
+
+	if err := j.Record(entry("GET", "/a", 200)); err == nil {
+		t.Error("Expected error from output")
+	}
+	if got := j.Entries(Filter{}); len(got) != 1 {
+		t.Errorf("Expected entry to be kept, got %d", len(got))
+	}
+}
+
+func TestJournal_Clear(t *testing.T) {
+	j := New(2, nil)
+	j.Record(entry("GET", "/a", 200))
+	j.Record(entry("GET", "/b", 200))
+	j.Record(entry("GET", "/c", 200))
+	j.Clear()
+
+	if got := j.Entries(Filter{}); len(got) != 0 {
+		t.Fatalf("Expected no entries, got %d", len(got))
+	}
+
+	j.Record(entry("GET", "/d", 200))
+	if got := paths(j.Entries(Filter{})); got != "/d" {
+		t.Errorf("Expected /d, got %q", got)
+	}
+}
+
+type closingWriter struct {
+	bytes.Buffer
+	closed int
+}
+
+func (w *closingWriter) Close() error {
+	w.closed++
+	return nil
+}
+
+func TestJournal_Close(t *testing.T) {
+	output := &closingWriter{}
+	j := New(5, output)
+	j.Record(entry("GET", "/a", 200))
+
+	if err := j.Close(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// Closing twice and recording after closing leave the output alone
+	j.Close()
+	if err := j.Record(entry("GET", "/b", 200)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if output.closed != 1 {
+		t.Errorf("Expected output to be closed once, got %d", output.closed)
+	}
+	if lines := strings.Count(output.String(), "\n"); lines != 1 {
+		t.Errorf("Expected 1 line in output, got %d", lines)
+	}
+	if got := paths(j.Entries(Filter{})); got != "/a,/b" {
+		t.Errorf("Expected /a,/b, got %q", got)
+	}
+}
@@ -0,0 +1,220 @@
+package journal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// closestLimit is the number of unmatched requests a failed verification reports.
+const closestLimit = 3
+
+// Matcher describes the requests a client is expected to have made.
+type Matcher struct {
+	Method string `json:"method"`
+	// Path is compared as is, PathRegex is a regular expression for the path.
+	Path      string `json:"path"`
+	PathRegex string `json:"path_regex"`
+	// Headers maps header names to regular expressions for their value.
+	Headers map[string]string `json:"headers"`
+	// Body is a JSON value the request body has to contain: objects may have
+	// more keys, everything else must be equal.
+	Body any `json:"body"`
+	// Count is the expected number of matching requests, at least one by default.
+	Count Count `json:"count"`
+}
+
+// Count is an inclusive range. Min defaults to 1 and Max to no limit.
+type Count struct {
+	Min *int `json:"min"`
+	Max *int `json:"max"`
+}
+
+func (c Count) contains(n int) bool {
+	min := 1
+	if c.Min != nil {
+		min = *c.Min
+	}
+	return n >= min && (c.Max == nil || n <= *c.Max)
+}
+
+// Result is the outcome of a verification.
+type Result struct {
+	Matched bool `json:"matched"`
+	// Count is the number of matching requests.
+	Count int `json:"count"`
+	// Closest are the unmatched requests nearest to the matcher when it failed,
+	// with the differences that kept them from matching.
+	Closest []Mismatch `json:"closest,omitempty"`
+}
+
+// Mismatch is a request that did not match and why.
+type Mismatch struct {
+	Entry Entry    `json:"entry"`
+	Diff  []string `json:"diff"`
+}
+
+// compiled is a Matcher with its regular expressions compiled.
+type compiled struct {
+	Matcher
+	path    *regexp.Regexp
+	headers map[string]*regexp.Regexp
+}
+
+func compile(m Matcher) (*compiled, error) {
+	c := &compiled{Matcher: m, headers: make(map[string]*regexp.Regexp, len(m.Headers))}
+	if m.PathRegex != "" {
+		re, err := regexp.Compile(m.PathRegex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid path_regex %q: %v", m.PathRegex, err)
+		}
+		c.path = re
+	}
+	for name, pattern := range m.Headers {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex %q for header %s: %v", pattern, name, err)
+		}
+		c.headers[name] = re
+	}
+	return c, nil
+}
+
+// diff lists what keeps e from matching, nothing when it matches.
+func (c *compiled) diff(e Entry) []string {
+	var diff []string
+	if c.Method != "" && !strings.EqualFold(c.Method, e.Method) {
+		diff = append(diff, fmt.Sprintf("method: expected %s, got %s", strings.ToUpper(c.Method), e.Method))
+	}
+	if c.Path != "" && c.Path != e.Path {
+		diff = append(diff, fmt.Sprintf("path: expected %s, got %s", c.Path, e.Path))
+	}
+	if c.path != nil && !c.path.MatchString(e.Path) {
+		diff = append(diff, fmt.Sprintf("path: expected match for %s, got %s", c.PathRegex, e.Path))
+	}
+
+	headers := http.Header(e.Headers)
+	for _, name := range slices.Sorted(maps.Keys(c.headers)) {
+		if value := headers.Get(name); !c.headers[name].MatchString(value) {
+			diff = append(diff, fmt.Sprintf("headers.%s: expected match for %s, got %q", name, c.Headers[name], value))
+		}
+	}
+
+	if c.Body != nil {
+		var body any
+		if err := json.Unmarshal([]byte(e.Body), &body); err != nil {
+			diff = append(diff, fmt.Sprintf("body: expected JSON, got %q", e.Body))
+		} else {
+			diff = append(diff, subsetDiff("body", c.Body, body)...)
+		}
+	}
+	return diff
+}
+
+// subsetDiff lists where actual does not contain expected.
+func subsetDiff(path string, expected, actual any) []string {
+	switch exp := expected.(type) {
+	case map[string]any:
+		obj, ok := actual.(map[string]any)
+		if !ok {
+			return []string{fmt.Sprintf("%s: expected an object, got %s", path, encode(actual))}
+		}
+		var diff []string
+		for _, key := range slices.Sorted(maps.Keys(exp)) {
+			value, exists := obj[key]
+			if !exists {
+				diff = append(diff, fmt.Sprintf("%s.%s: missing", path, key))
+				continue
+			}
+			diff = append(diff, subsetDiff(path+"."+key, exp[key], value)...)
+		}
+		return diff
+	case []any:
+		arr, ok := actual.([]any)
+		if !ok || len(arr) != len(exp) {
+			return []string{fmt.Sprintf("%s: expected %s, got %s", path, encode(expected), encode(actual))}
+		}
+		var diff []string
+		for i := range exp {
+			diff = append(diff, subsetDiff(fmt.Sprintf("%s[%d]", path, i), exp[i], arr[i])...)
+		}
+		return diff
+	default:
+		if !reflect.DeepEqual(expected, actual) {
+			return []string{fmt.Sprintf("%s: expected %s, got %s", path, encode(expected), encode(actual))}
+		}
+		return nil
+	}
+}
+
+func encode(v any) string {
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+// Verify checks entries against m.
+func Verify(entries []Entry, m Matcher) (Result, error) {
+	c, err := compile(m)
+	if err != nil {
+		return Result{}, err
+	}
+
+	var result Result
+	var mismatches []Mismatch
+	for _, e := range entries {
+		if diff := c.diff(e); len(diff) == 0 {
+			result.Count++
+		} else {
+			mismatches = append(mismatches, Mismatch{Entry: e, Diff: diff})
+		}
+	}
+	result.Matched = m.Count.contains(result.Count)
+
+	if !result.Matched {
+		// Most recent first among requests that are equally close
+		slices.Reverse(mismatches)
+		sort.SliceStable(mismatches, func(i, j int) bool {
+			return len(mismatches[i].Diff) < len(mismatches[j].Diff)
+		})
+		result.Closest = mismatches[:min(len(mismatches), closestLimit)]
+	}
+	return result, nil
+}
+
+// Verify checks the recorded requests against m.
+func (j *Journal) Verify(m Matcher) (Result, error) {
+	return Verify(j.Entries(Filter{}), m)
+}
+
+// VerifyAt asks the server whose admin API is served at adminURL, for example
+// "http://localhost:8080/__admin", to verify m against its journal.
+func VerifyAt(adminURL string, m Matcher) (Result, error) {
+	body, err := json.Marshal(m)
+	if err != nil {
+		return Result{}, err
+	}
+	resp, err := http.Post(strings.TrimSuffix(adminURL, "/")+"/requests/verify", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return Result{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var message bytes.Buffer
+		message.ReadFrom(resp.Body)
+		return Result{}, fmt.Errorf("verify: %s: %s", resp.Status, strings.TrimSpace(message.String()))
+	}
+
+	var result Result
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return Result{}, fmt.Errorf("verify: %v", err)
+	}
+	return result, nil
+}
@@ -0,0 +1,225 @@
+package journal
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func verifyTestEntries() []Entry {
+	return []Entry{
+		{Method: "POST", Path: "/orders", Headers: map[string][]string{"Authorization": {"Bearer abc"}}, Body: `{"total": 10, "items": [{"sku": "A"}], "note": "gift"}`},
+		{Method: "GET", Path: "/orders/1"},
+		{Method: "POST", Path: "/orders", Body: `{"total": 12, "items": [{"sku": "B"}]}`},
+		{Method: "POST", Path: "/payments", Body: `not json`},
+	}
+}
+
+func TestVerify_Matching(t *testing.T) {
+	tests := []struct {
+		name          string
+		matcher       Matcher
+		expected      bool
+		expectedCount int
+	}{
+		{name: "method_and_path", matcher: Matcher{Method: "post", Path: "/orders"}, expected: true, expectedCount: 2},
+		{name: "path_regex", matcher: Matcher{PathRegex: `^/orders/\d+$`}, expected: true, expectedCount: 1},
+		{name: "header_regex", matcher: Matcher{Headers: map[string]string{"authorization": "^Bearer "}}, expected: true, expectedCount: 1},
+		{name: "body_subset", matcher: Matcher{Body: map[string]any{"total": float64(10)}}, expected: true, expectedCount: 1},
+		{name: "nested_body_subset", matcher: Matcher{Body: map[string]any{"items": []any{map[string]any{"sku": "B"}}}}, expected: true, expectedCount: 1},
+		{name: "exactly_once", matcher: Matcher{Method: "POST", Path: "/orders", Count: Count{Min: intPtr(1), Max: intPtr(1)}}, expected: false, expectedCount: 2},
+		{name: "at_most_two", matcher: Matcher{Method: "POST", Path: "/orders", Count: Count{Max: intPtr(2)}}, expected: true, expectedCount: 2},
+		{name: "never", matcher: Matcher{Path: "/refunds", Count: Count{Min: intPtr(0), Max: intPtr(0)}}, expected: true, expectedCount: 0},
+		{name: "not_called", matcher: Matcher{Path: "/refunds"}, expected: false, expectedCount: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Verify(verifyTestEntries(), tt.matcher)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result.Matched != tt.expected {
+				t.Errorf("Expected matched %v, got %v", tt.expected, result.Matched)
+			}
+			if result.Count != tt.expectedCount {
+				t.Errorf("Expected count %d, got %d", tt.expectedCount, result.Count)
+			}
+		})
+	}
+}
+
+func TestVerify_Closest(t *testing.T) {
+	result, err := Verify(verifyTestEntries(), Matcher{
+		Method: "POST",
+		Path:   "/orders",
+		Body:   map[string]any{"total": float64(11), "items": []any{map[string]any{"sku": "B"}}},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Matched || len(result.Closest) != closestLimit {
+		t.Fatalf("Expected %d closest requests, got %+v", closestLimit, result)
+	}
+
+	closest := result.Closest[0]
+	if closest.Entry.Body != verifyTestEntries()[2].Body {
+		t.Errorf("Expected the request with one difference first, got %+v", closest.Entry)
+	}
+	if len(closest.Diff) != 1 || closest.Diff[0] != "body.total: expected 11, got 12" {
+		t.Errorf("Unexpected diff %v", closest.Diff)
+	}
+}
+
+func TestVerify_Diff(t *testing.T) {
+	tests := []struct {
+		name     string
+		matcher  Matcher
+		entry    Entry
+		expected []string
+	}{
+		{
+			name:     "method_and_path",
+			matcher:  Matcher{Method: "post", Path: "/orders"},
+			entry:    Entry{Method: "GET", Path: "/users"},
+			expected: []string{"method: expected POST, got GET", "path: expected /orders, got /users"},
+		},
+		{
+			name:     "path_regex",
+			matcher:  Matcher{PathRegex: "^/orders"},
+			entry:    Entry{Path: "/users"},
+			expected: []string{"path: expected match for ^/orders, got /users"},
+		},
+		{
+			name:     "header",
+			matcher:  Matcher{Headers: map[string]string{"X-Client": "^web$"}},
+			entry:    Entry{},
+			expected: []string{`headers.X-Client: expected match for ^web$, got ""`},
+		},
+		{
+			name:     "body_not_json",
+			matcher:  Matcher{Body: map[string]any{}},
+			entry:    Entry{Body: "plain"},
+			expected: []string{`body: expected JSON, got "plain"`},
+		},
+		{
+			name:     "body_not_object",
+			matcher:  Matcher{Body: map[string]any{"a": float64(1)}},
+			entry:    Entry{Body: `[1]`},
+			expected: []string{"body: expected an object, got [1]"},
+		},
+		{
+			name:     "body_missing_key",
+			matcher:  Matcher{Body: map[string]any{"a": float64(1)}},
+			entry:    Entry{Body: `{}`},
+			expected: []string{"body.a: missing"},
+		},
+		{
+			name:     "body_array_length",
+			matcher:  Matcher{Body: []any{float64(1)}},
+			entry:    Entry{Body: `[1, 2]`},
+			expected: []string{"body: expected [1], got [1,2]"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := compile(tt.matcher)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if diff := c.diff(tt.entry); strings.Join(diff, "\n") != strings.Join(tt.expected, "\n") {
+				t.Errorf("Expected %q, got %q", tt.expected, diff)
+			}
+		})
+	}
+}
+
+func TestVerify_InvalidMatcher(t *testing.T) {
+	tests := []struct {
+		name    string
+		matcher Matcher
+	}{
+		{name: "path_regex", matcher: Matcher{PathRegex: "("}},
+		{name: "header_regex", matcher: Matcher{Headers: map[string]string{"X": "["}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Verify(nil, tt.matcher); err == nil {
+				t.Error("Expected error but got none")
+			}
+		})
+	}
+}
+
+func TestJournal_Verify(t *testing.T) {
+	j := New(10, nil)
+	for _, e := range verifyTestEntries() {
+		j.Record(e)
+	}
+
+	result, err := j.Verify(Matcher{Method: "GET"})
+	if err != nil || !result.Matched || result.Count != 1 {
+		t.Errorf("Expected one GET request, got %+v, %v", result, err)
+	}
+}
+
+func TestVerifyAt(t *testing.T) {
+	j := New(10, nil)
+	for _, e := range verifyTestEntries() {
+		j.Record(e)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/__admin/requests/verify" {
+			http.NotFound(w, r)
+			return
+		}
+		var m Matcher
+		json.NewDecoder(r.Body).Decode(&m)
+		if m.Method == "broken" {
+			w.Write([]byte("{"))
+			return
+		}
+		result, err := j.Verify(m)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(result)
+	}))
+	defer server.Close()
+
+	result, err := VerifyAt(server.URL+"/__admin/", Matcher{Method: "POST", Path: "/orders"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !result.Matched || result.Count != 2 {
+		t.Errorf("Expected 2 matching requests, got %+v", result)
+	}
+
+	tests := []struct {
+		name     string
+		adminURL string
+		matcher  Matcher
+	}{
+		{name: "unmarshalable_matcher", adminURL: server.URL + "/__admin", matcher: Matcher{Body: func() {}}},
+		{name: "unreachable", adminURL: "http://127.0.0.1:0/__admin", matcher: Matcher{}},
+		{name: "error_status", adminURL: server.URL + "/__admin", matcher: Matcher{PathRegex: "("}},
+		{name: "invalid_response", adminURL: server.URL + "/__admin", matcher: Matcher{Method: "broken"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := VerifyAt(tt.adminURL, tt.matcher); err == nil {
+				t.Error("Expected error but got none")
+			}
+		})
+	}
+}
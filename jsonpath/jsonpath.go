@@ -0,0 +1,118 @@
+// Package jsonpath implements the subset of JSONPath used to point into request
+// bodies: $.a.b, $['a'], $.items[0], $.items[*].id and $.*.
+package jsonpath
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type segment struct {
+	key      string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+// Path is a parsed JSONPath expression.
+type Path []segment
+
+// Parse parses expr. An expression without the leading "$" is relative to the
+// root, so "user.name" is the same as "$.user.name".
+func Parse(expr string) (Path, error) {
+	rest := expr
+	if strings.HasPrefix(rest, "$") {
+		rest = rest[1:]
+	} else if rest != "" && !strings.HasPrefix(rest, "[") {
+		rest = "." + rest
+	}
+
+	var path Path
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			name := rest[:end]
+			if name == "" {
+				return nil, fmt.Errorf("jsonpath %q: empty name", expr)
+			}
+			if name == "*" {
+				path = append(path, segment{wildcard: true})
+			} else {
+				path = append(path, segment{key: name})
+			}
+			rest = rest[end:]
+		case '[':
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("jsonpath %q: missing ]", expr)
+			}
+			seg, err := parseBracket(rest[1:end])
+			if err != nil {
+				return nil, fmt.Errorf("jsonpath %q: %v", expr, err)
+			}
+			path = append(path, seg)
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("jsonpath %q: unexpected %q", expr, rest[0])
+		}
+	}
+	return path, nil
+}
+
+func parseBracket(inner string) (segment, error) {
+	if inner == "*" {
+		return segment{wildcard: true}, nil
+	}
+	if len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0] {
+		return segment{key: inner[1 : len(inner)-1]}, nil
+	}
+	index, err := strconv.Atoi(inner)
+	if err != nil || index < 0 {
+		return segment{}, fmt.Errorf("invalid index %q", inner)
+	}
+	return segment{index: index, isIndex: true}, nil
+}
+
+// Lookup returns every value of doc the path points to. Wildcards may match
+// several values; a path that does not exist matches none.
+func (p Path) Lookup(doc any) []any {
+	values := []any{doc}
+	for _, seg := range p {
+		var next []any
+		for _, value := range values {
+			next = append(next, seg.lookup(value)...)
+		}
+		values = next
+	}
+	return values
+}
+
+func (s segment) lookup(value any) []any {
+	switch v := value.(type) {
+	case map[string]any:
+		if s.wildcard {
+			values := make([]any, 0, len(v))
+			for _, item := range v {
+				values = append(values, item)
+			}
+			return values
+		}
+		if item, ok := v[s.key]; ok && !s.isIndex {
+			return []any{item}
+		}
+	case []any:
+		if s.wildcard {
+			return v
+		}
+		if s.isIndex && s.index < len(v) {
+			return []any{v[s.index]}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package jsonpath
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestJSONPath_Lookup(t *testing.T) {
+	doc := map[string]any{
+		"user": map[string]any{"name": "admin", "roles": []any{"read", "write"}},
+		"items": []any{
+			map[string]any{"id": float64(1)},
+			map[string]any{"id": float64(2)},
+		},
+		"odd key": true,
+	}
+
+	tests := []struct {
+		name     string
+		expr     string
+		expected []any
+	}{
+		{name: "root", expr: "$", expected: []any{doc}},
+		{name: "dotted", expr: "$.user.name", expected: []any{"admin"}},
+		{name: "relative", expr: "user.name", expected: []any{"admin"}},
+		{name: "bracket_key", expr: "$['odd key']", expected: []any{true}},
+		{name: "double_quoted_key", expr: `$["user"]["name"]`, expected: []any{"admin"}},
+		{name: "relative_bracket", expr: `['odd key']`, expected: []any{true}},
+		{name: "index", expr: "$.user.roles[1]", expected: []any{"write"}},
+		{name: "index_out_of_range", expr: "$.user.roles[5]", expected: nil},
+		{name: "array_wildcard", expr: "$.items[*].id", expected: []any{float64(1), float64(2)}},
+		{name: "object_wildcard", expr: "$.user.*", expected: []any{"admin", []any{"read", "write"}}},
+		{name: "missing_key", expr: "$.user.email", expected: nil},
+		{name: "key_on_array", expr: "$.items.id", expected: nil},
+		{name: "index_on_object", expr: "$.user[0]", expected: nil},
+		{name: "key_on_scalar", expr: "$.user.name.first", expected: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			got := path.Lookup(doc)
+			if tt.name == "object_wildcard" {
+				// Object members have no order
+				sort.Slice(got, func(i, j int) bool { _, ok := got[i].(string); return ok })
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestJSONPath_ParseErrors(t *testing.T) {
+	tests := []string{
+		"$.",
+		"$.user..name",
+		"$[0",
+		"$[abc]",
+		"$[-1]",
+		"$x",
+	}
+
+	for _, expr := range tests {
+		t.Run(expr, func(t *testing.T) {
+			if _, err := Parse(expr); err == nil {
+				t.Errorf("Expected error for %q", expr)
+			}
+		})
+	}
+}
@@ -0,0 +1,267 @@
+package jsonschema
+
+import (
+	"maps"
+	"math"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Directive keys of templates, see the handler package.
+const (
+	regexDirective   = "$regex"
+	formatDirective  = "$format"
+	rangeDirective   = "$range"
+	literalDirective = "$literal"
+)
+
+// Sampler describes a template string: a sample of the value it generates, the
+// data type it generates, if any, and whether it is a literal returned as is.
+type Sampler func(value string) (sample any, dataType string, literal bool)
+
+// Schemas of data types whose values have a standard format.
+var typeSchemas = map[string]Schema{
+	"uuid":        {Type: Types{"string"}, Format: "uuid"},
+	"email":       {Type: Types{"string"}, Format: "email"},
+	"url":         {Type: Types{"string"}, Format: "uri"},
+	"domain_name": {Type: Types{"string"}, Format: "hostname"},
+	"ipv4":        {Type: Types{"string"}, Format: "ipv4"},
+	"ipv6":        {Type: Types{"string"}, Format: "ipv6"},
+	"password":    {Type: Types{"string"}, Format: "password"},
+	"int32":       {Type: Types{"integer"}, Format: "int32"},
+	"int64":       {Type: Types{"integer"}, Format: "int64"},
+	"float32":     {Type: Types{"number"}, Format: "float"},
+	"float64":     {Type: Types{"number"}, Format: "double"},
+}
+
+// FromResponse returns the schema of the data generated from a response
+// template. A top-level array is a paginated list of its first item.
+func FromResponse(template any, sample Sampler) *Schema {
+	if items, ok := template.([]any); ok {
+		item := any(map[string]any{})
+		if len(items) > 0 {
+			item = items[0]
+		}
+		return &Schema{Type: Types{"array"}, Items: fromTemplate(item, sample)}
+	}
+	return fromTemplate(template, sample)
+}
+
+func fromTemplate(template any, sample Sampler) *Schema {
+	switch t := template.(type) {
+	case string:
+		value, dataType, literal := sample(t)
+		schema := dataSchema(value, dataType)
+		if literal {
+			schema.Example = value
+		}
+		return schema
+	case map[string]any:
+		if schema, ok := directiveSchema(t); ok {
+			return schema
+		}
+		schema := &Schema{Type: Types{"object"}, Properties: make(map[string]*Schema, len(t))}
+		for _, key := range sortedKeys(t) {
+			schema.Properties[key] = fromTemplate(t[key], sample)
+			schema.Required = append(schema.Required, key)
+		}
+		return schema
+	case []any:
+		schema := &Schema{Type: Types{"array"}, Items: &Schema{}}
+		if len(t) > 0 {
+			schema.Items = fromTemplate(t[0], sample)
+		}
+		return schema
+	default:
+		schema := valueSchema(t)
+		schema.Example = t
+		return schema
+	}
+}
+
+// FromPayload returns the schema of the request bodies a payload template
+// accepts. Keys with a null value are optional. Leaves are checked when they
+// name a data type, are a directive or a marked literal such as "=admin" in
+// strict mode, and accept anything otherwise.
+func FromPayload(template any, sample Sampler) *Schema {
+	switch t := template.(type) {
+	case map[string]any:
+		if schema, ok := directiveSchema(t); ok {
+			if literal, ok := t[literalDirective]; ok {
+				return &Schema{Const: literal}
+			}
+			return schema
+		}
+		schema := &Schema{Type: Types{"object"}, Properties: make(map[string]*Schema, len(t))}
+		for _, key := range sortedKeys(t) {
+			if t[key] == nil {
+				schema.Properties[key] = &Schema{}
+				continue
+			}
+			schema.Properties[key] = FromPayload(t[key], sample)
+			schema.Required = append(schema.Required, key)
+		}
+		return schema
+	case []any:
+		item := any(map[string]any{})
+		if len(t) > 0 {
+			item = t[0]
+		}
+		return &Schema{Type: Types{"array"}, Items: FromPayload(item, sample)}
+	case string:
+		value, dataType, literal := sample(t)
+		if dataType != "" {
+			return dataSchema(value, dataType)
+		}
+		// The sampler strips the marker of marked literals
+		if literal && value != t {
+			return &Schema{Const: value}
+		}
+		return &Schema{}
+	default:
+		return &Schema{}
+	}
+}
+
+// dataSchema returns the schema of a data type, or of its sample for types
+// without a standard format.
+func dataSchema(sample any, dataType string) *Schema {
+	if schema, ok := typeSchemas[dataType]; ok {
+		return &schema
+	}
+	return valueSchema(sample)
+}
+
+// valueSchema returns the schema of a generated or literal value.
+func valueSchema(value any) *Schema {
+	if _, ok := value.(time.Time); ok {
+		return &Schema{Type: Types{"string"}, Format: "date-time"}
+	}
+	switch reflect.ValueOf(value).Kind() {
+	case reflect.String:
+		return &Schema{Type: Types{"string"}}
+	case reflect.Bool:
+		return &Schema{Type: Types{"boolean"}}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: Types{"integer"}}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: Types{"number"}}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: Types{"array"}, Items: &Schema{}}
+	case reflect.Map, reflect.Struct:
+		return &Schema{Type: Types{"object"}}
+	default:
+		return &Schema{Nullable: true}
+	}
+}
+
+// directiveSchema returns the schema of a directive such as {"$regex": "..."}.
+// The second return value reports whether the object is a directive at all.
+func directiveSchema(fields map[string]any) (*Schema, bool) {
+	if len(fields) != 1 {
+		return nil, false
+	}
+	if literal, ok := fields[literalDirective]; ok {
+		schema := valueSchema(literal)
+		schema.Example = literal
+		return schema, true
+	}
+	if pattern, ok := fields[regexDirective].(string); ok {
+		return &Schema{Type: Types{"string"}, Pattern: pattern}, true
+	}
+	if mask, ok := fields[formatDirective].(string); ok {
+		return &Schema{Type: Types{"string"}, Pattern: maskPattern(mask)}, true
+	}
+	if bounds, ok := fields[rangeDirective]; ok {
+		if r, ok := ParseRange(bounds); ok {
+			schema := &Schema{Type: Types{"number"}, Minimum: &r.Min, Maximum: &r.Max}
+			if r.Integer {
+				schema.Type = Types{"integer"}
+			}
+			return schema, true
+		}
+	}
+	return nil, false
+}
+
+// MaxRangeInteger is the largest bound of an integer range, the largest
+// integer a JSON number holds exactly.
+const MaxRangeInteger = 1<<53 - 1
+
+// Range is the value of a $range directive.
+type Range struct {
+	Min, Max float64
+	// Integer makes the range generate whole numbers.
+	Integer bool
+}
+
+// ParseRange reads a $range directive: [min, max] or [min, max, type] with
+// the type "integer" or "number". Without a type the range is integer when
+// both bounds are whole. Integer bounds must be whole and within
+// MaxRangeInteger of zero.
+func ParseRange(value any) (Range, bool) {
+	bounds, ok := value.([]any)
+	if !ok || len(bounds) < 2 || len(bounds) > 3 {
+		return Range{}, false
+	}
+	min, minOk := bounds[0].(float64)
+	max, maxOk := bounds[1].(float64)
+	if !minOk || !maxOk || min > max {
+		return Range{}, false
+	}
+	whole := isWhole(min) && isWhole(max)
+	r := Range{Min: min, Max: max, Integer: whole}
+	if len(bounds) == 3 {
+		switch bounds[2] {
+		case "integer":
+			r.Integer = true
+		case "number":
+			r.Integer = false
+		default:
+			return Range{}, false
+		}
+	}
+	if r.Integer && !whole {
+		return Range{}, false
+	}
+	return r, true
+}
+
+// isWhole reports whether f is an integer that fits a range.
+func isWhole(f float64) bool {
+	return f == math.Trunc(f) && math.Abs(f) <= MaxRangeInteger
+}
+
+// maskPattern converts a $format mask into the regular expression it matches.
+func maskPattern(mask string) string {
+	var b strings.Builder
+	b.WriteString("^")
+	escaped := false
+	for _, r := range mask {
+		switch {
+		case escaped:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '#':
+			b.WriteString("[0-9]")
+		case r == '?':
+			b.WriteString("[A-Z]")
+		case r == '*':
+			b.WriteString("[0-9a-f]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+	return b.String()
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	return slices.Sorted(maps.Keys(m))
+}
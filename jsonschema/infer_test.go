@@ -0,0 +1,76 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testSampler knows the uuid and int types and strips a leading "=" as in
+// strict mode, everything else is a literal.
+func testSampler(value string) (any, string, bool) {
+	if literal, ok := strings.CutPrefix(value, "="); ok {
+		return literal, "", true
+	}
+	switch strings.TrimPrefix(value, "$") {
+	case "uuid":
+		return "9b2c4f1e-8d3a-4c5b-9e6f-0a1b2c3d4e5f", "uuid", false
+	case "int":
+		return 7, "int", false
+	}
+	return value, "", true
+}
+
+func TestFromPayload(t *testing.T) {
+	tests := []struct {
+		name     string
+		template any
+		expected string
+	}{
+		{name: "data_type", template: "uuid", expected: `{"type":"string","format":"uuid"}`},
+		{name: "sampled_type", template: "$int", expected: `{"type":"integer"}`},
+		{name: "unknown_string", template: "", expected: `{}`},
+		{name: "literal", template: "=admin", expected: `{"const":"admin"}`},
+		{name: "unmarked_literal", template: "admin", expected: `{}`},
+		{name: "literal_directive", template: map[string]any{"$literal": 3.0}, expected: `{"const":3}`},
+		{name: "regex", template: map[string]any{"$regex": "^A"}, expected: `{"type":"string","pattern":"^A"}`},
+		{name: "range", template: map[string]any{"$range": []any{1.0, 9.0}}, expected: `{"type":"integer","minimum":1,"maximum":9}`},
+		{name: "number_range", template: map[string]any{"$range": []any{1.0, 9.0, "number"}}, expected: `{"type":"number","minimum":1,"maximum":9}`},
+		{name: "invalid_range", template: map[string]any{"$range": []any{9.0, 1.0}}, expected: `{"type":"object","properties":{"$range":{"type":"array","items":{}}},"required":["$range"]}`},
+		{name: "number", template: 3.0, expected: `{}`},
+		{
+			name:     "object",
+			template: map[string]any{"id": "uuid", "note": nil},
+			expected: `{"type":"object","properties":{"id":{"type":"string","format":"uuid"},"note":{}},"required":["id"]}`,
+		},
+		{name: "array", template: []any{"int"}, expected: `{"type":"array","items":{"type":"integer"}}`},
+		{name: "empty_array", template: []any{}, expected: `{"type":"array","items":{"type":"object"}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := json.Marshal(FromPayload(tt.template, testSampler))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if string(got) != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestFromPayload_Validates(t *testing.T) {
+	schema := FromPayload(map[string]any{"id": "uuid", "age": "int", "role": "=admin"}, testSampler)
+
+	violations := schema.Validate(map[string]any{"id": "nope", "age": "abc", "role": "user"})
+	expected := []Violation{
+		{Pointer: "/age", Message: "must be of type integer, got string"},
+		{Pointer: "/id", Message: "must be a valid uuid"},
+		{Pointer: "/role", Message: `must be "admin"`},
+	}
+	if !reflect.DeepEqual(violations, expected) {
+		t.Errorf("Expected %v, got %v", expected, violations)
+	}
+}
@@ -0,0 +1,306 @@
+// Package jsonschema validates JSON values against the subset of JSON Schema
+// (draft 2020-12) that request payloads and OpenAPI documents use, and infers
+// schemas from response and payload templates.
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Schema is a JSON Schema. The boolean schemas true and false decode to an
+// empty schema and to {"not": {}}.
+type Schema struct {
+	Ref              string             `json:"$ref,omitempty"`
+	Defs             map[string]*Schema `json:"$defs,omitempty"`
+	Type             Types              `json:"type,omitempty"`
+	Format           string             `json:"format,omitempty"`
+	Enum             []any              `json:"enum,omitempty"`
+	Const            any                `json:"const,omitempty"`
+	Pattern          string             `json:"pattern,omitempty"`
+	Minimum          *float64           `json:"minimum,omitempty"`
+	Maximum          *float64           `json:"maximum,omitempty"`
+	ExclusiveMinimum *float64           `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum *float64           `json:"exclusiveMaximum,omitempty"`
+	MinLength        *int               `json:"minLength,omitempty"`
+	MaxLength        *int               `json:"maxLength,omitempty"`
+	Items            *Schema            `json:"items,omitempty"`
+	MinItems         *int               `json:"minItems,omitempty"`
+	MaxItems         *int               `json:"maxItems,omitempty"`
+	Properties       map[string]*Schema `json:"properties,omitempty"`
+	Required         []string           `json:"required,omitempty"`
+	// AdditionalProperties applies to the properties not listed in Properties.
+	AdditionalProperties *Schema   `json:"additionalProperties,omitempty"`
+	AllOf                []*Schema `json:"allOf,omitempty"`
+	OneOf                []*Schema `json:"oneOf,omitempty"`
+	AnyOf                []*Schema `json:"anyOf,omitempty"`
+	Not                  *Schema   `json:"not,omitempty"`
+	// Nullable is the OpenAPI 3.0 way of allowing null.
+	Nullable bool `json:"nullable,omitempty"`
+	Example  any  `json:"example,omitempty"`
+
+	// pattern is Pattern compiled by Check
+	pattern *regexp.Regexp
+}
+
+func (s *Schema) UnmarshalJSON(data []byte) error {
+	var allow bool
+	if json.Unmarshal(data, &allow) == nil {
+		*s = Schema{}
+		if !allow {
+			s.Not = &Schema{}
+		}
+		return nil
+	}
+	// The alias has the fields of Schema without its methods
+	type schema Schema
+	return json.Unmarshal(data, (*schema)(s))
+}
+
+// Types is a schema type, written as "string" or as a list such as
+// ["string", "null"].
+type Types []string
+
+func (t *Types) UnmarshalJSON(data []byte) error {
+	var single string
+	if json.Unmarshal(data, &single) == nil {
+		*t = Types{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("schema type must be a string or a list of strings: %s", data)
+	}
+	*t = list
+	return nil
+}
+
+func (t Types) MarshalJSON() ([]byte, error) {
+	if len(t) == 1 {
+		return json.Marshal(t[0])
+	}
+	return json.Marshal([]string(t))
+}
+
+// Primary returns the first type other than "null", or "" if there is none.
+func (t Types) Primary() string {
+	for _, name := range t {
+		if name != "null" {
+			return name
+		}
+	}
+	return ""
+}
+
+// Known type names.
+var types = map[string]bool{
+	"null": true, "boolean": true, "object": true, "array": true,
+	"number": true, "integer": true, "string": true,
+}
+
+// Check reports the first problem that would keep the schema from validating:
+// an unknown type, a malformed pattern, a reference that does not resolve or
+// one that leads back to itself. It compiles the patterns for Validate, so it
+// is called once before the schema is used, not while it validates values.
+func (s *Schema) Check() error {
+	return s.check(s, "#", map[*Schema]loopState{})
+}
+
+// loopState tracks the search for reference loops.
+type loopState int
+
+const (
+	unvisited loopState = iota
+	visiting
+	looped
+	finished
+)
+
+func (s *Schema) check(root *Schema, location string, states map[*Schema]loopState) error {
+	for _, name := range s.Type {
+		if !types[name] {
+			return fmt.Errorf("%s: unknown type %q", location, name)
+		}
+	}
+	if s.Pattern != "" {
+		pattern, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("%s: invalid pattern: %w", location, err)
+		}
+		s.pattern = pattern
+	}
+	if s.Ref != "" {
+		if _, err := root.resolve(s.Ref); err != nil {
+			return fmt.Errorf("%s: %w", location, err)
+		}
+	}
+	if s.loops(root, states) {
+		return fmt.Errorf("%s: reference loop that never descends into a property or item", location)
+	}
+
+	children := map[string]*Schema{
+		"/items":                s.Items,
+		"/additionalProperties": s.AdditionalProperties,
+		"/not":                  s.Not,
+	}
+	for name, child := range s.Defs {
+		children["/$defs/"+escapePointer(name)] = child
+	}
+	for name, child := range s.Properties {
+		children["/properties/"+escapePointer(name)] = child
+	}
+	for keyword, list := range map[string][]*Schema{"allOf": s.AllOf, "oneOf": s.OneOf, "anyOf": s.AnyOf} {
+		for i, child := range list {
+			children[fmt.Sprintf("/%s/%d", keyword, i)] = child
+		}
+	}
+	for _, path := range slices.Sorted(maps.Keys(children)) {
+		if children[path] == nil {
+			continue
+		}
+		if err := children[path].check(root, location+path, states); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loops reports whether the schema reaches itself again through references,
+// allOf, anyOf, oneOf or not. Those apply to the same value, so validating it
+// would never end.
+func (s *Schema) loops(root *Schema, states map[*Schema]loopState) bool {
+	switch states[s] {
+	case visiting, looped:
+		states[s] = looped
+		return true
+	case finished:
+		return false
+	}
+	states[s] = visiting
+	next := slices.Concat(s.AllOf, s.AnyOf, s.OneOf)
+	if s.Not != nil {
+		next = append(next, s.Not)
+	}
+	if s.Ref != "" {
+		// References that don't resolve are reported on their own
+		if ref, err := root.resolve(s.Ref); err == nil {
+			next = append(next, ref)
+		}
+	}
+	for _, sub := range next {
+		if sub.loops(root, states) {
+			states[s] = looped
+			return true
+		}
+	}
+	states[s] = finished
+	return false
+}
+
+// resolve returns the schema a local reference such as "#/$defs/user" points to.
+func (s *Schema) resolve(ref string) (*Schema, error) {
+	if ref == "#" {
+		return s, nil
+	}
+	name, ok := strings.CutPrefix(ref, "#/$defs/")
+	if !ok {
+		return nil, fmt.Errorf("unsupported reference %q, only #/$defs/... is supported", ref)
+	}
+	def := s.Defs[unescapePointer(name)]
+	if def == nil {
+		return nil, fmt.Errorf("unknown reference %q", ref)
+	}
+	return def, nil
+}
+
+// escapePointer escapes a key for a JSON pointer (RFC 6901).
+func escapePointer(key string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
+
+func unescapePointer(key string) string {
+	return strings.NewReplacer("~1", "/", "~0", "~").Replace(key)
+}
+
+// Shape is what a schema says about the structure of values, gathered from
+// the schema, its $ref and the subschemas of allOf, anyOf and oneOf. It lets
+// untyped input such as query parameters be converted before validation.
+type Shape struct {
+	// Type is the first type declared, empty when there is none.
+	Type       string
+	root       *Schema
+	properties map[string]*Schema
+	items      *Schema
+	additional *Schema
+}
+
+// Shape returns the shape of s, resolving references against s.
+func (s *Schema) Shape() *Shape {
+	return shapeOf(s, s)
+}
+
+func shapeOf(s, root *Schema) *Shape {
+	shape := &Shape{root: root, properties: map[string]*Schema{}}
+	shape.gather(s, map[*Schema]bool{})
+	return shape
+}
+
+// gather adds what s declares, the first declaration of everything winning.
+func (sh *Shape) gather(s *Schema, seen map[*Schema]bool) {
+	if s == nil || seen[s] {
+		return
+	}
+	seen[s] = true
+	if sh.Type == "" {
+		sh.Type = s.Type.Primary()
+	}
+	for name, property := range s.Properties {
+		if _, ok := sh.properties[name]; !ok {
+			sh.properties[name] = property
+		}
+	}
+	if sh.items == nil {
+		sh.items = s.Items
+	}
+	if sh.additional == nil {
+		sh.additional = s.AdditionalProperties
+	}
+	if s.Ref != "" {
+		if ref, err := sh.root.resolve(s.Ref); err == nil {
+			sh.gather(ref, seen)
+		}
+	}
+	for _, sub := range slices.Concat(s.AllOf, s.AnyOf, s.OneOf) {
+		sh.gather(sub, seen)
+	}
+}
+
+// Properties returns the names of the declared properties, sorted.
+func (sh *Shape) Properties() []string {
+	return slices.Sorted(maps.Keys(sh.properties))
+}
+
+// Property returns the shape of a property, or of additionalProperties when
+// it is not declared. It returns nil when neither applies.
+func (sh *Shape) Property(name string) *Shape {
+	property := sh.properties[name]
+	if property == nil {
+		property = sh.additional
+	}
+	if property == nil {
+		return nil
+	}
+	return shapeOf(property, sh.root)
+}
+
+// Items returns the shape of array items, nil when they are not described.
+func (sh *Shape) Items() *Shape {
+	if sh.items == nil {
+		return nil
+	}
+	return shapeOf(sh.items, sh.root)
+}
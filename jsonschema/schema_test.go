@@ -0,0 +1,144 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestTypes(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		primary string
+		encoded string
+	}{
+		{name: "single", json: `"string"`, primary: "string", encoded: `"string"`},
+		{name: "list", json: `["null", "integer"]`, primary: "integer", encoded: `["null","integer"]`},
+		{name: "null_only", json: `["null"]`, primary: "", encoded: `"null"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var types Types
+			if err := json.Unmarshal([]byte(tt.json), &types); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got := types.Primary(); got != tt.primary {
+				t.Errorf("Expected primary %q, got %q", tt.primary, got)
+			}
+			encoded, err := json.Marshal(types)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if string(encoded) != tt.encoded {
+				t.Errorf("Expected %s, got %s", tt.encoded, encoded)
+			}
+		})
+	}
+
+	var types Types
+	if err := json.Unmarshal([]byte(`3`), &types); err == nil {
+		t.Error("Expected error for a numeric type")
+	}
+}
+
+func TestSchema_BooleanSchemas(t *testing.T) {
+	var schema Schema
+	if err := json.Unmarshal([]byte(`{"properties": {"any": true, "none": false}, "additionalProperties": false}`), &schema); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if any := schema.Properties["any"]; any.Not != nil {
+		t.Errorf("Expected true to allow anything, got %+v", any)
+	}
+	if none := schema.Properties["none"]; none.Not == nil {
+		t.Errorf("Expected false to allow nothing, got %+v", none)
+	}
+	if schema.AdditionalProperties == nil || schema.AdditionalProperties.Not == nil {
+		t.Errorf("Expected additionalProperties false to allow nothing, got %+v", schema.AdditionalProperties)
+	}
+	if err := json.Unmarshal([]byte(`{"type": 3}`), &schema); err == nil {
+		t.Error("Expected error for an invalid schema")
+	}
+}
+
+func TestSchema_Check(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		error  string
+	}{
+		{name: "valid", schema: `{"$defs": {"a/b": {"type": "string"}}, "properties": {"x": {"$ref": "#/$defs/a~1b"}, "self": {"$ref": "#"}}}`},
+		{name: "unknown_type", schema: `{"type": "text"}`, error: `#: unknown type "text"`},
+		{name: "invalid_pattern", schema: `{"properties": {"a~b": {"pattern": "("}}}`, error: "#/properties/a~0b: invalid pattern"},
+		{name: "unknown_reference", schema: `{"items": {"$ref": "#/$defs/missing"}}`, error: `#/items: unknown reference "#/$defs/missing"`},
+		{name: "unsupported_reference", schema: `{"allOf": [{"$ref": "other.json"}]}`, error: "#/allOf/0: unsupported reference"},
+		{name: "nested_definition", schema: `{"$defs": {"a": {"not": {"type": "bad"}}}}`, error: "#/$defs/a/not: unknown type"},
+		{name: "self_reference", schema: `{"$ref": "#"}`, error: "#: reference loop"},
+		{name: "definition_loop", schema: `{"$defs": {"a": {"$ref": "#/$defs/a"}}, "properties": {"x": {"$ref": "#/$defs/a"}}}`, error: "#/$defs/a: reference loop"},
+		{name: "combinator_loop", schema: `{"$defs": {"a": {"allOf": [{"$ref": "#/$defs/b"}]}, "b": {"anyOf": [{"not": {"$ref": "#/$defs/a"}}]}}}`, error: "#/$defs/a: reference loop"},
+		{name: "oneof_loop", schema: `{"oneOf": [{"$ref": "#"}]}`, error: "#: reference loop"},
+		{name: "recursion_through_items", schema: `{"$defs": {"tree": {"type": "object", "properties": {"children": {"items": {"$ref": "#/$defs/tree"}}}}}, "allOf": [{"$ref": "#/$defs/tree"}, {"$ref": "#/$defs/tree"}]}`},
+		{name: "combinators", schema: `{"oneOf": [{}], "anyOf": [{"additionalProperties": {"type": "bad"}}]}`, error: "#/anyOf/0/additionalProperties: unknown type"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var schema Schema
+			if err := json.Unmarshal([]byte(tt.schema), &schema); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			err := schema.Check()
+			if tt.error == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.error) {
+				t.Errorf("Expected error containing %q, got %v", tt.error, err)
+			}
+		})
+	}
+}
+
+func TestSchema_Shape(t *testing.T) {
+	var schema Schema
+	err := json.Unmarshal([]byte(`{
+		"$defs": {
+			"n": {"type": "integer"},
+			"base": {"$ref": "#", "properties": {"id": {"type": "string"}, "limit": {"type": "string"}}}
+		},
+		"$ref": "#/$defs/base",
+		"properties": {"limit": {"$ref": "#/$defs/n"}},
+		"oneOf": [{"$ref": "#/$defs/missing"}, {"type": "object", "properties": {"tags": {"items": {"allOf": [{"$ref": "#/$defs/n"}]}}}}],
+		"additionalProperties": {"type": "boolean"}
+	}`), &schema)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	shape := schema.Shape()
+	if shape.Type != "object" {
+		t.Errorf("Expected type object, got %q", shape.Type)
+	}
+	if got := strings.Join(shape.Properties(), ","); got != "id,limit,tags" {
+		t.Errorf("Expected properties id,limit,tags, got %s", got)
+	}
+	// The schema's own declaration wins over the referenced one
+	if got := shape.Property("limit").Type; got != "integer" {
+		t.Errorf("Expected limit to be an integer, got %q", got)
+	}
+	if got := shape.Property("tags").Items().Type; got != "integer" {
+		t.Errorf("Expected tags of integers, got %q", got)
+	}
+	if shape.Property("id").Items() != nil {
+		t.Error("Expected no items for a string")
+	}
+	if got := shape.Property("other").Type; got != "boolean" {
+		t.Errorf("Expected additional properties to be booleans, got %q", got)
+	}
+	if (&Schema{}).Shape().Property("other") != nil {
+		t.Error("Expected no shape for an undeclared property")
+	}
+}
@@ -0,0 +1,296 @@
+package jsonschema
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Violation is a value that does not match its schema.
+type Violation struct {
+	// Pointer is the JSON pointer (RFC 6901) of the value, "" for the root.
+	Pointer string `json:"pointer"`
+	Message string `json:"message"`
+}
+
+func (v Violation) String() string {
+	if v.Pointer == "" {
+		return v.Message
+	}
+	return v.Pointer + ": " + v.Message
+}
+
+// Validate checks value, as decoded by encoding/json, against the schema and
+// returns every violation. The schema is expected to pass Check.
+func (s *Schema) Validate(value any) []Violation {
+	v := &validator{root: s}
+	v.validate(s, value, "")
+	return v.violations
+}
+
+type validator struct {
+	root       *Schema
+	violations []Violation
+}
+
+func (v *validator) fail(pointer, format string, args ...any) {
+	v.violations = append(v.violations, Violation{Pointer: pointer, Message: fmt.Sprintf(format, args...)})
+}
+
+// matches reports whether value matches the schema, without recording violations.
+func (v *validator) matches(s *Schema, value any, pointer string) bool {
+	sub := &validator{root: v.root}
+	sub.validate(s, value, pointer)
+	return len(sub.violations) == 0
+}
+
+func (v *validator) validate(s *Schema, value any, pointer string) {
+	if s.Ref != "" {
+		// References are resolved by Check
+		ref, _ := v.root.resolve(s.Ref)
+		v.validate(ref, value, pointer)
+	}
+	if s.Not != nil && v.matches(s.Not, value, pointer) {
+		v.fail(pointer, "must not match the schema")
+	}
+	for _, sub := range s.AllOf {
+		v.validate(sub, value, pointer)
+	}
+	if len(s.AnyOf) > 0 && !slices.ContainsFunc(s.AnyOf, func(sub *Schema) bool { return v.matches(sub, value, pointer) }) {
+		v.fail(pointer, "must match at least one schema of anyOf")
+	}
+	if len(s.OneOf) > 0 {
+		matched := 0
+		for _, sub := range s.OneOf {
+			if v.matches(sub, value, pointer) {
+				matched++
+			}
+		}
+		if matched != 1 {
+			v.fail(pointer, "must match exactly one schema of oneOf, matched %d", matched)
+		}
+	}
+
+	if s.Const != nil && !equal(value, s.Const) {
+		v.fail(pointer, "must be %s", describe(s.Const))
+	}
+	if len(s.Enum) > 0 && !slices.ContainsFunc(s.Enum, func(allowed any) bool { return equal(value, allowed) }) {
+		v.fail(pointer, "must be one of %s", describeAll(s.Enum))
+	}
+
+	if len(s.Type) > 0 && !slices.ContainsFunc(s.Type, func(name string) bool { return hasType(value, name) }) {
+		if !(value == nil && s.Nullable) {
+			v.fail(pointer, "must be of type %s, got %s", strings.Join(s.Type, " or "), typeOf(value))
+			return
+		}
+	}
+
+	switch t := value.(type) {
+	case string:
+		v.validateString(s, t, pointer)
+	case float64:
+		v.validateNumber(s, t, pointer)
+	case []any:
+		v.validateArray(s, t, pointer)
+	case map[string]any:
+		v.validateObject(s, t, pointer)
+	}
+}
+
+func (v *validator) validateString(s *Schema, value string, pointer string) {
+	length := utf8.RuneCountInString(value)
+	if s.MinLength != nil && length < *s.MinLength {
+		v.fail(pointer, "must be at least %d characters long", *s.MinLength)
+	}
+	if s.MaxLength != nil && length > *s.MaxLength {
+		v.fail(pointer, "must be at most %d characters long", *s.MaxLength)
+	}
+	if s.Pattern != "" && !s.compiledPattern().MatchString(value) {
+		v.fail(pointer, "must match the pattern %s", s.Pattern)
+	}
+	if check, ok := formats[s.Format]; ok && !check(value) {
+		v.fail(pointer, "must be a valid %s", s.Format)
+	}
+}
+
+// compiledPattern returns the pattern compiled by Check. Schemas that did not
+// go through Check, such as inferred ones, compile it on every use.
+func (s *Schema) compiledPattern() *regexp.Regexp {
+	if s.pattern != nil {
+		return s.pattern
+	}
+	return regexp.MustCompile(s.Pattern)
+}
+
+func (v *validator) validateNumber(s *Schema, value float64, pointer string) {
+	if s.Minimum != nil && value < *s.Minimum {
+		v.fail(pointer, "must be at least %v", *s.Minimum)
+	}
+	if s.Maximum != nil && value > *s.Maximum {
+		v.fail(pointer, "must be at most %v", *s.Maximum)
+	}
+	if s.ExclusiveMinimum != nil && value <= *s.ExclusiveMinimum {
+		v.fail(pointer, "must be greater than %v", *s.ExclusiveMinimum)
+	}
+	if s.ExclusiveMaximum != nil && value >= *s.ExclusiveMaximum {
+		v.fail(pointer, "must be less than %v", *s.ExclusiveMaximum)
+	}
+	if bits, ok := integerFormats[s.Format]; ok && (value != math.Trunc(value) || value < -math.Pow(2, bits-1) || value >= math.Pow(2, bits-1)) {
+		v.fail(pointer, "must be a valid %s", s.Format)
+	}
+}
+
+func (v *validator) validateArray(s *Schema, value []any, pointer string) {
+	if s.MinItems != nil && len(value) < *s.MinItems {
+		v.fail(pointer, "must have at least %d items", *s.MinItems)
+	}
+	if s.MaxItems != nil && len(value) > *s.MaxItems {
+		v.fail(pointer, "must have at most %d items", *s.MaxItems)
+	}
+	if s.Items != nil {
+		for i, item := range value {
+			v.validate(s.Items, item, pointer+"/"+strconv.Itoa(i))
+		}
+	}
+}
+
+func (v *validator) validateObject(s *Schema, value map[string]any, pointer string) {
+	for _, name := range s.Required {
+		if _, ok := value[name]; !ok {
+			v.fail(pointer+"/"+escapePointer(name), "is required")
+		}
+	}
+	for _, name := range sortedKeys(value) {
+		property := s.Properties[name]
+		if property == nil {
+			property = s.AdditionalProperties
+		}
+		if property != nil {
+			v.validate(property, value[name], pointer+"/"+escapePointer(name))
+		}
+	}
+}
+
+// hasType reports whether a decoded JSON value is of the named type.
+func hasType(value any, name string) bool {
+	switch name {
+	case "integer":
+		f, ok := value.(float64)
+		return ok && f == math.Trunc(f)
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	default:
+		return typeOf(value) == name
+	}
+}
+
+// typeOf returns the JSON type of a decoded value.
+func typeOf(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+// equal compares decoded JSON values. Schema values may be of other Go types,
+// such as int, so both sides are compared in their JSON form.
+func equal(a, b any) bool {
+	return reflect.DeepEqual(normalize(a), normalize(b))
+}
+
+func normalize(value any) any {
+	rv := reflect.ValueOf(value)
+	switch {
+	case rv.CanInt():
+		return float64(rv.Int())
+	case rv.CanUint():
+		return float64(rv.Uint())
+	case rv.CanFloat():
+		return rv.Float()
+	}
+	return value
+}
+
+func describe(value any) string {
+	if s, ok := value.(string); ok {
+		return strconv.Quote(s)
+	}
+	return fmt.Sprint(value)
+}
+
+func describeAll(values []any) string {
+	described := make([]string, len(values))
+	for i, value := range values {
+		described[i] = describe(value)
+	}
+	return strings.Join(described, ", ")
+}
+
+var (
+	uuidPattern     = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	hostnamePattern = regexp.MustCompile(`^(?i)[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?(\.[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?)*$`)
+)
+
+// Checks for string formats. Other formats are annotations only.
+var formats = map[string]func(string) bool{
+	"email": func(s string) bool {
+		address, err := mail.ParseAddress(s)
+		return err == nil && address.Address == s
+	},
+	"uuid": uuidPattern.MatchString,
+	"uri": func(s string) bool {
+		u, err := url.Parse(s)
+		return err == nil && u.Scheme != ""
+	},
+	"hostname": func(s string) bool {
+		return len(s) <= 253 && hostnamePattern.MatchString(s)
+	},
+	"ipv4": func(s string) bool {
+		ip := net.ParseIP(s)
+		return ip != nil && ip.To4() != nil && !strings.Contains(s, ":")
+	},
+	"ipv6": func(s string) bool {
+		return net.ParseIP(s) != nil && strings.Contains(s, ":")
+	},
+	"date-time": func(s string) bool {
+		_, err := time.Parse(time.RFC3339, s)
+		return err == nil
+	},
+	"date": func(s string) bool {
+		_, err := time.Parse(time.DateOnly, s)
+		return err == nil
+	},
+	"time": func(s string) bool {
+		_, err := time.Parse("15:04:05Z07:00", s)
+		if err != nil {
+			_, err = time.Parse(time.TimeOnly, s)
+		}
+		return err == nil
+	},
+}
+
+// Bit sizes of the integer formats of OpenAPI.
+var integerFormats = map[string]float64{
+	"int32": 32,
+	"int64": 64,
+}
@@ -0,0 +1,162 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestSchema_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		schema   string
+		value    string
+		expected []string
+	}{
+		{name: "any", schema: `{}`, value: `{"a": [1, "b"]}`},
+		{name: "type", schema: `{"type": "integer"}`, value: `"abc"`, expected: []string{"must be of type integer, got string"}},
+		{name: "integer", schema: `{"type": "integer"}`, value: `2.5`, expected: []string{"must be of type integer, got number"}},
+		{name: "type_list", schema: `{"type": ["string", "null"]}`, value: `null`},
+		{name: "nullable", schema: `{"type": "string", "nullable": true}`, value: `null`},
+		{name: "type_names", schema: `{"type": ["boolean", "array", "object", "number"]}`, value: `true`},
+		{name: "wrong_object", schema: `{"type": "object"}`, value: `[]`, expected: []string{"must be of type object, got array"}},
+		{name: "wrong_null", schema: `{"type": "array"}`, value: `null`, expected: []string{"must be of type array, got null"}},
+		{name: "enum", schema: `{"enum": ["a", 1]}`, value: `"b"`, expected: []string{`must be one of "a", 1`}},
+		{name: "enum_match", schema: `{"enum": ["a", 1]}`, value: `1`},
+		{name: "const", schema: `{"const": "admin"}`, value: `"user"`, expected: []string{`must be "admin"`}},
+		{name: "const_number", schema: `{"const": 3}`, value: `4`, expected: []string{"must be 3"}},
+		{
+			name:     "string_keywords",
+			schema:   `{"minLength": 3, "maxLength": 4, "pattern": "^[a-z]+$"}`,
+			value:    `"A"`,
+			expected: []string{"must be at least 3 characters long", "must match the pattern ^[a-z]+$"},
+		},
+		{name: "max_length", schema: `{"maxLength": 2}`, value: `"héé"`, expected: []string{"must be at most 2 characters long"}},
+		{name: "multibyte_length", schema: `{"maxLength": 3}`, value: `"héé"`},
+		{
+			name:     "number_keywords",
+			schema:   `{"minimum": 1, "maximum": 2, "exclusiveMinimum": 5, "exclusiveMaximum": 0}`,
+			value:    `3`,
+			expected: []string{"must be at most 2", "must be greater than 5", "must be less than 0"},
+		},
+		{name: "minimum", schema: `{"minimum": 1}`, value: `0`, expected: []string{"must be at least 1"}},
+		{name: "int32", schema: `{"format": "int32"}`, value: `3000000000`, expected: []string{"must be a valid int32"}},
+		{name: "int64", schema: `{"format": "int64"}`, value: `1.5`, expected: []string{"must be a valid int64"}},
+		{
+			name:     "array_keywords",
+			schema:   `{"minItems": 3, "items": {"type": "string"}}`,
+			value:    `["a", 1]`,
+			expected: []string{"must have at least 3 items", "/1: must be of type string, got number"},
+		},
+		{name: "max_items", schema: `{"maxItems": 1}`, value: `[1, 2]`, expected: []string{"must have at most 1 items"}},
+		{
+			name:     "object_keywords",
+			schema:   `{"required": ["id", "a/b"], "properties": {"id": {"type": "string"}}, "additionalProperties": {"type": "number"}}`,
+			value:    `{"id": 1, "x": "y"}`,
+			expected: []string{"/a~1b: is required", "/id: must be of type string, got number", "/x: must be of type number, got string"},
+		},
+		{name: "no_additional_properties", schema: `{"properties": {"a": {}}, "additionalProperties": false}`, value: `{"a": 1, "b": 2}`, expected: []string{"/b: must not match the schema"}},
+		{
+			name:     "nested",
+			schema:   `{"properties": {"user": {"properties": {"tags": {"items": {"format": "email"}}}}}}`,
+			value:    `{"user": {"tags": ["ada@example.com", "nope"]}}`,
+			expected: []string{"/user/tags/1: must be a valid email"},
+		},
+		{name: "all_of", schema: `{"allOf": [{"minimum": 2}, {"maximum": 0}]}`, value: `1`, expected: []string{"must be at least 2", "must be at most 0"}},
+		{name: "any_of", schema: `{"anyOf": [{"type": "string"}, {"type": "boolean"}]}`, value: `1`, expected: []string{"must match at least one schema of anyOf"}},
+		{name: "any_of_match", schema: `{"anyOf": [{"type": "string"}, {"type": "number"}]}`, value: `1`},
+		{name: "one_of", schema: `{"oneOf": [{"type": "number"}, {"type": "integer"}]}`, value: `1`, expected: []string{"must match exactly one schema of oneOf, matched 2"}},
+		{name: "one_of_match", schema: `{"oneOf": [{"type": "number"}, {"type": "string"}]}`, value: `1`},
+		{name: "not", schema: `{"not": {"type": "string"}}`, value: `"a"`, expected: []string{"must not match the schema"}},
+		{
+			name:     "reference",
+			schema:   `{"$defs": {"id": {"type": "integer"}}, "properties": {"id": {"$ref": "#/$defs/id"}, "children": {"items": {"$ref": "#"}}}}`,
+			value:    `{"id": "a", "children": [{"id": 2}, {"id": true}]}`,
+			expected: []string{"/children/1/id: must be of type integer, got boolean", "/id: must be of type integer, got string"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var schema Schema
+			if err := json.Unmarshal([]byte(tt.schema), &schema); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			var value any
+			if err := json.Unmarshal([]byte(tt.value), &value); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			var got []string
+			for _, violation := range schema.Validate(value) {
+				got = append(got, violation.String())
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestSchema_Formats(t *testing.T) {
+	tests := []struct {
+		format  string
+		valid   []string
+		invalid []string
+	}{
+		{format: "email", valid: []string{"ada@example.com"}, invalid: []string{"ada", "Ada <ada@example.com>"}},
+		{format: "uuid", valid: []string{"9b2c4f1e-8d3a-4c5b-9e6f-0a1b2c3d4e5f"}, invalid: []string{"9b2c4f1e"}},
+		{format: "uri", valid: []string{"https://example.com/a"}, invalid: []string{"/relative", "%zz"}},
+		{format: "hostname", valid: []string{"api.example.com"}, invalid: []string{"-bad-.com"}},
+		{format: "ipv4", valid: []string{"10.0.0.1"}, invalid: []string{"::1", "::ffff:10.0.0.1", "300.0.0.1"}},
+		{format: "ipv6", valid: []string{"::1"}, invalid: []string{"10.0.0.1"}},
+		{format: "date-time", valid: []string{"2024-05-01T12:00:00Z"}, invalid: []string{"2024-05-01"}},
+		{format: "date", valid: []string{"2024-05-01"}, invalid: []string{"2024-13-01"}},
+		{format: "time", valid: []string{"12:00:00", "12:00:00+02:00"}, invalid: []string{"25:00:00"}},
+		{format: "custom", valid: []string{"anything"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			schema := &Schema{Format: tt.format}
+			for _, value := range tt.valid {
+				if violations := schema.Validate(value); len(violations) != 0 {
+					t.Errorf("Expected %q to be valid, got %v", value, violations)
+				}
+			}
+			for _, value := range tt.invalid {
+				if violations := schema.Validate(value); len(violations) != 1 {
+					t.Errorf("Expected %q to be invalid", value)
+				}
+			}
+		})
+	}
+}
+
+func TestSchema_ValidateGoValues(t *testing.T) {
+	schema := &Schema{Enum: []any{2, uint8(3)}}
+	for _, value := range []any{2.0, 3.0} {
+		if violations := schema.Validate(value); len(violations) != 0 {
+			t.Errorf("Expected %v to match, got %v", value, violations)
+		}
+	}
+	if violations := schema.Validate(4.0); len(violations) != 1 {
+		t.Errorf("Expected one violation, got %v", violations)
+	}
+}
+
+func TestSchema_ValidateCheckedPattern(t *testing.T) {
+	schema := &Schema{Properties: map[string]*Schema{"code": {Pattern: "^[A-Z]{3}$"}}}
+	if err := schema.Check(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if schema.Properties["code"].pattern == nil {
+		t.Fatal("Expected Check to compile the pattern")
+	}
+	if violations := schema.Validate(map[string]any{"code": "abc"}); len(violations) != 1 {
+		t.Errorf("Expected one violation, got %v", violations)
+	}
+	if violations := schema.Validate(map[string]any{"code": "ABC"}); len(violations) != 0 {
+		t.Errorf("Expected no violations, got %v", violations)
+	}
+}
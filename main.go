@@ -18,6 +18,7 @@ func Main() {
 	// Define command-line flags
 	port := flag.Int("p", 8080, "port number")
 	configPath := flag.String("c", "config.json", "path to config file")
+	seed := flag.String("seed", "", `data generator seed, a number or "random" (overrides the config)`)
 	flag.Parse()
 
 	// Check if PORT environment variable is set and use it if not overridden by a command-line flag
@@ -36,7 +37,13 @@ func Main() {
 		*configPath = envConfig
 	}
 
-	server, err := app.Run(*configPath, *port)
+	// Check if SEED environment variable is set and use it if not overridden by a command-line flag
+	envSeed := os.Getenv("SEED")
+	if envSeed != "" {
+		*seed = envSeed
+	}
+
+	server, err := app.Run(*configPath, *port, app.WithSeed(*seed))
 	if err != nil {
 		log.Fatalf("Error starting server: %v", err)
 	}
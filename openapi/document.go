@@ -0,0 +1,159 @@
+// Package openapi converts between OpenAPI 3 documents and endpoint configs.
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/paqstd-team/fake-cli/jsonschema"
+	"gopkg.in/yaml.v3"
+)
+
+// Document is the part of an OpenAPI 3 document that describes endpoints.
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components *Components          `json:"components,omitempty"`
+}
+
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type Components struct {
+	Schemas       map[string]*Schema      `json:"schemas,omitempty"`
+	Responses     map[string]*Response    `json:"responses,omitempty"`
+	RequestBodies map[string]*RequestBody `json:"requestBodies,omitempty"`
+}
+
+type PathItem struct {
+	Get        *Operation   `json:"get,omitempty"`
+	Put        *Operation   `json:"put,omitempty"`
+	Post       *Operation   `json:"post,omitempty"`
+	Delete     *Operation   `json:"delete,omitempty"`
+	Patch      *Operation   `json:"patch,omitempty"`
+	Head       *Operation   `json:"head,omitempty"`
+	Options    *Operation   `json:"options,omitempty"`
+	Parameters []*Parameter `json:"parameters,omitempty"`
+}
+
+// Operations returns the operations of the path item by HTTP method, in a fixed order.
+func (p *PathItem) Operations() []MethodOperation {
+	var operations []MethodOperation
+	for _, o := range []MethodOperation{
+		{"GET", p.Get}, {"POST", p.Post}, {"PUT", p.Put}, {"PATCH", p.Patch},
+		{"DELETE", p.Delete}, {"HEAD", p.Head}, {"OPTIONS", p.Options},
+	} {
+		if o.Operation != nil {
+			operations = append(operations, o)
+		}
+	}
+	return operations
+}
+
+// MethodOperation is an operation with the HTTP method it is served for.
+type MethodOperation struct {
+	Method    string
+	Operation *Operation
+}
+
+type Operation struct {
+	OperationID string               `json:"operationId,omitempty"`
+	Summary     string               `json:"summary,omitempty"`
+	Parameters  []*Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required,omitempty"`
+	Schema   *Schema `json:"schema,omitempty"`
+}
+
+type RequestBody struct {
+	Ref      string                `json:"$ref,omitempty"`
+	Required bool                  `json:"required,omitempty"`
+	Content  map[string]*MediaType `json:"content,omitempty"`
+}
+
+type Response struct {
+	Ref         string                `json:"$ref,omitempty"`
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema,omitempty"`
+}
+
+// Schema is the JSON Schema of a parameter, request or response body.
+type Schema = jsonschema.Schema
+
+// Types is the type of a Schema.
+type Types = jsonschema.Types
+
+// Load reads an OpenAPI 3 document from a JSON or YAML file.
+func Load(path string) (*Document, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return doc, nil
+}
+
+// Parse decodes an OpenAPI 3 document written as JSON or YAML.
+func Parse(data []byte) (*Document, error) {
+	// YAML is a superset of JSON, so one decoder reads both
+	var raw any
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	normalized, err := json.Marshal(stringKeys(raw))
+	if err != nil {
+		return nil, err
+	}
+
+	var doc Document
+	if err := json.Unmarshal(normalized, &doc); err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		return nil, fmt.Errorf("unsupported OpenAPI version %q, only 3.x is supported", doc.OpenAPI)
+	}
+	return &doc, nil
+}
+
+// stringKeys converts YAML mappings with non-string keys, such as the status
+// code 200, into objects that JSON can represent.
+func stringKeys(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, item := range v {
+			v[key] = stringKeys(item)
+		}
+		return v
+	case map[any]any:
+		result := make(map[string]any, len(v))
+		for key, item := range v {
+			result[fmt.Sprint(key)] = stringKeys(item)
+		}
+		return result
+	case []any:
+		for i, item := range v {
+			v[i] = stringKeys(item)
+		}
+		return v
+	default:
+		return v
+	}
+}
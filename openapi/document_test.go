@@ -0,0 +1,123 @@
+package openapi
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const petsYAML = `
+openapi: 3.0.3
+info:
+  title: Pets
+  version: "1.0"
+paths:
+  /pets:
+    get:
+      responses:
+        200:
+          description: OK
+          content:
+            application/json:
+              schema:
+                type: array
+                items:
+                  $ref: '#/components/schemas/Pet'
+components:
+  schemas:
+    Pet:
+      type: object
+      properties:
+        id:
+          type: string
+          format: uuid
+`
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  string
+		error string
+	}{
+		{name: "yaml", data: petsYAML},
+		{name: "json", data: `{"openapi": "3.1.0", "info": {"title": "Pets", "version": "1"}, "paths": {}}`},
+		{name: "invalid_yaml", data: "openapi: [", error: "yaml"},
+		{name: "invalid_document", data: `{"openapi": "3.0.0", "paths": []}`, error: "cannot unmarshal"},
+		{name: "unsupported_version", data: `{"swagger": "2.0"}`, error: "unsupported OpenAPI version"},
+		{name: "unrepresentable_value", data: "openapi: 3.0.0\nx-value: .nan", error: "unsupported value"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Parse([]byte(tt.data))
+			if tt.error != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.error) {
+					t.Fatalf("Expected error containing %q, got %v", tt.error, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if doc.Info.Title != "Pets" {
+				t.Errorf("Expected title Pets, got %q", doc.Info.Title)
+			}
+		})
+	}
+}
+
+func TestParse_StatusCodeKeys(t *testing.T) {
+	doc, err := Parse([]byte(petsYAML))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	get := doc.Paths["/pets"].Get
+	if get == nil || get.Responses["200"] == nil {
+		t.Fatalf("Expected a 200 response, got %+v", get)
+	}
+	items := get.Responses["200"].Content["application/json"].Schema.Items
+	if items.Ref != "#/components/schemas/Pet" {
+		t.Errorf("Expected item reference, got %q", items.Ref)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "pets.yaml")
+	if err := os.WriteFile(valid, []byte(petsYAML), 0o600); err != nil {
+		t.Fatalf("Failed to write document: %v", err)
+	}
+	invalid := filepath.Join(dir, "invalid.json")
+	if err := os.WriteFile(invalid, []byte(`{"openapi": "2.0"}`), 0o600); err != nil {
+		t.Fatalf("Failed to write document: %v", err)
+	}
+
+	if _, err := Load(valid); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if _, err := Load(filepath.Join(dir, "missing.yaml")); err == nil {
+		t.Error("Expected error for missing file")
+	}
+	if _, err := Load(invalid); err == nil || !strings.Contains(err.Error(), invalid) {
+		t.Errorf("Expected error naming the file, got %v", err)
+	}
+}
+
+func TestPathItem_Operations(t *testing.T) {
+	op := &Operation{}
+	item := &PathItem{Get: op, Put: op, Post: op, Delete: op, Patch: op, Head: op, Options: op}
+
+	var methods []string
+	for _, o := range item.Operations() {
+		methods = append(methods, o.Method)
+	}
+	expected := []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"}
+	if !reflect.DeepEqual(methods, expected) {
+		t.Errorf("Expected %v, got %v", expected, methods)
+	}
+	if got := (&PathItem{}).Operations(); len(got) != 0 {
+		t.Errorf("Expected no operations, got %v", got)
+	}
+}
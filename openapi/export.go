@@ -0,0 +1,229 @@
+package openapi
+
+import (
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/paqstd-team/fake-cli/config"
+	"github.com/paqstd-team/fake-cli/jsonschema"
+)
+
+// Version is the OpenAPI version of exported documents.
+const Version = "3.0.3"
+
+// Export describes the endpoints as an OpenAPI document. Schemas are inferred
+// from the templates, using sample to tell data types from literals.
+func Export(endpoints []config.Endpoint, sample jsonschema.Sampler) *Document {
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    Info{Title: "fake-cli", Version: "1.0.0"},
+		Paths:   make(map[string]*PathItem),
+	}
+	e := exporter{sample: sample}
+	for _, endpoint := range endpoints {
+		path, parameters := pathParameters(endpoint.URL)
+		item := doc.Paths[path]
+		if item == nil {
+			item = &PathItem{}
+		}
+		if item.setOperation(endpoint.Type, e.operation(endpoint, parameters)) {
+			doc.Paths[path] = item
+		}
+	}
+	return doc
+}
+
+// setOperation sets the operation for method and reports whether the method
+// can be described.
+func (p *PathItem) setOperation(method string, op *Operation) bool {
+	operations := map[string]**Operation{
+		"": &p.Get, http.MethodGet: &p.Get, http.MethodPost: &p.Post, http.MethodPut: &p.Put,
+		http.MethodPatch: &p.Patch, http.MethodDelete: &p.Delete, http.MethodHead: &p.Head,
+		http.MethodOptions: &p.Options,
+	}
+	field, ok := operations[method]
+	if ok {
+		*field = op
+	}
+	return ok
+}
+
+// pathParameters turns a route such as /users/{id:[0-9]+} into the OpenAPI
+// path /users/{id} and its parameters.
+func pathParameters(url string) (string, []*Parameter) {
+	var path strings.Builder
+	var parameters []*Parameter
+	for {
+		start := strings.Index(url, "{")
+		if start < 0 {
+			path.WriteString(url)
+			return path.String(), parameters
+		}
+		end := closingBrace(url, start)
+		if end < 0 {
+			path.WriteString(url)
+			return path.String(), parameters
+		}
+		name, pattern, _ := strings.Cut(url[start+1:end], ":")
+		schema := &Schema{Type: Types{"string"}}
+		if pattern != "" {
+			schema.Pattern = "^" + pattern + "$"
+		}
+		parameters = append(parameters, &Parameter{Name: name, In: "path", Required: true, Schema: schema})
+		path.WriteString(url[:start] + "{" + name + "}")
+		url = url[end+1:]
+	}
+}
+
+// closingBrace returns the index of the brace that closes the one at start,
+// allowing for braces in a variable's pattern, or -1.
+func closingBrace(s string, start int) int {
+	depth := 0
+	for i := start; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+type exporter struct {
+	sample jsonschema.Sampler
+}
+
+func (e exporter) operation(endpoint config.Endpoint, parameters []*Parameter) *Operation {
+	op := &Operation{Parameters: parameters, Responses: make(map[string]*Response)}
+
+	list := false
+	for i, reply := range replies(endpoint) {
+		// The upstream answers for passthrough endpoints
+		if i == 0 && endpoint.Passthrough {
+			op.Responses["default"] = &Response{Description: "Passed through to the upstream"}
+			continue
+		}
+		status := reply.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		code := strconv.Itoa(status)
+		if op.Responses[code] != nil {
+			continue
+		}
+		response := &Response{Description: http.StatusText(status)}
+		switch {
+		case reply.Response == nil || status == http.StatusNoContent:
+		case endpoint.Stream != nil && status == http.StatusOK:
+			// Every event carries one generated response
+			response.Content = map[string]*MediaType{"text/event-stream": {Schema: jsonschema.FromResponse(reply.Response, e.sample)}}
+		default:
+			response.Content = jsonContent(jsonschema.FromResponse(reply.Response, e.sample))
+			_, isList := reply.Response.([]any)
+			list = list || isList
+		}
+		op.Responses[code] = response
+	}
+
+	op.Parameters = append(op.Parameters, schemaParameters(endpoint.Query, "query")...)
+	op.Parameters = append(op.Parameters, schemaParameters(endpoint.HeaderSchema, "header")...)
+	if list {
+		for _, name := range []string{"page", "per_page"} {
+			if endpoint.Query != nil && endpoint.Query.Properties[name] != nil {
+				continue
+			}
+			op.Parameters = append(op.Parameters, &Parameter{Name: name, In: "query", Schema: &Schema{Type: Types{"integer"}, Minimum: floatPtr(1)}})
+		}
+	}
+
+	if slices.Contains([]string{http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}, endpoint.Type) {
+		schema := endpoint.PayloadSchema
+		if schema == nil && endpoint.Payload != nil {
+			schema = jsonschema.FromPayload(endpoint.Payload, e.sample)
+		}
+		if schema != nil {
+			op.RequestBody = &RequestBody{Required: true, Content: jsonContent(schema)}
+		}
+		if len(endpoint.Files) > 0 {
+			op.RequestBody = &RequestBody{Required: true, Content: map[string]*MediaType{"multipart/form-data": {Schema: formSchema(schema, endpoint.Files)}}}
+		}
+	}
+	return op
+}
+
+// formSchema describes a multipart body: the fields of the payload schema,
+// which may be nil, and the uploaded files.
+func formSchema(payload *Schema, files map[string]config.Upload) *Schema {
+	schema := &Schema{Type: Types{"object"}, Properties: map[string]*Schema{}}
+	if payload != nil {
+		maps.Copy(schema.Properties, payload.Properties)
+		schema.Required = slices.Clone(payload.Required)
+	}
+	for _, field := range slices.Sorted(maps.Keys(files)) {
+		upload := files[field]
+		file := &Schema{Type: Types{"string"}, Format: "binary"}
+		if upload.MaxCount != 1 {
+			file = &Schema{Type: Types{"array"}, Items: file}
+			if upload.MinCount > 0 {
+				file.MinItems = &upload.MinCount
+			}
+			if upload.MaxCount > 0 {
+				file.MaxItems = &upload.MaxCount
+			}
+		}
+		schema.Properties[field] = file
+		if upload.MinCount > 0 {
+			schema.Required = append(schema.Required, field)
+		}
+	}
+	return schema
+}
+
+// schemaParameters lists the properties of a query or headers schema, which may
+// be nil, as parameters sorted by name.
+func schemaParameters(schema *Schema, in string) []*Parameter {
+	if schema == nil {
+		return nil
+	}
+	var parameters []*Parameter
+	for _, name := range slices.Sorted(maps.Keys(schema.Properties)) {
+		parameters = append(parameters, &Parameter{
+			Name:     name,
+			In:       in,
+			Required: slices.Contains(schema.Required, name),
+			Schema:   schema.Properties[name],
+		})
+	}
+	return parameters
+}
+
+// replies returns every reply the endpoint can send: its own, then those of
+// cases, variants, scenario states and the sequence.
+func replies(endpoint config.Endpoint) []config.Reply {
+	all := []config.Reply{endpoint.Reply()}
+	for _, c := range endpoint.Cases {
+		all = append(all, c.Reply)
+	}
+	for _, name := range slices.Sorted(maps.Keys(endpoint.Variants)) {
+		all = append(all, endpoint.Variants[name])
+	}
+	for _, name := range slices.Sorted(maps.Keys(endpoint.States)) {
+		all = append(all, endpoint.States[name].Reply)
+	}
+	return append(all, endpoint.Sequence...)
+}
+
+func jsonContent(schema *Schema) map[string]*MediaType {
+	return map[string]*MediaType{"application/json": {Schema: schema}}
+}
+
+func floatPtr(f float64) *float64 {
+	return &f
+}
@@ -0,0 +1,294 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/paqstd-team/fake-cli/config"
+)
+
+// testSampler knows a few data types, everything else is a literal.
+func testSampler(value string) (any, string, bool) {
+	samples := map[string]any{
+		"uuid":    "9b2c4f1e-8d3a-4c5b-9e6f-0a1b2c3d4e5f",
+		"email":   "ada@example.com",
+		"int8":    int8(1),
+		"uint16":  uint16(1),
+		"price":   9.99,
+		"bool":    true,
+		"date":    time.Time{},
+		"words":   []string{"a"},
+		"address": struct{}{},
+		"none":    nil,
+	}
+	name := strings.TrimPrefix(value, "$")
+	if sample, ok := samples[name]; ok {
+		return sample, name, false
+	}
+	if strings.HasPrefix(value, "{{") {
+		return "generated", "", false
+	}
+	return strings.TrimPrefix(value, "="), "", true
+}
+
+func TestExport(t *testing.T) {
+	endpoints := []config.Endpoint{
+		{
+			URL: "/users/{id:[0-9]{1,3}}",
+			Response: map[string]any{
+				"id":    "$uuid",
+				"role":  "=admin",
+				"bio":   "{{word}} text",
+				"tags":  []any{"words"},
+				"empty": []any{},
+			},
+			Variants: map[string]config.Reply{"gone": {Status: 404}, "other": {Status: 200, Response: "ignored"}},
+		},
+		{
+			URL:      "/users",
+			Type:     "POST",
+			Status:   201,
+			Response: []any{map[string]any{"id": "uuid"}},
+			Payload: map[string]any{
+				"email": "email",
+				"name":  "",
+				"note":  nil,
+				"tags":  []any{"uuid"},
+				"items": []any{},
+				"age":   float64(3),
+			},
+			Cases:    []config.Case{{Reply: config.Reply{Status: 409}}},
+			States:   map[string]config.State{"done": {Reply: config.Reply{Status: 204, Response: "uuid"}}},
+			Sequence: []config.Reply{{Status: 503, Response: []any{}}},
+		},
+		{URL: "/users", Type: "GET", Response: []any{"email"}},
+		{URL: "/users", Type: "TRACE"},
+		{URL: "/proxy", Type: "GET", Passthrough: true, Variants: map[string]config.Reply{"fail": {Status: 500}}},
+		{URL: "/broken/{id", Type: "DELETE", Payload: map[string]any{"id": "uuid"}},
+		{URL: "/search", Payload: map[string]any{"q": "word"}},
+	}
+	doc := Export(endpoints, testSampler)
+
+	if doc.OpenAPI != Version {
+		t.Errorf("Expected version %s, got %s", Version, doc.OpenAPI)
+	}
+	paths := make([]string, 0, len(doc.Paths))
+	for path := range doc.Paths {
+		paths = append(paths, path)
+	}
+	if len(paths) != 5 || doc.Paths["/users/{id}"] == nil || doc.Paths["/broken/{id"] == nil {
+		t.Fatalf("Expected five paths, got %v", paths)
+	}
+
+	get := doc.Paths["/users/{id}"].Get
+	expectedParameters := []*Parameter{{Name: "id", In: "path", Required: true, Schema: &Schema{Type: Types{"string"}, Pattern: "^[0-9]{1,3}$"}}}
+	if !reflect.DeepEqual(get.Parameters, expectedParameters) {
+		t.Errorf("Expected path parameters, got %s", mustJSON(get.Parameters))
+	}
+	expectedUser := &Schema{
+		Type: Types{"object"},
+		Properties: map[string]*Schema{
+			"id":    {Type: Types{"string"}, Format: "uuid"},
+			"role":  {Type: Types{"string"}, Example: "admin"},
+			"bio":   {Type: Types{"string"}},
+			"tags":  {Type: Types{"array"}, Items: &Schema{Type: Types{"array"}, Items: &Schema{}}},
+			"empty": {Type: Types{"array"}, Items: &Schema{}},
+		},
+		Required: []string{"bio", "empty", "id", "role", "tags"},
+	}
+	if got := get.Responses["200"].Content["application/json"].Schema; !reflect.DeepEqual(got, expectedUser) {
+		t.Errorf("Expected %s, got %s", mustJSON(expectedUser), mustJSON(got))
+	}
+	if got := get.Responses["404"]; got.Description != "Not Found" || got.Content != nil {
+		t.Errorf("Expected empty 404 response, got %s", mustJSON(got))
+	}
+
+	post := doc.Paths["/users"].Post
+	codes := make([]string, 0, len(post.Responses))
+	for code := range post.Responses {
+		codes = append(codes, code)
+	}
+	if len(codes) != 4 || post.Responses["201"] == nil || post.Responses["409"] == nil || post.Responses["204"] == nil || post.Responses["503"] == nil {
+		t.Errorf("Expected responses 201, 204, 409 and 503, got %v", codes)
+	}
+	if post.Responses["204"].Content != nil {
+		t.Error("Expected no content for 204")
+	}
+	if len(post.Parameters) != 2 || post.Parameters[0].Name != "page" || post.Parameters[1].Name != "per_page" {
+		t.Errorf("Expected pagination parameters, got %s", mustJSON(post.Parameters))
+	}
+	expectedPayload := &Schema{
+		Type: Types{"object"},
+		Properties: map[string]*Schema{
+			"email": {Type: Types{"string"}, Format: "email"},
+			"name":  {},
+			"note":  {},
+			"tags":  {Type: Types{"array"}, Items: &Schema{Type: Types{"string"}, Format: "uuid"}},
+			"items": {Type: Types{"array"}, Items: &Schema{Type: Types{"object"}, Properties: map[string]*Schema{}}},
+			"age":   {},
+		},
+		Required: []string{"age", "email", "items", "name", "tags"},
+	}
+	if post.RequestBody == nil || !post.RequestBody.Required {
+		t.Fatalf("Expected a required request body, got %s", mustJSON(post.RequestBody))
+	}
+	if got := post.RequestBody.Content["application/json"].Schema; !reflect.DeepEqual(got, expectedPayload) {
+		t.Errorf("Expected %s, got %s", mustJSON(expectedPayload), mustJSON(got))
+	}
+
+	list := doc.Paths["/users"].Get.Responses["200"].Content["application/json"].Schema
+	if !reflect.DeepEqual(list, &Schema{Type: Types{"array"}, Items: &Schema{Type: Types{"string"}, Format: "email"}}) {
+		t.Errorf("Expected list of emails, got %s", mustJSON(list))
+	}
+
+	proxy := doc.Paths["/proxy"].Get
+	if proxy.Responses["default"] == nil || proxy.Responses["500"] == nil || proxy.Responses["200"] != nil {
+		t.Errorf("Expected default and 500 responses, got %s", mustJSON(proxy.Responses))
+	}
+
+	if doc.Paths["/broken/{id"].Delete.RequestBody == nil {
+		t.Error("Expected a request body for DELETE")
+	}
+	if doc.Paths["/search"].Get.RequestBody != nil {
+		t.Error("Expected no request body for GET")
+	}
+}
+
+func TestExport_Parameters(t *testing.T) {
+	perPage := &Schema{Type: Types{"integer"}, Maximum: floatPtr(50)}
+	version := &Schema{Enum: []any{"1", "2"}}
+	endpoints := []config.Endpoint{{
+		URL:      "/items",
+		Response: []any{"uuid"},
+		Query: &Schema{
+			Required:   []string{"per_page"},
+			Properties: map[string]*Schema{"per_page": perPage, "q": {Type: Types{"string"}}},
+		},
+		HeaderSchema: &Schema{Required: []string{"X-Api-Version"}, Properties: map[string]*Schema{"X-Api-Version": version}},
+	}}
+	get := Export(endpoints, testSampler).Paths["/items"].Get
+
+	expected := []*Parameter{
+		{Name: "per_page", In: "query", Required: true, Schema: perPage},
+		{Name: "q", In: "query", Schema: &Schema{Type: Types{"string"}}},
+		{Name: "X-Api-Version", In: "header", Required: true, Schema: version},
+		{Name: "page", In: "query", Schema: &Schema{Type: Types{"integer"}, Minimum: floatPtr(1)}},
+	}
+	if !reflect.DeepEqual(get.Parameters, expected) {
+		t.Errorf("Expected %s, got %s", mustJSON(expected), mustJSON(get.Parameters))
+	}
+}
+
+func TestExport_Files(t *testing.T) {
+	two := 2
+	endpoints := []config.Endpoint{{
+		URL:     "/upload",
+		Type:    "POST",
+		Payload: map[string]any{"title": "word", "note": nil},
+		Files: map[string]config.Upload{
+			"avatar":      {MinCount: 1, MaxCount: 1},
+			"attachments": {MaxCount: 2},
+			"scans":       {MinCount: 2},
+		},
+	}, {
+		URL:   "/files",
+		Type:  "PUT",
+		Files: map[string]config.Upload{"file": {MaxCount: 1}},
+	}}
+	doc := Export(endpoints, testSampler)
+
+	file := &Schema{Type: Types{"string"}, Format: "binary"}
+	expected := &Schema{
+		Type: Types{"object"},
+		Properties: map[string]*Schema{
+			"title":       {},
+			"note":        {},
+			"avatar":      file,
+			"attachments": {Type: Types{"array"}, Items: file, MaxItems: &two},
+			"scans":       {Type: Types{"array"}, Items: file, MinItems: &two},
+		},
+		Required: []string{"title", "avatar", "scans"},
+	}
+	body := doc.Paths["/upload"].Post.RequestBody
+	if got := body.Content["multipart/form-data"].Schema; !body.Required || !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %s, got %s", mustJSON(expected), mustJSON(got))
+	}
+
+	expected = &Schema{Type: Types{"object"}, Properties: map[string]*Schema{"file": file}}
+	if got := doc.Paths["/files"].Put.RequestBody.Content["multipart/form-data"].Schema; !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %s, got %s", mustJSON(expected), mustJSON(got))
+	}
+}
+
+func TestExport_Stream(t *testing.T) {
+	endpoints := []config.Endpoint{{
+		URL:      "/events",
+		Response: []any{"uuid"},
+		Stream:   &config.Stream{Event: "tick"},
+		Cases:    []config.Case{{Reply: config.Reply{Status: 401, Response: map[string]any{"error": "word"}}}},
+	}}
+	get := Export(endpoints, testSampler).Paths["/events"].Get
+
+	if content := get.Responses["200"].Content; content["text/event-stream"] == nil || content["application/json"] != nil {
+		t.Errorf("Expected an event stream, got %s", mustJSON(content))
+	}
+	if content := get.Responses["401"].Content; content["application/json"] == nil {
+		t.Errorf("Expected JSON for other statuses, got %s", mustJSON(content))
+	}
+	if len(get.Parameters) != 0 {
+		t.Errorf("Expected no pagination parameters, got %s", mustJSON(get.Parameters))
+	}
+}
+
+func TestExport_Schemas(t *testing.T) {
+	tests := []struct {
+		name     string
+		template any
+		expected *Schema
+	}{
+		{name: "integer_type", template: "int8", expected: &Schema{Type: Types{"integer"}}},
+		{name: "unsigned_type", template: "uint16", expected: &Schema{Type: Types{"integer"}}},
+		{name: "number_type", template: "price", expected: &Schema{Type: Types{"number"}}},
+		{name: "bool_type", template: "bool", expected: &Schema{Type: Types{"boolean"}}},
+		{name: "date_type", template: "date", expected: &Schema{Type: Types{"string"}, Format: "date-time"}},
+		{name: "list_type", template: "words", expected: &Schema{Type: Types{"array"}, Items: &Schema{}}},
+		{name: "object_type", template: "address", expected: &Schema{Type: Types{"object"}}},
+		{name: "null_type", template: "none", expected: &Schema{Nullable: true}},
+		{name: "number", template: 3.5, expected: &Schema{Type: Types{"number"}, Example: 3.5}},
+		{name: "bool", template: false, expected: &Schema{Type: Types{"boolean"}, Example: false}},
+		{name: "null_items", template: []any{nil}, expected: &Schema{Type: Types{"array"}, Items: &Schema{Nullable: true}}},
+		{name: "literal", template: map[string]any{"$literal": "word"}, expected: &Schema{Type: Types{"string"}, Example: "word"}},
+		{name: "regex", template: map[string]any{"$regex": "^[a-z]$"}, expected: &Schema{Type: Types{"string"}, Pattern: "^[a-z]$"}},
+		{name: "format", template: map[string]any{"$format": `A-#?*\#.`}, expected: &Schema{Type: Types{"string"}, Pattern: `^A-[0-9][A-Z][0-9a-f]#\.$`}},
+		{name: "integer_range", template: map[string]any{"$range": []any{1.0, 5.0}}, expected: &Schema{Type: Types{"integer"}, Minimum: floatPtr(1), Maximum: floatPtr(5)}},
+		{name: "number_range", template: map[string]any{"$range": []any{0.5, 5.0}}, expected: &Schema{Type: Types{"number"}, Minimum: floatPtr(0.5), Maximum: floatPtr(5)}},
+		{
+			name:     "invalid_range",
+			template: map[string]any{"$range": []any{"a", 5.0}},
+			expected: &Schema{Type: Types{"object"}, Properties: map[string]*Schema{"$range": {Type: Types{"array"}, Items: &Schema{Type: Types{"string"}, Example: "a"}}}, Required: []string{"$range"}},
+		},
+		{
+			name:     "short_range",
+			template: map[string]any{"$range": []any{}},
+			expected: &Schema{Type: Types{"object"}, Properties: map[string]*Schema{"$range": {Type: Types{"array"}, Items: &Schema{}}}, Required: []string{"$range"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := Export([]config.Endpoint{{URL: "/a", Type: "GET", Response: tt.template}}, testSampler)
+			got := doc.Paths["/a"].Get.Responses["200"].Content["application/json"].Schema
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Expected %s, got %s", mustJSON(tt.expected), mustJSON(got))
+			}
+		})
+	}
+}
+
+func mustJSON(v any) string {
+	data, _ := json.Marshal(v)
+	return string(data)
+}
@@ -0,0 +1,545 @@
+package openapi
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"net/http"
+	"regexp"
+	"regexp/syntax"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/paqstd-team/fake-cli/config"
+	"github.com/paqstd-team/fake-cli/jsonschema"
+	"github.com/paqstd-team/fake-cli/record"
+)
+
+// Patterns for string formats that have no data type of their own.
+var formatPatterns = map[string]string{
+	"date": `^20[0-2][0-9]-(0[1-9]|1[0-2])-(0[1-9]|1[0-9]|2[0-8])$`,
+	"time": `^([01][0-9]|2[0-3]):[0-5][0-9]:[0-5][0-9]$`,
+}
+
+// Data types for string formats.
+var formatTypes = map[string]string{
+	"uuid":          "uuid",
+	"email":         "email",
+	"uri":           "url",
+	"url":           "url",
+	"uri-reference": "url",
+	"hostname":      "domain_name",
+	"ipv4":          "ipv4",
+	"ipv6":          "ipv6",
+	"date-time":     "date",
+	"password":      "password",
+	"phone":         "phone",
+	"int32":         "int32",
+	"int64":         "int64",
+	"float":         "float32",
+	"double":        "float64",
+}
+
+// Endpoints returns an endpoint for every operation in the document. Paths
+// without variables come first so that /users/me is matched before /users/{id}.
+func Endpoints(doc *Document) ([]config.Endpoint, error) {
+	paths := make([]string, 0, len(doc.Paths))
+	for path := range doc.Paths {
+		paths = append(paths, path)
+	}
+	slices.SortFunc(paths, func(a, b string) int {
+		if n := strings.Count(a, "{") - strings.Count(b, "{"); n != 0 {
+			return n
+		}
+		return strings.Compare(a, b)
+	})
+
+	c := &converter{doc: doc, visiting: make(map[string]bool)}
+	endpoints := []config.Endpoint{}
+	for _, path := range paths {
+		for _, op := range doc.Paths[path].Operations() {
+			endpoint, err := c.endpoint(path, op.Method, op.Operation, doc.Paths[path].Parameters)
+			if err != nil {
+				return nil, fmt.Errorf("%s %s: %w", op.Method, path, err)
+			}
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+	return endpoints, nil
+}
+
+// converter turns schemas into templates, resolving references.
+type converter struct {
+	doc *Document
+	// visiting holds the references being converted, to stop at recursive schemas
+	visiting map[string]bool
+}
+
+func (c *converter) endpoint(path, method string, op *Operation, shared []*Parameter) (config.Endpoint, error) {
+	endpoint := config.Endpoint{URL: path, Type: method}
+
+	var err error
+	if endpoint.Query, endpoint.HeaderSchema, err = c.parameters(slices.Concat(shared, op.Parameters)); err != nil {
+		return endpoint, err
+	}
+
+	status, response, err := c.successResponse(op.Responses)
+	if err != nil {
+		return endpoint, err
+	}
+	if status != http.StatusOK {
+		endpoint.Status = status
+	}
+	if schema := jsonSchema(response.Content); schema != nil && status != http.StatusNoContent {
+		if endpoint.Response, err = c.template("", schema); err != nil {
+			return endpoint, err
+		}
+	}
+
+	if op.RequestBody != nil {
+		body, err := c.requestBody(op.RequestBody)
+		if err != nil {
+			return endpoint, err
+		}
+		if schema := jsonSchema(body.Content); schema != nil {
+			if endpoint.PayloadSchema, err = c.payloadSchema(schema); err != nil {
+				return endpoint, err
+			}
+		}
+	}
+	return endpoint, nil
+}
+
+// parameters turns query and header parameters into the schemas of objects
+// holding them. Operation parameters come after, and override, those of the path.
+func (c *converter) parameters(parameters []*Parameter) (query, headers *Schema, err error) {
+	schemas := map[string]*Schema{}
+	for _, parameter := range parameters {
+		if parameter.In != "query" && parameter.In != "header" {
+			continue
+		}
+		property := &Schema{}
+		if parameter.Schema != nil {
+			// No schema is being converted yet, so references always resolve
+			resolved, done, err := c.resolve(parameter.Schema)
+			if err != nil {
+				return nil, nil, fmt.Errorf("parameter %s: %w", parameter.Name, err)
+			}
+			done()
+			property = resolved
+		}
+		schema := schemas[parameter.In]
+		if schema == nil {
+			schema = &Schema{Type: Types{"object"}, Properties: map[string]*Schema{}}
+			schemas[parameter.In] = schema
+		}
+		schema.Properties[parameter.Name] = property
+		// Operation parameters redefine the requirement too
+		schema.Required = slices.DeleteFunc(schema.Required, func(name string) bool { return name == parameter.Name })
+		if len(schema.Required) == 0 {
+			schema.Required = nil
+		}
+		if parameter.Required {
+			schema.Required = append(schema.Required, parameter.Name)
+		}
+	}
+	return schemas["query"], schemas["header"], nil
+}
+
+// successResponse picks the lowest 2xx response, or the default one.
+func (c *converter) successResponse(responses map[string]*Response) (int, *Response, error) {
+	codes := make([]string, 0, len(responses))
+	for code := range responses {
+		if strings.HasPrefix(code, "2") {
+			codes = append(codes, code)
+		}
+	}
+	slices.Sort(codes)
+
+	status, response := http.StatusOK, responses["default"]
+	if len(codes) > 0 {
+		response = responses[codes[0]]
+		// Ranges such as "2XX" fall back to 200
+		if code, err := strconv.Atoi(codes[0]); err == nil {
+			status = code
+		}
+	}
+	if response == nil {
+		return status, &Response{}, nil
+	}
+	if response.Ref == "" {
+		return status, response, nil
+	}
+
+	name, err := refName(response.Ref, "responses")
+	if err != nil {
+		return 0, nil, err
+	}
+	if c.doc.Components == nil || c.doc.Components.Responses[name] == nil {
+		return 0, nil, fmt.Errorf("unknown reference %q", response.Ref)
+	}
+	return status, c.doc.Components.Responses[name], nil
+}
+
+func (c *converter) requestBody(body *RequestBody) (*RequestBody, error) {
+	if body.Ref == "" {
+		return body, nil
+	}
+	name, err := refName(body.Ref, "requestBodies")
+	if err != nil {
+		return nil, err
+	}
+	if c.doc.Components == nil || c.doc.Components.RequestBodies[name] == nil {
+		return nil, fmt.Errorf("unknown reference %q", body.Ref)
+	}
+	return c.doc.Components.RequestBodies[name], nil
+}
+
+// jsonSchema returns the schema of the JSON media type, if there is one.
+func jsonSchema(content map[string]*MediaType) *Schema {
+	if media := content["application/json"]; media != nil {
+		return media.Schema
+	}
+	types := make([]string, 0, len(content))
+	for name := range content {
+		types = append(types, name)
+	}
+	slices.Sort(types)
+	for _, name := range types {
+		if strings.Contains(name, "json") && content[name] != nil {
+			return content[name].Schema
+		}
+	}
+	return nil
+}
+
+// refName returns the component name of a local reference such as
+// "#/components/schemas/User".
+func refName(ref, kind string) (string, error) {
+	prefix := "#/components/" + kind + "/"
+	if !strings.HasPrefix(ref, prefix) {
+		return "", fmt.Errorf("unsupported reference %q, only %s... is supported", ref, prefix)
+	}
+	return strings.TrimPrefix(ref, prefix), nil
+}
+
+// resolve follows a schema reference. It returns nil for a schema that is
+// already being converted, which ends recursive structures with null.
+func (c *converter) resolve(schema *Schema) (*Schema, func(), error) {
+	done := func() {}
+	if schema.Ref == "" {
+		return schema, done, nil
+	}
+	name, err := refName(schema.Ref, "schemas")
+	if err != nil {
+		return nil, done, err
+	}
+	if c.doc.Components == nil || c.doc.Components.Schemas[name] == nil {
+		return nil, done, fmt.Errorf("unknown reference %q", schema.Ref)
+	}
+	if c.visiting[name] {
+		return nil, done, nil
+	}
+	c.visiting[name] = true
+	return c.doc.Components.Schemas[name], func() { delete(c.visiting, name) }, nil
+}
+
+// alternative returns the first schema of oneOf or anyOf, nil if there is none.
+func alternative(schema *Schema) *Schema {
+	if len(schema.OneOf) > 0 {
+		return schema.OneOf[0]
+	}
+	if len(schema.AnyOf) > 0 {
+		return schema.AnyOf[0]
+	}
+	return nil
+}
+
+// merge combines the object schemas of allOf into one.
+func (c *converter) merge(schema *Schema) (*Schema, error) {
+	if len(schema.AllOf) == 0 {
+		return schema, nil
+	}
+
+	merged := *schema
+	merged.AllOf = nil
+	merged.Properties = make(map[string]*Schema)
+	for name, property := range schema.Properties {
+		merged.Properties[name] = property
+	}
+	for _, part := range schema.AllOf {
+		part, done, err := c.resolve(part)
+		if err != nil {
+			return nil, err
+		}
+		if part == nil {
+			continue
+		}
+		part, err = c.merge(part)
+		done()
+		if err != nil {
+			return nil, err
+		}
+		for name, property := range part.Properties {
+			merged.Properties[name] = property
+		}
+		merged.Required = append(merged.Required, part.Required...)
+		if merged.Type == nil {
+			merged.Type = part.Type
+		}
+	}
+	return &merged, nil
+}
+
+// schemaType returns the type of a schema, inferring it from its keywords
+// when it is not given.
+func schemaType(schema *Schema) string {
+	if t := schema.Type.Primary(); t != "" {
+		return t
+	}
+	switch {
+	case schema.Properties != nil:
+		return "object"
+	case schema.Items != nil:
+		return "array"
+	case len(schema.Type) > 0:
+		return "null"
+	}
+	return "string"
+}
+
+// template converts a schema into a response template. name is the property
+// name, used to guess a data type for plain strings and numbers.
+func (c *converter) template(name string, schema *Schema) (any, error) {
+	schema, done, err := c.resolve(schema)
+	defer done()
+	if err != nil || schema == nil {
+		return nil, err
+	}
+	if alt := alternative(schema); alt != nil {
+		return c.template(name, alt)
+	}
+	if schema, err = c.merge(schema); err != nil {
+		return nil, err
+	}
+
+	if len(schema.Enum) > 0 {
+		return enumTemplate(schema.Enum), nil
+	}
+
+	switch schemaType(schema) {
+	case "object":
+		template := make(map[string]any, len(schema.Properties))
+		for property, propertySchema := range schema.Properties {
+			value, err := c.template(property, propertySchema)
+			if err != nil {
+				return nil, err
+			}
+			template[property] = value
+		}
+		return template, nil
+	case "array":
+		if schema.Items == nil {
+			return []any{}, nil
+		}
+		item, err := c.template(name, schema.Items)
+		if err != nil {
+			return nil, err
+		}
+		return []any{item}, nil
+	case "integer":
+		return numberTemplate(name, schema, true), nil
+	case "number":
+		return numberTemplate(name, schema, false), nil
+	case "boolean":
+		return "$bool", nil
+	case "null":
+		return nil, nil
+	default:
+		return stringTemplate(name, schema), nil
+	}
+}
+
+// enumTemplate picks one of the string values, or uses the first value.
+func enumTemplate(values []any) any {
+	alternatives := make([]string, 0, len(values))
+	for _, value := range values {
+		s, ok := value.(string)
+		if !ok {
+			return literal(values[0])
+		}
+		alternatives = append(alternatives, regexp.QuoteMeta(s))
+	}
+	return map[string]any{"$regex": "^(" + strings.Join(alternatives, "|") + ")$"}
+}
+
+func literal(value any) any {
+	if s, ok := value.(string); ok {
+		return map[string]any{"$literal": s}
+	}
+	return value
+}
+
+// maxRepeat is the largest repeat count Go regular expressions accept.
+const maxRepeat = 1000
+
+func stringTemplate(name string, schema *Schema) any {
+	// Patterns Go cannot parse, such as ECMA-only ones, fall back to the
+	// other properties of the schema
+	if _, err := syntax.Parse(schema.Pattern, syntax.Perl); schema.Pattern != "" && err == nil {
+		return map[string]any{"$regex": schema.Pattern}
+	}
+	if pattern, ok := formatPatterns[schema.Format]; ok {
+		return map[string]any{"$regex": pattern}
+	}
+	if dataType, ok := formatTypes[schema.Format]; ok {
+		return "$" + dataType
+	}
+	if dataType, ok := record.KeyType(name, false); ok && schema.MinLength == nil && schema.MaxLength == nil {
+		return "$" + dataType
+	}
+	if schema.MinLength != nil || schema.MaxLength != nil {
+		low, high := 1, 16
+		if schema.MinLength != nil {
+			low = *schema.MinLength
+		}
+		if schema.MaxLength != nil {
+			high = *schema.MaxLength
+		}
+		low = min(low, maxRepeat)
+		high = max(low, min(high, maxRepeat))
+		return map[string]any{"$regex": fmt.Sprintf("^[a-z]{%d,%d}$", low, high)}
+	}
+	return "$word"
+}
+
+// numberTemplate returns the template of a number, or of an integer when
+// integer is set. Ranges say which one they generate, and integer ones are
+// narrowed to the whole numbers a range can hold.
+func numberTemplate(name string, schema *Schema, integer bool) any {
+	if schema.Minimum != nil || schema.Maximum != nil {
+		low, high := 0.0, 1000.0
+		switch {
+		case schema.Minimum != nil && schema.Maximum != nil:
+			low, high = *schema.Minimum, *schema.Maximum
+		case schema.Minimum != nil:
+			low, high = *schema.Minimum, *schema.Minimum+1000
+		case *schema.Maximum >= 0:
+			high = *schema.Maximum
+		default:
+			low, high = *schema.Maximum-1000, *schema.Maximum
+		}
+		if !integer {
+			return map[string]any{"$range": []any{low, high, "number"}}
+		}
+		low = max(math.Ceil(low), -jsonschema.MaxRangeInteger)
+		high = min(math.Floor(high), jsonschema.MaxRangeInteger)
+		if low <= high {
+			return map[string]any{"$range": []any{low, high, "integer"}}
+		}
+	}
+	if dataType, ok := formatTypes[schema.Format]; ok {
+		return "$" + dataType
+	}
+	if integer {
+		return "$number"
+	}
+	if dataType, ok := record.KeyType(name, true); ok {
+		return "$" + dataType
+	}
+	return "$price"
+}
+
+// payloadSchema returns a request body schema as a payload schema, with the
+// components it refers to as its $defs. Patterns Go cannot compile are left
+// out, and so is a schema that still cannot validate, such as one that
+// refers to itself without descending into a property or item.
+func (c *converter) payloadSchema(schema *Schema) (*Schema, error) {
+	defs := map[string]*Schema{}
+	payload, err := c.localize(schema, defs)
+	if err != nil {
+		return nil, err
+	}
+	if len(defs) > 0 {
+		merged := make(map[string]*Schema, len(payload.Defs)+len(defs))
+		maps.Copy(merged, payload.Defs)
+		maps.Copy(merged, defs)
+		payload.Defs = merged
+	}
+	if payload.Check() != nil {
+		return nil, nil
+	}
+	return payload, nil
+}
+
+// localize copies schema with its references to components turned into
+// references to $defs, and adds the components it refers to to defs.
+func (c *converter) localize(schema *Schema, defs map[string]*Schema) (*Schema, error) {
+	if schema == nil {
+		return nil, nil
+	}
+	copied := *schema
+	if _, err := regexp.Compile(schema.Pattern); err != nil {
+		copied.Pattern = ""
+	}
+	if schema.Ref != "" {
+		name, err := refName(schema.Ref, "schemas")
+		if err != nil {
+			return nil, err
+		}
+		if c.doc.Components == nil || c.doc.Components.Schemas[name] == nil {
+			return nil, fmt.Errorf("unknown reference %q", schema.Ref)
+		}
+		copied.Ref = "#/$defs/" + name
+		if _, ok := defs[name]; !ok {
+			// Claimed first so that recursive components are copied once
+			defs[name] = nil
+			if defs[name], err = c.localize(c.doc.Components.Schemas[name], defs); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	var err error
+	localize := func(schema *Schema) *Schema {
+		if err != nil {
+			return nil
+		}
+		var localized *Schema
+		localized, err = c.localize(schema, defs)
+		return localized
+	}
+	localizeAll := func(schemas map[string]*Schema) map[string]*Schema {
+		if schemas == nil {
+			return nil
+		}
+		localized := make(map[string]*Schema, len(schemas))
+		for name, schema := range schemas {
+			localized[name] = localize(schema)
+		}
+		return localized
+	}
+	localizeList := func(schemas []*Schema) []*Schema {
+		if schemas == nil {
+			return nil
+		}
+		localized := make([]*Schema, len(schemas))
+		for i, schema := range schemas {
+			localized[i] = localize(schema)
+		}
+		return localized
+	}
+	copied.Defs = localizeAll(schema.Defs)
+	copied.Properties = localizeAll(schema.Properties)
+	copied.Items = localize(schema.Items)
+	copied.AdditionalProperties = localize(schema.AdditionalProperties)
+	copied.Not = localize(schema.Not)
+	copied.AllOf = localizeList(schema.AllOf)
+	copied.OneOf = localizeList(schema.OneOf)
+	copied.AnyOf = localizeList(schema.AnyOf)
+	if err != nil {
+		return nil, err
+	}
+	return &copied, nil
+}
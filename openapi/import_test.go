@@ -0,0 +1,415 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/paqstd-team/fake-cli/config"
+	"github.com/paqstd-team/fake-cli/jsonschema"
+)
+
+const storeJSON = `{
+	"openapi": "3.1.0",
+	"info": {"title": "Store", "version": "1"},
+	"paths": {
+		"/users/{id}": {
+			"get": {"responses": {"200": {"$ref": "#/components/responses/User"}}},
+			"delete": {"responses": {"204": {"description": "Deleted"}}}
+		},
+		"/users/me": {
+			"get": {"responses": {"2XX": {"$ref": "#/components/responses/User"}}}
+		},
+		"/users": {
+			"get": {"responses": {
+				"default": {"description": "Error"},
+				"200": {"description": "OK", "content": {"application/json": {"schema": {
+					"type": "array", "items": {"$ref": "#/components/schemas/User"}
+				}}}}
+			}},
+			"post": {
+				"requestBody": {"$ref": "#/components/requestBodies/NewUser"},
+				"responses": {
+					"201": {"description": "Created", "content": {"application/vnd.api+json": {"schema": {"$ref": "#/components/schemas/User"}}}},
+					"400": {"description": "Invalid"}
+				}
+			}
+		},
+		"/health": {
+			"head": {"responses": {"default": {"description": "OK", "content": {"text/plain": {"schema": {"type": "string"}}}}}},
+			"options": {"responses": {}}
+		}
+	},
+	"components": {
+		"schemas": {
+			"Loop": {"allOf": [{"$ref": "#/components/schemas/Loop"}], "properties": {"ok": {"type": "boolean"}}},
+			"Base": {"type": "object", "required": ["id"], "properties": {"id": {"type": "string", "format": "uuid"}}},
+			"User": {
+				"allOf": [{"$ref": "#/components/schemas/Base"}],
+				"required": ["email"],
+				"properties": {
+					"email": {"type": "string", "format": "email"},
+					"first_name": {"type": "string"},
+					"code": {"type": "string", "pattern": "^[A-Z]{3}$"},
+					"born": {"type": "string", "format": "date"},
+					"nick": {"type": "string", "minLength": 3, "maxLength": 8},
+					"tag": {"type": "string", "maxLength": 4},
+					"long": {"type": "string", "minLength": 20},
+					"essay": {"type": "string", "minLength": 2000, "maxLength": 5000},
+					"summary": {"type": "string", "maxLength": 5000},
+					"slug": {"type": "string", "pattern": "^(?=.*[0-9])[a-z0-9]+$", "maxLength": 10},
+					"bio": {"type": "string"},
+					"role": {"type": "string", "enum": ["admin", "a.b"]},
+					"level": {"type": "integer", "enum": [1, 2]},
+					"kind": {"enum": ["x", 2]},
+					"age": {"type": "integer", "minimum": 18, "maximum": 99},
+					"rank": {"type": "integer", "minimum": 5},
+					"score": {"type": "number", "maximum": 10},
+					"debt": {"type": "number", "maximum": -5},
+					"tiny": {"type": "integer", "minimum": 1.2, "maximum": 1.8},
+					"huge": {"type": "integer", "minimum": -1e300, "maximum": 1e300},
+					"visits": {"type": "integer", "format": "int64"},
+					"count": {"type": "integer"},
+					"price": {"type": "number"},
+					"ratio": {"type": "number", "format": "float"},
+					"weight": {"type": "number"},
+					"active": {"type": "boolean"},
+					"deleted": {"type": ["null"]},
+					"tags": {"type": "array", "items": {"type": "string", "format": "hostname"}},
+					"any": {"type": "array"},
+					"address": {"properties": {"city": {"type": "string"}}},
+					"contact": {"oneOf": [{"type": "string", "format": "email"}, {"type": "string"}]},
+					"alias": {"anyOf": [{"type": "string", "format": "ipv4"}]},
+					"friend": {"$ref": "#/components/schemas/User"},
+					"loop": {"$ref": "#/components/schemas/Loop"},
+					"untyped": {}
+				}
+			}
+		},
+		"responses": {
+			"User": {"description": "A user", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/User"}}}}
+		},
+		"requestBodies": {
+			"NewUser": {"content": {"application/json": {"schema": {
+				"type": "object",
+				"required": ["email", "age", "score", "active", "role", "tags", "profile", "contact", "kind", "any"],
+				"properties": {
+					"email": {"type": "string", "format": "email"},
+					"age": {"type": "integer", "minimum": 18},
+					"score": {"type": "number", "maximum": 10},
+					"active": {"type": "boolean"},
+					"role": {"type": "string", "enum": ["admin"]},
+					"kind": {"type": ["null"]},
+					"tags": {"type": "array", "items": {"type": "string"}},
+					"any": {"type": "array"},
+					"profile": {"$ref": "#/components/schemas/Base"},
+					"contact": {"anyOf": [{"type": "string", "format": "uuid"}]},
+					"nickname": {"type": "string"}
+				}
+			}}}}
+		}
+	}
+}`
+
+func TestEndpoints(t *testing.T) {
+	doc, err := Parse([]byte(storeJSON))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	endpoints, err := Endpoints(doc)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	user := map[string]any{
+		"id":         "$uuid",
+		"email":      "$email",
+		"first_name": "$first_name",
+		"code":       map[string]any{"$regex": "^[A-Z]{3}$"},
+		"born":       map[string]any{"$regex": formatPatterns["date"]},
+		"nick":       map[string]any{"$regex": "^[a-z]{3,8}$"},
+		"tag":        map[string]any{"$regex": "^[a-z]{1,4}$"},
+		"long":       map[string]any{"$regex": "^[a-z]{20,20}$"},
+		"essay":      map[string]any{"$regex": "^[a-z]{1000,1000}$"},
+		"summary":    map[string]any{"$regex": "^[a-z]{1,1000}$"},
+		"slug":       map[string]any{"$regex": "^[a-z]{1,10}$"},
+		"bio":        "$word",
+		"role":       map[string]any{"$regex": `^(admin|a\.b)$`},
+		"level":      float64(1),
+		"kind":       map[string]any{"$literal": "x"},
+		"age":        map[string]any{"$range": []any{18.0, 99.0, "integer"}},
+		"rank":       map[string]any{"$range": []any{5.0, 1005.0, "integer"}},
+		"score":      map[string]any{"$range": []any{0.0, 10.0, "number"}},
+		"debt":       map[string]any{"$range": []any{-1005.0, -5.0, "number"}},
+		"tiny":       "$number",
+		"huge":       map[string]any{"$range": []any{-float64(jsonschema.MaxRangeInteger), float64(jsonschema.MaxRangeInteger), "integer"}},
+		"visits":     "$int64",
+		"count":      "$number",
+		"price":      "$price",
+		"ratio":      "$float32",
+		"weight":     "$price",
+		"active":     "$bool",
+		"deleted":    nil,
+		"tags":       []any{"$domain_name"},
+		"any":        []any{},
+		"address":    map[string]any{"city": "$city"},
+		"contact":    "$email",
+		"alias":      "$ipv4",
+		"friend":     nil,
+		"loop":       map[string]any{"ok": "$bool"},
+		"untyped":    "$word",
+	}
+	expected := []config.Endpoint{
+		{URL: "/health", Type: "HEAD"},
+		{URL: "/health", Type: "OPTIONS"},
+		{URL: "/users", Type: "GET", Response: []any{user}},
+		{URL: "/users", Type: "POST", Status: 201, Response: user},
+		{URL: "/users/me", Type: "GET", Response: user},
+		{URL: "/users/{id}", Type: "GET", Response: user},
+		{URL: "/users/{id}", Type: "DELETE", Status: 204},
+	}
+
+	if len(endpoints) != len(expected) {
+		t.Fatalf("Expected %d endpoints, got %d: %+v", len(expected), len(endpoints), endpoints)
+	}
+	// The request body becomes a payload schema, see TestEndpoints_PayloadSchema
+	payload := endpoints[3].PayloadSchema
+	if payload == nil || payload.Properties["profile"].Ref != "#/$defs/Base" || payload.Defs["Base"] == nil {
+		t.Errorf("Expected the payload schema to refer to its own $defs, got %+v", payload)
+	}
+	endpoints[3].PayloadSchema = nil
+	for i := range expected {
+		if !reflect.DeepEqual(endpoints[i], expected[i]) {
+			t.Errorf("Endpoint %d: expected %+v, got %+v", i, expected[i], endpoints[i])
+		}
+	}
+}
+
+func TestEndpoints_PayloadSchema(t *testing.T) {
+	doc, err := Parse([]byte(`{"openapi": "3.0.0", "paths": {
+		"/users": {"post": {"requestBody": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/NewUser"}}}}, "responses": {}}},
+		"/loops": {"post": {"requestBody": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/Loop"}}}}, "responses": {}}}
+	}, "components": {"schemas": {
+		"NewUser": {
+			"type": "object",
+			"required": ["email", "age", "role"],
+			"properties": {
+				"email": {"type": "string", "format": "email"},
+				"age": {"type": "integer", "minimum": 18, "maximum": 99},
+				"role": {"type": "string", "enum": ["admin", "user"]},
+				"code": {"type": "string", "pattern": "^[A-Z]{3}$"},
+				"slug": {"type": "string", "pattern": "^(?=.*[0-9])[a-z0-9]+$"},
+				"manager": {"$ref": "#/components/schemas/NewUser"},
+				"tags": {"type": "array", "items": {"type": "string"}, "maxItems": 2},
+				"extra": {"additionalProperties": {"type": "integer"}},
+				"nick": {"allOf": [{"type": "string"}], "not": {"enum": ["root"]}, "anyOf": [{"minLength": 2}], "oneOf": [{"maxLength": 8}]}
+			},
+			"$defs": {"unused": {"type": "string"}}
+		},
+		"Loop": {"allOf": [{"$ref": "#/components/schemas/Loop"}]}
+	}}}`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	endpoints, err := Endpoints(doc)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// A schema that cannot validate is left out
+	if endpoints[0].PayloadSchema != nil {
+		t.Errorf("Expected no payload schema for a reference loop, got %+v", endpoints[0].PayloadSchema)
+	}
+
+	schema := endpoints[1].PayloadSchema
+	if schema == nil {
+		t.Fatal("Expected a payload schema")
+	}
+	tests := []struct {
+		name  string
+		body  string
+		valid bool
+	}{
+		{name: "valid", body: `{"email": "a@b.co", "age": 30, "role": "admin", "code": "ABC", "slug": "any", "manager": {"email": "c@d.co", "age": 40, "role": "user"}, "extra": {"n": 1}, "nick": "bob"}`, valid: true},
+		{name: "missing_property", body: `{"email": "a@b.co", "age": 30}`},
+		{name: "out_of_range", body: `{"email": "a@b.co", "age": 12, "role": "admin"}`},
+		{name: "not_in_enum", body: `{"email": "a@b.co", "age": 30, "role": "guest"}`},
+		{name: "pattern", body: `{"email": "a@b.co", "age": 30, "role": "admin", "code": "abc"}`},
+		{name: "format", body: `{"email": "nope", "age": 30, "role": "admin"}`},
+		{name: "referenced", body: `{"email": "a@b.co", "age": 30, "role": "admin", "manager": {"age": 30}}`},
+		{name: "items", body: `{"email": "a@b.co", "age": 30, "role": "admin", "tags": ["a", "b", "c"]}`},
+		{name: "additional_properties", body: `{"email": "a@b.co", "age": 30, "role": "admin", "extra": {"n": "one"}}`},
+		{name: "not", body: `{"email": "a@b.co", "age": 30, "role": "admin", "nick": "root"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body any
+			if err := json.Unmarshal([]byte(tt.body), &body); err != nil {
+				t.Fatalf("Invalid test body: %v", err)
+			}
+			if violations := schema.Validate(body); (len(violations) == 0) != tt.valid {
+				t.Errorf("Expected valid=%v, got violations %v", tt.valid, violations)
+			}
+		})
+	}
+}
+
+func TestEndpoints_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		paths string
+		error string
+	}{
+		{
+			name:  "unknown_response",
+			paths: `{"/a": {"get": {"responses": {"200": {"$ref": "#/components/responses/Missing"}}}}}`,
+			error: `GET /a: unknown reference "#/components/responses/Missing"`,
+		},
+		{
+			name:  "foreign_response",
+			paths: `{"/a": {"get": {"responses": {"200": {"$ref": "other.yaml#/Missing"}}}}}`,
+			error: "unsupported reference",
+		},
+		{
+			name:  "unknown_request_body",
+			paths: `{"/a": {"post": {"requestBody": {"$ref": "#/components/requestBodies/Missing"}, "responses": {}}}}`,
+			error: "unknown reference",
+		},
+		{
+			name:  "foreign_request_body",
+			paths: `{"/a": {"post": {"requestBody": {"$ref": "#/definitions/Missing"}, "responses": {}}}}`,
+			error: "unsupported reference",
+		},
+		{
+			name:  "unknown_schema",
+			paths: `{"/a": {"get": {"responses": {"200": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/Missing"}}}}}}}}`,
+			error: "unknown reference",
+		},
+		{
+			name:  "foreign_schema",
+			paths: `{"/a": {"get": {"responses": {"200": {"content": {"application/json": {"schema": {"$ref": "#/definitions/Missing"}}}}}}}}`,
+			error: "unsupported reference",
+		},
+		{
+			name:  "unknown_nested_schema",
+			paths: `{"/a": {"get": {"responses": {"200": {"content": {"application/json": {"schema": {"properties": {"b": {"$ref": "#/components/schemas/Missing"}}}}}}}}}}`,
+			error: "unknown reference",
+		},
+		{
+			name:  "unknown_item_schema",
+			paths: `{"/a": {"get": {"responses": {"200": {"content": {"application/json": {"schema": {"items": {"$ref": "#/components/schemas/Missing"}}}}}}}}}`,
+			error: "unknown reference",
+		},
+		{
+			name:  "unknown_all_of_schema",
+			paths: `{"/a": {"get": {"responses": {"200": {"content": {"application/json": {"schema": {"allOf": [{"$ref": "#/components/schemas/Missing"}]}}}}}}}}`,
+			error: "unknown reference",
+		},
+		{
+			name:  "unknown_nested_all_of_schema",
+			paths: `{"/a": {"get": {"responses": {"200": {"content": {"application/json": {"schema": {"allOf": [{"allOf": [{"$ref": "#/components/schemas/Missing"}]}]}}}}}}}}`,
+			error: "unknown reference",
+		},
+		{
+			name:  "unknown_payload_schema",
+			paths: `{"/a": {"post": {"requestBody": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/Missing"}}}}, "responses": {}}}}`,
+			error: "unknown reference",
+		},
+		{
+			name:  "unknown_payload_all_of_schema",
+			paths: `{"/a": {"post": {"requestBody": {"content": {"application/json": {"schema": {"allOf": [{"$ref": "#/components/schemas/Missing"}]}}}}, "responses": {}}}}`,
+			error: "unknown reference",
+		},
+		{
+			name:  "unknown_payload_property_schema",
+			paths: `{"/a": {"post": {"requestBody": {"content": {"application/json": {"schema": {"required": ["b"], "properties": {"b": {"$ref": "#/components/schemas/Missing"}}}}}}, "responses": {}}}}`,
+			error: "unknown reference",
+		},
+		{
+			name:  "unknown_payload_item_schema",
+			paths: `{"/a": {"post": {"requestBody": {"content": {"application/json": {"schema": {"items": {"$ref": "#/components/schemas/Missing"}}}}}, "responses": {}}}}`,
+			error: "unknown reference",
+		},
+		{
+			name:  "foreign_payload_schema",
+			paths: `{"/a": {"post": {"requestBody": {"content": {"application/json": {"schema": {"$ref": "#/definitions/Missing"}}}}, "responses": {}}}}`,
+			error: "unsupported reference",
+		},
+		{
+			name:  "unknown_payload_component_schema",
+			paths: `{"/a": {"post": {"requestBody": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/B"}}}}, "responses": {}}}}, "components": {"schemas": {"B": {"items": {"$ref": "#/components/schemas/Missing"}}}}`,
+			error: "unknown reference",
+		},
+		{
+			name:  "unknown_parameter_schema",
+			paths: `{"/a": {"get": {"parameters": [{"name": "q", "in": "query", "schema": {"$ref": "#/components/schemas/Missing"}}], "responses": {}}}}`,
+			error: "GET /a: parameter q: unknown reference",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Parse([]byte(`{"openapi": "3.0.0", "paths": ` + tt.paths + `}`))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if _, err := Endpoints(doc); err == nil || !strings.Contains(err.Error(), tt.error) {
+				t.Errorf("Expected error containing %q, got %v", tt.error, err)
+			}
+		})
+	}
+}
+
+func TestEndpoints_MediaTypes(t *testing.T) {
+	doc, err := Parse([]byte(`{"openapi": "3.0.0", "paths": {
+		"/empty": {"get": {"responses": {"200": {"content": {"application/json": null, "text/json": null}}}}},
+		"/text": {"get": {"responses": {"200": {"content": {"text/html": {"schema": {"type": "string"}}}}}}}
+	}}`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	endpoints, err := Endpoints(doc)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, endpoint := range endpoints {
+		if endpoint.Response != nil {
+			t.Errorf("Expected no response for %s, got %v", endpoint.URL, endpoint.Response)
+		}
+	}
+}
+
+func TestEndpoints_Parameters(t *testing.T) {
+	doc, err := Parse([]byte(`{"openapi": "3.0.0", "paths": {"/items/{id}": {
+		"parameters": [
+			{"name": "id", "in": "path", "required": true},
+			{"name": "limit", "in": "query", "required": true, "schema": {"type": "integer"}},
+			{"name": "X-Api-Version", "in": "header", "schema": {"$ref": "#/components/schemas/Version"}}
+		],
+		"get": {"parameters": [{"name": "limit", "in": "query", "schema": {"type": "integer", "maximum": 50}}, {"name": "q", "in": "query"}], "responses": {}},
+		"delete": {"responses": {}}
+	}}, "components": {"schemas": {"Version": {"enum": ["1", "2"]}}}}`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	endpoints, err := Endpoints(doc)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	headers := &Schema{Type: Types{"object"}, Properties: map[string]*Schema{"X-Api-Version": {Enum: []any{"1", "2"}}}}
+	expected := []config.Endpoint{
+		{
+			URL: "/items/{id}", Type: "GET", HeaderSchema: headers,
+			Query: &Schema{Type: Types{"object"}, Properties: map[string]*Schema{"limit": {Type: Types{"integer"}, Maximum: floatPtr(50)}, "q": {}}},
+		},
+		{
+			URL: "/items/{id}", Type: "DELETE", HeaderSchema: headers,
+			Query: &Schema{Type: Types{"object"}, Required: []string{"limit"}, Properties: map[string]*Schema{"limit": {Type: Types{"integer"}}}},
+		},
+	}
+	if !reflect.DeepEqual(endpoints, expected) {
+		t.Errorf("Expected %s, got %s", mustJSON(expected), mustJSON(endpoints))
+	}
+}
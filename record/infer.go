@@ -0,0 +1,133 @@
+package record
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Strings that are recognized by their shape, checked in order.
+var valueTypes = []struct {
+	pattern  *regexp.Regexp
+	dataType string
+}{
+	{regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`), "uuid"},
+	{regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[a-zA-Z]{2,}$`), "email"},
+	{regexp.MustCompile(`^https?://\S+$`), "url"},
+	{regexp.MustCompile(`^(\d{1,3}\.){3}\d{1,3}$`), "ipv4"},
+	{regexp.MustCompile(`^#[0-9a-fA-F]{6}$`), "hex_color"},
+}
+
+// Strings that are recognized by their key, after lowercasing and removing
+// dashes and underscores.
+var stringKeys = map[string]string{
+	"name":        "name",
+	"fullname":    "name",
+	"firstname":   "first_name",
+	"lastname":    "last_name",
+	"username":    "username",
+	"login":       "username",
+	"phone":       "phone",
+	"phonenumber": "phone",
+	"city":        "city",
+	"country":     "country",
+	"state":       "state",
+	"street":      "street",
+	"address":     "address",
+	"zip":         "zip",
+	"zipcode":     "zip",
+	"postalcode":  "postal_code",
+	"company":     "company",
+	"jobtitle":    "job_title",
+	"title":       "sentence",
+	"description": "paragraph",
+	"comment":     "comment",
+	"color":       "color",
+	"currency":    "currency_code",
+	"ip":          "ip",
+	"useragent":   "user_agent",
+}
+
+// Numbers that are recognized by their key.
+var numberKeys = map[string]string{
+	"price":     "price",
+	"amount":    "price",
+	"total":     "price",
+	"cost":      "price",
+	"latitude":  "latitude",
+	"lat":       "latitude",
+	"longitude": "longitude",
+	"lng":       "longitude",
+	"lon":       "longitude",
+}
+
+// Template turns a recorded JSON value into a response template. Strings are
+// written as literals unless infer finds a data type for them. Without infer,
+// a top-level array is a literal as a whole, since a template array would be
+// served as a paginated list of its first item.
+func Template(value any, infer bool) any {
+	if items, ok := value.([]any); ok && !infer {
+		return map[string]any{"$literal": items}
+	}
+	return template("", value, infer)
+}
+
+func template(key string, value any, infer bool) any {
+	switch v := value.(type) {
+	case map[string]any:
+		result := make(map[string]any, len(v))
+		for k, item := range v {
+			result[k] = template(k, item, infer)
+		}
+		return result
+	case []any:
+		result := make([]any, len(v))
+		for i, item := range v {
+			result[i] = template(key, item, infer)
+		}
+		return result
+	case string:
+		if infer {
+			if dataType, ok := inferString(key, v); ok {
+				return dataType
+			}
+		}
+		return map[string]any{"$literal": v}
+	case float64:
+		if infer {
+			if dataType, ok := KeyType(key, true); ok {
+				return dataType
+			}
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+func inferString(key, value string) (string, bool) {
+	for _, t := range valueTypes {
+		if t.pattern.MatchString(value) {
+			return t.dataType, true
+		}
+	}
+	if _, err := time.Parse(time.RFC3339, value); err == nil {
+		return "date", true
+	}
+	return KeyType(key, false)
+}
+
+// KeyType returns the data type a value probably has judging by its key, such
+// as "first_name" for "firstName". numeric picks the types for numbers.
+func KeyType(key string, numeric bool) (string, bool) {
+	keys := stringKeys
+	if numeric {
+		keys = numberKeys
+	}
+	dataType, ok := keys[normalizeKey(key)]
+	return dataType, ok
+}
+
+func normalizeKey(key string) string {
+	return strings.NewReplacer("_", "", "-", "").Replace(strings.ToLower(key))
+}
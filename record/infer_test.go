@@ -0,0 +1,64 @@
+package record
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTemplate(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    any
+		infer    bool
+		expected any
+	}{
+		{name: "literal_string", value: "Ada", expected: map[string]any{"$literal": "Ada"}},
+		{name: "literal_type_name", value: "email", expected: map[string]any{"$literal": "email"}},
+		{name: "number", value: float64(3), expected: float64(3)},
+		{name: "bool", value: true, expected: true},
+		{name: "null", value: nil, expected: nil},
+		{name: "uuid", value: "9b2c4f1e-8d3a-4c5b-9e6f-0a1b2c3d4e5f", infer: true, expected: "uuid"},
+		{name: "email", value: "ada@example.com", infer: true, expected: "email"},
+		{name: "url", value: "https://example.com/a", infer: true, expected: "url"},
+		{name: "ipv4", value: "10.0.0.1", infer: true, expected: "ipv4"},
+		{name: "hex_color", value: "#ff00aa", infer: true, expected: "hex_color"},
+		{name: "date", value: "2024-05-01T12:00:00Z", infer: true, expected: "date"},
+		{name: "unknown_string", value: "pending", infer: true, expected: map[string]any{"$literal": "pending"}},
+		{
+			name:     "keys",
+			value:    map[string]any{"first_name": "Ada", "Last-Name": "Lovelace", "total": 12.5, "count": float64(2), "status": "active"},
+			infer:    true,
+			expected: map[string]any{"first_name": "first_name", "Last-Name": "last_name", "total": "price", "count": float64(2), "status": map[string]any{"$literal": "active"}},
+		},
+		{
+			name:     "array_items_keep_key",
+			value:    map[string]any{"city": []any{"Paris", "Rome"}},
+			infer:    true,
+			expected: map[string]any{"city": []any{"city", "city"}},
+		},
+		{
+			name:     "nested_literal",
+			value:    map[string]any{"users": []any{map[string]any{"name": "Ada"}}},
+			expected: map[string]any{"users": []any{map[string]any{"name": map[string]any{"$literal": "Ada"}}}},
+		},
+		{
+			name:     "literal_array",
+			value:    []any{map[string]any{"name": "Ada"}, map[string]any{"name": "Grace"}},
+			expected: map[string]any{"$literal": []any{map[string]any{"name": "Ada"}, map[string]any{"name": "Grace"}}},
+		},
+		{
+			name:     "inferred_array",
+			value:    []any{map[string]any{"name": "Ada"}},
+			infer:    true,
+			expected: []any{map[string]any{"name": "name"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Template(tt.value, tt.infer); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
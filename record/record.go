@@ -0,0 +1,104 @@
+// Package record captures upstream responses and turns them into endpoint configs.
+package record
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/paqstd-team/fake-cli/config"
+)
+
+// Recording is a response captured from the upstream.
+type Recording struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	Status int    `json:"status"`
+	// Body is the decoded JSON body, nil when the body was empty.
+	Body any `json:"body"`
+}
+
+// Recorder keeps recordings in the order they were captured.
+type Recorder struct {
+	mutex      sync.Mutex
+	recordings []Recording
+}
+
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+func (r *Recorder) Add(recording Recording) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.recordings = append(r.recordings, recording)
+}
+
+// Recordings returns a copy of the recordings.
+func (r *Recorder) Recordings() []Recording {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return append([]Recording{}, r.recordings...)
+}
+
+func (r *Recorder) Clear() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.recordings = nil
+}
+
+// idSegment matches path segments that look like identifiers.
+var idSegment = regexp.MustCompile(`^(\d+|[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12})$`)
+
+// templatePath replaces identifier segments such as /users/42 with path
+// variables: /users/{id}, then {id2} and so on.
+func templatePath(path string) string {
+	segments := strings.Split(path, "/")
+	count := 0
+	for i, segment := range segments {
+		if !idSegment.MatchString(segment) {
+			continue
+		}
+		count++
+		if count == 1 {
+			segments[i] = "{id}"
+		} else {
+			segments[i] = fmt.Sprintf("{id%d}", count)
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// Endpoints turns recordings into endpoints, one per method and path with the
+// latest recording winning. Without infer every value is kept literally; with
+// infer values are replaced with data types where they look like one, and
+// identifiers in paths become path variables.
+func Endpoints(recordings []Recording, infer bool) []config.Endpoint {
+	endpoints := []config.Endpoint{}
+	index := make(map[string]int)
+	for _, recording := range recordings {
+		path := recording.Path
+		if infer {
+			path = templatePath(path)
+		}
+
+		endpoint := config.Endpoint{URL: path, Type: recording.Method, Response: Template(recording.Body, infer)}
+		if recording.Status != http.StatusOK {
+			endpoint.Status = recording.Status
+		}
+
+		key := recording.Method + " " + path
+		if i, ok := index[key]; ok {
+			endpoints[i] = endpoint
+			continue
+		}
+		index[key] = len(endpoints)
+		endpoints = append(endpoints, endpoint)
+	}
+	return endpoints
+}
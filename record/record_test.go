@@ -0,0 +1,93 @@
+package record
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestRecorder(t *testing.T) {
+	recorder := NewRecorder()
+	recorder.Add(Recording{Method: "GET", Path: "/a"})
+	recorder.Add(Recording{Method: "GET", Path: "/b"})
+
+	recordings := recorder.Recordings()
+	if len(recordings) != 2 || recordings[1].Path != "/b" {
+		t.Fatalf("Expected recordings in order, got %+v", recordings)
+	}
+
+	// The copy is not affected by later changes
+	recorder.Clear()
+	if len(recordings) != 2 || len(recorder.Recordings()) != 0 {
+		t.Errorf("Expected cleared recorder and intact copy, got %d and %d", len(recorder.Recordings()), len(recordings))
+	}
+}
+
+func TestTemplatePath(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{path: "/users", expected: "/users"},
+		{path: "/users/42", expected: "/users/{id}"},
+		{path: "/users/42/orders/7", expected: "/users/{id}/orders/{id2}"},
+		{path: "/orders/9b2c4f1e-8d3a-4c5b-9e6f-0a1b2c3d4e5f", expected: "/orders/{id}"},
+		{path: "/v2/items", expected: "/v2/items"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := templatePath(tt.path); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestEndpoints(t *testing.T) {
+	recordings := []Recording{
+		{Method: "GET", Path: "/users/1", Status: http.StatusOK, Body: map[string]any{"id": float64(1), "name": "Ada"}},
+		{Method: "GET", Path: "/users/2", Status: http.StatusOK, Body: map[string]any{"id": float64(2), "name": "Grace"}},
+		{Method: "DELETE", Path: "/users/2", Status: http.StatusNoContent},
+	}
+
+	tests := []struct {
+		name     string
+		infer    bool
+		expected []map[string]any
+	}{
+		{
+			name:  "literal",
+			infer: false,
+			expected: []map[string]any{
+				{"url": "/users/1", "type": "GET", "status": 0, "response": map[string]any{"id": float64(1), "name": map[string]any{"$literal": "Ada"}}},
+				{"url": "/users/2", "type": "GET", "status": 0, "response": map[string]any{"id": float64(2), "name": map[string]any{"$literal": "Grace"}}},
+				{"url": "/users/2", "type": "DELETE", "status": http.StatusNoContent, "response": nil},
+			},
+		},
+		{
+			name:  "inferred",
+			infer: true,
+			expected: []map[string]any{
+				{"url": "/users/{id}", "type": "GET", "status": 0, "response": map[string]any{"id": float64(2), "name": "name"}},
+				{"url": "/users/{id}", "type": "DELETE", "status": http.StatusNoContent, "response": nil},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			endpoints := Endpoints(recordings, tt.infer)
+			if len(endpoints) != len(tt.expected) {
+				t.Fatalf("Expected %d endpoints, got %d", len(tt.expected), len(endpoints))
+			}
+			for i, expected := range tt.expected {
+				e := endpoints[i]
+				got := map[string]any{"url": e.URL, "type": e.Type, "status": e.Status, "response": e.Response}
+				if !reflect.DeepEqual(got, expected) {
+					t.Errorf("Endpoint %d: expected %v, got %v", i, expected, got)
+				}
+			}
+		})
+	}
+}